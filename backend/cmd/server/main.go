@@ -1,43 +1,225 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/danil/accessibility-analyzer/internal/api"
 	"github.com/danil/accessibility-analyzer/internal/config"
+	"github.com/danil/accessibility-analyzer/internal/redact"
 	"github.com/danil/accessibility-analyzer/internal/service"
 	"github.com/danil/accessibility-analyzer/internal/translator"
+	"github.com/danil/accessibility-analyzer/internal/webhook"
 )
 
 func main() {
 	// Загружаем конфигурацию
 	cfg := config.Load()
 
+	// Персональные данные и секреты убираются из логов, промптов и уведомлений
+	var redactor *redact.Redactor
+	if cfg.RedactEnabled {
+		var err error
+		if redactor, err = redact.New(cfg.RedactPatterns); err != nil {
+			log.Fatalf("Failed to parse REDACT_PATTERNS: %v", err)
+		}
+		log.SetOutput(redactor.Writer(os.Stderr))
+	}
+
 	// Инициализируем хранилище
-	storage := service.NewStorage()
+	storage, err := openStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+
+	// Уведомления о завершении задач на callback_url
+	notifier, err := webhook.NewNotifier(storage, webhook.Options{
+		Secret:       cfg.WebhookSecret,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		Timeout:      cfg.WebhookTimeout,
+		Backoff:      cfg.WebhookBackoff,
+		Redactor:     redactor,
+		AllowedHosts: cfg.WebhookAllowedHosts,
+	})
+	if err != nil {
+		log.Fatalf("Failed to parse WEBHOOK_ALLOWED_HOSTS: %v", err)
+	}
+
+	// Подключаемся к LLM (nil - демо-режим)
+	provider, err := translator.NewProvider(translator.ProviderConfig{
+		Provider:    cfg.LLMProvider,
+		APIKey:      cfg.LLMAPIKey,
+		BaseURL:     cfg.LLMBaseURL,
+		Model:       cfg.LLMModel,
+		MaxTokens:   cfg.LLMMaxTokens,
+		Temperature: cfg.LLMTemperature,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure LLM: %v", err)
+	}
+	// Запись ответов модели в файлы или воспроизведение из них (для тестов и отладки)
+	if provider, err = translator.NewFixtureProvider(provider, cfg.LLMFixtureMode, cfg.LLMFixtureDir); err != nil {
+		log.Fatalf("Failed to configure LLM fixtures: %v", err)
+	}
+	if cfg.LLMFixtureMode != "" {
+		log.Printf("LLM fixture mode: %s (%s)", cfg.LLMFixtureMode, cfg.LLMFixtureDir)
+	}
+	if provider != nil {
+		// Повторы временных ошибок и переход на статические описания, пока провайдер недоступен
+		provider = translator.NewResilientProvider(provider, translator.ResilienceOptions{
+			Timeout:          cfg.LLMTimeout,
+			MaxAttempts:      cfg.LLMMaxAttempts,
+			Backoff:          cfg.LLMBackoff,
+			MaxBackoff:       cfg.LLMMaxBackoff,
+			FailureThreshold: cfg.LLMBreakerThreshold,
+			Cooldown:         cfg.LLMBreakerCooldown,
+			// Общие для всех задач лимиты запросов и токенов в минуту; их расходует каждая попытка
+			Limiter: translator.NewRateLimiter(cfg.LLMRequestsPerMinute, cfg.LLMTokensPerMinute),
+		})
+	}
+
+	prices, err := translator.ParsePriceTable(cfg.LLMPrices)
+	if err != nil {
+		log.Fatalf("Failed to parse LLM_PRICES: %v", err)
+	}
+
+	// Шаблоны промптов проверяются при запуске, чтобы ошибка в них не проявилась на первом анализе
+	prompts, err := translator.LoadPrompts(cfg.LLMPromptsDir)
+	if err != nil {
+		log.Fatalf("Failed to load prompts: %v", err)
+	}
+	log.Printf("Prompts version: %s", prompts.Version())
 
 	// Инициализируем транслятор
-	trans := translator.NewTranslator(cfg.OpenAIKey, storage)
+	trans := translator.NewTranslator(provider, storage, translator.Options{
+		Workers:     cfg.WorkerCount,
+		QueueLength: cfg.QueueMaxLength,
+		RetryAfter:  cfg.QueueRetryAfter,
+		Notifier:    notifier,
+
+		EnrichmentCacheTTL: cfg.LLMCacheTTL,
+		AIConcurrency:      cfg.LLMConcurrency,
+		Prices:             prices,
+		NodeFixesPerRule:   cfg.LLMNodeFixesPerRule,
+		NodeFixesMax:       cfg.LLMNodeFixesMax,
+		Redactor:           redactor,
+		Prompts:            prompts,
+	})
+
+	// Возобновляем задачи, прерванные предыдущей остановкой сервера
+	requeued, failed, err := trans.ResumeInterrupted(cfg.ResumeInterruptedJobs)
+	if err != nil {
+		log.Printf("⚠️  Не удалось возобновить прерванные задачи: %v", err)
+	} else if requeued > 0 || failed > 0 {
+		log.Printf("Interrupted jobs: %d requeued, %d marked as failed", requeued, failed)
+	}
+
+	// Запускаем очистку старых задач, отчетов и просроченного кэша описаний
+	janitor := service.NewJanitor(storage, service.RetentionPolicy{
+		MaxAge:   cfg.RetentionMaxAge,
+		MaxCount: cfg.RetentionMaxCount,
+		MaxBytes: cfg.RetentionMaxBytes,
+	}, cfg.RetentionInterval)
+	janitor.Start()
 
 	// Инициализируем обработчик
-	handler := api.NewHandler(storage, trans)
+	handler := api.NewHandler(storage, trans, janitor, api.Options{
+		IdempotencyTTL: cfg.IdempotencyKeyTTL,
+		DedupWindow:    cfg.DedupWindow,
+	})
 
 	// Настраиваем роутер
 	router := api.SetupRouter(handler, cfg.GinMode)
 
-	// Запускаем сервер
 	addr := fmt.Sprintf(":%s", cfg.ServerPort)
+	server := &http.Server{Addr: addr, Handler: router}
+	// SSE-потоки не завершаются сами, поэтому закрываем их при остановке
+	server.RegisterOnShutdown(trans.Events().Close)
+
 	log.Printf("Starting server on %s", addr)
 	log.Printf("GinMode: %s", cfg.GinMode)
 
-	if cfg.OpenAIKey == "" {
-		log.Println("⚠️  OPENAI_API_KEY не установлен. Работа в демо-режиме.")
+	if provider == nil {
+		log.Println("⚠️  LLM_API_KEY не установлен. Работа в демо-режиме.")
 	} else {
-		log.Println("✅ OpenAI API настроен")
+		log.Printf("✅ LLM настроена: %s", provider.Name())
+	}
+
+	if cfg.WebhookSecret == "" {
+		log.Println("⚠️  WEBHOOK_SECRET не установлен. Уведомления на callback_url отправляются без подписи.")
 	}
 
-	if err := router.Run(addr); err != nil {
+	// Запускаем сервер и ждем сигнала остановки
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("Failed to start server: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("Shutting down (timeout %s)...", cfg.ShutdownTimeout)
+	shutdown(server, trans, notifier, janitor, storage, cfg.ShutdownTimeout)
+	log.Println("Server stopped")
+}
+
+// shutdown останавливает сервер: перестает принимать запросы, дожидается текущих
+// анализов и доставки уведомлений, после чего закрывает хранилище. Ожидание HTTP-запросов
+// и обработчиков ограничено timeout по отдельности, чтобы медленный клиент не отнимал время
+// у завершения анализов.
+func shutdown(server *http.Server, trans *translator.Translator, notifier *webhook.Notifier,
+	janitor *service.Janitor, storage service.Storage, timeout time.Duration) {
+	httpCtx, cancelHTTP := context.WithTimeout(context.Background(), timeout)
+	defer cancelHTTP()
+
+	if err := server.Shutdown(httpCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("⚠️  HTTP server shutdown: %v", err)
+	}
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), timeout)
+	defer cancelDrain()
+
+	if err := trans.Shutdown(drainCtx); err != nil {
+		log.Printf("⚠️  Не все анализы успели завершиться, они будут возобновлены при запуске: %v", err)
+	}
+	notifier.Close()
+	janitor.Stop()
+
+	if closer, ok := storage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("⚠️  Failed to close storage: %v", err)
+		}
+	}
+}
+
+// openStorage создает хранилище, выбранное в конфигурации
+func openStorage(cfg *config.Config) (service.Storage, error) {
+	switch cfg.StorageBackend {
+	case "memory":
+		log.Println("⚠️  Используется хранилище в памяти: задачи будут потеряны при перезапуске")
+		return service.NewMemoryStorage(), nil
+	case "file":
+		log.Printf("Storage: file (%s)", cfg.DataDir)
+		return service.NewFileStorage(cfg.DataDir)
+	case "sqlite":
+		log.Printf("Storage: sqlite (%s)", cfg.SQLitePath)
+		return service.NewSQLiteStorage(cfg.SQLitePath)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
 	}
 }
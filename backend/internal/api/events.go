@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeatInterval - как часто отправлять комментарий-heartbeat,
+// чтобы прокси и балансировщики не закрывали простаивающее соединение
+var sseHeartbeatInterval = 15 * time.Second
+
+// sseRetry - через сколько миллисекунд клиенту (EventSource) переподключаться после разрыва
+const sseRetry = 3000
+
+// JobDoneEvent - итоговое событие потока задачи
+type JobDoneEvent struct {
+	JobResponse
+	// ReportURL - ссылка на отчет, если задача завершилась успешно
+	ReportURL string `json:"report_url,omitempty"`
+}
+
+// StreamJobEvents транслирует события задачи через Server-Sent Events.
+// События: status (снимок задачи), progress (обработан батч), done (итог со ссылкой на отчет).
+// Клиент может переподключиться с заголовком Last-Event-ID (или параметром last_event_id)
+// и получить пропущенные события.
+func (h *Handler) StreamJobEvents(c *gin.Context) {
+	jobID := c.Param("id")
+	lastEventID := parseLastEventID(c)
+
+	// Подписываемся до чтения задачи, чтобы не потерять изменения между ними
+	sub := h.translator.Events().Subscribe(jobID, lastEventID)
+	defer sub.Close()
+
+	job, err := h.storage.GetJob(jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Job not found",
+		})
+		return
+	}
+
+	startSSE(c)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry)
+
+	if lastEventID == 0 || sub.Gap {
+		// Новый клиент или история уже потеряна: отправляем текущее состояние задачи
+		snapshot := service.JobEvent{ID: sub.LastID, JobID: jobID, Type: service.EventStatus, Data: job}
+		if job.Status.IsFinal() {
+			snapshot.Type = service.EventDone
+		}
+		if !h.writeEvent(c, snapshot) || snapshot.Type == service.EventDone {
+			return
+		}
+	} else {
+		for _, event := range sub.Replay {
+			if !h.writeEvent(c, event) || event.Type == service.EventDone {
+				return
+			}
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Подписку закрыли (клиент не успевал читать): он переподключится с Last-Event-ID
+				return
+			}
+			if !h.writeEvent(c, event) || event.Type == service.EventDone {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// startSSE отправляет заголовки потока Server-Sent Events
+func startSSE(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+}
+
+// writeSSE отправляет событие без id. Возвращает false, если соединение закрыто.
+func writeSSE(c *gin.Context, event string, payload interface{}) bool {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
+
+// writeEvent отправляет событие клиенту. Возвращает false, если соединение закрыто.
+func (h *Handler) writeEvent(c *gin.Context, event service.JobEvent) bool {
+	var payload interface{} = event.Data
+	if job, ok := event.Data.(*service.Job); ok {
+		response := h.jobResponse(job)
+		payload = response
+
+		if event.Type == service.EventDone {
+			done := JobDoneEvent{JobResponse: response}
+			if job.Status == service.StatusCompleted {
+				done.ReportURL = "/api/v1/jobs/" + job.ID + "/report"
+			}
+			payload = done
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+
+	if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
+
+// parseLastEventID читает ID последнего полученного клиентом события.
+// EventSource передает его в заголовке Last-Event-ID при переподключении.
+func parseLastEventID(c *gin.Context) int64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
@@ -1,36 +1,63 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
 	"github.com/danil/accessibility-analyzer/internal/service"
 	"github.com/danil/accessibility-analyzer/internal/translator"
 	"github.com/gin-gonic/gin"
 )
 
+// Options содержит настройки обработчика
+type Options struct {
+	// IdempotencyTTL - сколько повтор запроса с тем же Idempotency-Key возвращает исходную задачу
+	IdempotencyTTL time.Duration
+	// DedupWindow - в течение какого времени одинаковый запрос (URL и нарушения) возвращает
+	// уже созданную задачу вместо повторной обработки (0 - не искать дубли)
+	DedupWindow time.Duration
+}
+
+// maxIdempotencyKeyLength - максимальная длина заголовка Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
 // Handler обрабатывает HTTP запросы
 type Handler struct {
-	storage    *service.Storage
+	storage    service.Storage
 	translator *translator.Translator
+	janitor    *service.Janitor
+	opts       Options
+
+	// createMu сериализует поиск дублей и создание задачи, чтобы одновременные
+	// повторы одного запроса не создали две задачи
+	createMu sync.Mutex
 }
 
 // NewHandler создает новый обработчик
-func NewHandler(storage *service.Storage, trans *translator.Translator) *Handler {
+func NewHandler(storage service.Storage, trans *translator.Translator, janitor *service.Janitor, opts Options) *Handler {
 	return &Handler{
 		storage:    storage,
 		translator: trans,
+		janitor:    janitor,
+		opts:       opts,
 	}
 }
 
 // HealthCheck проверяет здоровье сервиса
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"time":   time.Now().Format(time.RFC3339),
+		"status":    "ok",
+		"time":      time.Now().Format(time.RFC3339),
+		"retention": h.janitor.Stats(),
+		"ai":        h.translator.AIStatus(),
 	})
 }
 
@@ -45,8 +72,46 @@ func (h *Handler) CreateAnalysis(c *gin.Context) {
 		return
 	}
 
+	if req.CallbackURL != "" && !isValidCallbackURL(req.CallbackURL) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_callback_url",
+			Message: "callback_url must be an absolute http or https URL",
+		})
+		return
+	}
+
+	locale, ok := requestLocale(c, req.Locale)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_locale",
+			Message: "locale must be one of: " + strings.Join(i18n.Supported(), ", "),
+		})
+		return
+	}
+	// Сохраняем выбранный язык в запросе, чтобы повторная обработка использовала его же
+	req.Locale = locale
+
+	idempotencyKey := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_idempotency_key",
+			Message: fmt.Sprintf("Idempotency-Key must not exceed %d characters", maxIdempotencyKeyLength),
+		})
+		return
+	}
+	requestHash := service.RequestHash(&req)
+
+	h.createMu.Lock()
+	defer h.createMu.Unlock()
+
+	if h.respondDuplicate(c, &req, idempotencyKey, requestHash) {
+		return
+	}
+
 	// Создаем задачу
 	job := service.NewJob(req.URL)
+	job.IdempotencyKey = idempotencyKey
+	job.RequestHash = requestHash
 	if err := h.storage.SaveJob(job); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_error",
@@ -55,17 +120,112 @@ func (h *Handler) CreateAnalysis(c *gin.Context) {
 		return
 	}
 
-	// Запускаем обработку асинхронно
-	h.translator.ProcessAnalysis(job, req.Violations)
+	// Сохраняем исходный запрос, чтобы задачу можно было перезапустить
+	if err := h.storage.SaveRequest(job.ID, &req); err != nil {
+		h.storage.DeleteJob(job.ID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to create job",
+		})
+		return
+	}
 
-	c.JSON(http.StatusCreated, JobResponse{
-		ID:        job.ID,
-		URL:       job.URL,
-		Status:    string(job.Status),
-		Progress:  job.Progress,
-		CreatedAt: job.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: job.UpdatedAt.Format(time.RFC3339),
-	})
+	// Ставим задачу в очередь на асинхронную обработку
+	if err := h.translator.ProcessAnalysis(job, &req); err != nil {
+		h.storage.DeleteJob(job.ID)
+
+		if errors.Is(err, translator.ErrQueueFull) {
+			h.respondQueueFull(c)
+			return
+		}
+		if errors.Is(err, translator.ErrShuttingDown) {
+			h.respondShuttingDown(c)
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to enqueue job",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newJobResponse(job, h.translator.QueuePosition(job.ID)))
+}
+
+// respondDuplicate отвечает исходной задачей, если запрос повторяет уже принятый:
+// тот же Idempotency-Key или, при включенном DedupWindow, то же содержимое.
+// Возвращает true, если ответ уже отправлен.
+func (h *Handler) respondDuplicate(c *gin.Context, req *domain.AnalysisRequest, idempotencyKey, requestHash string) bool {
+	now := time.Now()
+
+	if idempotencyKey != "" && h.opts.IdempotencyTTL > 0 {
+		existing, err := h.findRecentJob(service.JobQuery{
+			IdempotencyKey: idempotencyKey,
+			CreatedFrom:    now.Add(-h.opts.IdempotencyTTL),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to check Idempotency-Key",
+			})
+			return true
+		}
+		if existing != nil {
+			if existing.RequestHash != requestHash {
+				c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+					Error:   "idempotency_key_reused",
+					Message: "Idempotency-Key was already used with a different request",
+				})
+				return true
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.JSON(http.StatusOK, h.jobResponse(existing))
+			return true
+		}
+	}
+
+	// Запрос с callback_url не объединяем с чужой задачей: иначе уведомление не придет
+	if h.opts.DedupWindow > 0 && req.CallbackURL == "" {
+		existing, err := h.findRecentJob(service.JobQuery{
+			RequestHash: requestHash,
+			Statuses:    []service.JobStatus{service.StatusPending, service.StatusProcessing, service.StatusCompleted},
+			CreatedFrom: now.Add(-h.opts.DedupWindow),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to check for duplicate analyses",
+			})
+			return true
+		}
+		if existing != nil {
+			c.Header("X-Deduplicated", "true")
+			c.JSON(http.StatusOK, h.jobResponse(existing))
+			return true
+		}
+	}
+
+	return false
+}
+
+// findRecentJob возвращает самую новую задачу, подходящую под запрос, или nil
+func (h *Handler) findRecentJob(query service.JobQuery) (*service.Job, error) {
+	query.Limit = 1
+	page, err := h.storage.QueryJobs(query)
+	if err != nil || len(page.Entries) == 0 {
+		return nil, err
+	}
+	return page.Entries[0].Job, nil
+}
+
+// jobResponse формирует JobResponse с позицией в очереди для ожидающей задачи
+func (h *Handler) jobResponse(job *service.Job) JobResponse {
+	queuePosition := 0
+	if job.Status == service.StatusPending {
+		queuePosition = h.translator.QueuePosition(job.ID)
+	}
+	return newJobResponse(job, queuePosition)
 }
 
 // GetJobStatus возвращает статус задачи
@@ -81,15 +241,7 @@ func (h *Handler) GetJobStatus(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, JobResponse{
-		ID:        job.ID,
-		URL:       job.URL,
-		Status:    string(job.Status),
-		Progress:  job.Progress,
-		CreatedAt: job.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: job.UpdatedAt.Format(time.RFC3339),
-		Error:     job.Error,
-	})
+	c.JSON(http.StatusOK, h.jobResponse(job))
 }
 
 // GetReport возвращает готовый отчет
@@ -180,11 +332,62 @@ func (h *Handler) GetReportPDF(c *gin.Context) {
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
 
-// DeleteJob удаляет задачу
-func (h *Handler) DeleteJob(c *gin.Context) {
+// CancelJob отменяет ожидающую или выполняющуюся задачу
+func (h *Handler) CancelJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	job, err := h.translator.Cancel(jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrJobNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Job not found",
+			})
+		case errors.Is(err, translator.ErrJobFinished):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "job_finished",
+				Message: "Job is already finished",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to cancel job",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newJobResponse(job, 0))
+}
+
+// RetryJob повторно запускает задачу, завершившуюся ошибкой
+func (h *Handler) RetryJob(c *gin.Context) {
+	job, err := h.translator.Retry(c.Param("id"))
+	if err != nil {
+		h.respondRestartError(c, err, "Only failed jobs can be retried")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newJobResponse(job, h.translator.QueuePosition(job.ID)))
+}
+
+// ReprocessJob заново обрабатывает завершенную задачу, сохраняя прежний отчет как ревизию
+func (h *Handler) ReprocessJob(c *gin.Context) {
+	job, err := h.translator.Reprocess(c.Param("id"))
+	if err != nil {
+		h.respondRestartError(c, err, "Only completed jobs can be reprocessed")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newJobResponse(job, h.translator.QueuePosition(job.ID)))
+}
+
+// GetReportRevisions возвращает архивные версии отчета
+func (h *Handler) GetReportRevisions(c *gin.Context) {
 	jobID := c.Param("id")
 
-	if err := h.storage.DeleteJob(jobID); err != nil {
+	if _, err := h.storage.GetJob(jobID); err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
 			Message: "Job not found",
@@ -192,19 +395,26 @@ func (h *Handler) DeleteJob(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{
-		Success: true,
-		Message: "Job deleted successfully",
+	revisions, err := h.storage.ListReportRevisions(jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to load report revisions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":    jobID,
+		"revisions": revisions,
 	})
 }
 
-// GetReportSummary генерирует комплексное резюме с рекомендациями по всему отчёту
-func (h *Handler) GetReportSummary(c *gin.Context) {
+// GetWebhookDeliveries возвращает попытки доставки уведомлений о завершении задачи
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
 	jobID := c.Param("id")
 
-	// Проверяем статус задачи
-	job, err := h.storage.GetJob(jobID)
-	if err != nil {
+	if _, err := h.storage.GetJob(jobID); err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
 			Message: "Job not found",
@@ -212,89 +422,134 @@ func (h *Handler) GetReportSummary(c *gin.Context) {
 		return
 	}
 
-	if job.Status != service.StatusCompleted {
-		c.JSON(http.StatusAccepted, ErrorResponse{
-			Error:   "not_ready",
-			Message: "Report is not ready yet",
+	deliveries, err := h.storage.ListWebhookDeliveries(jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to load webhook deliveries",
 		})
 		return
 	}
 
-	// Получаем отчет
-	report, err := h.storage.GetReport(jobID)
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":     jobID,
+		"deliveries": deliveries,
+	})
+}
+
+// isValidCallbackURL проверяет, что callback_url - абсолютный http(s) адрес.
+// Внутренние адреса отклоняет webhook.Notifier при соединении, после разрешения DNS.
+func isValidCallbackURL(raw string) bool {
+	u, err := url.Parse(raw)
 	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// requestLocale возвращает язык отчета: из поля locale запроса, иначе из заголовка
+// Accept-Language, иначе язык по умолчанию. Возвращает false, если поле locale
+// содержит неподдерживаемый язык.
+func requestLocale(c *gin.Context, requested string) (string, bool) {
+	if requested != "" {
+		return i18n.Normalize(requested)
+	}
+	if locale, ok := i18n.FromAcceptLanguage(c.GetHeader("Accept-Language")); ok {
+		return locale, true
+	}
+	return i18n.Default, true
+}
+
+// respondRestartError отвечает на ошибку повторного запуска задачи
+func (h *Handler) respondRestartError(c *gin.Context, err error, invalidStatusMessage string) {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
-			Message: "Report not found",
+			Message: "Job not found",
+		})
+	case errors.Is(err, translator.ErrInvalidJobStatus):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "invalid_status",
+			Message: invalidStatusMessage,
+		})
+	case errors.Is(err, service.ErrRequestNotFound):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "request_unavailable",
+			Message: "Original analysis request is not stored for this job",
+		})
+	case errors.Is(err, translator.ErrQueueFull):
+		h.respondQueueFull(c)
+	case errors.Is(err, translator.ErrShuttingDown):
+		h.respondShuttingDown(c)
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to restart job",
 		})
-		return
 	}
+}
 
-	// Создаём упрощённую версию отчёта для AI (только ключевые данные)
-	// Отправка полного JSON с тысячами строк технических деталей даёт странные результаты
-	type SimplifiedIssue struct {
-		Impact      string `json:"impact"`
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Count       int    `json:"count"`
-	}
-
-	type SimplifiedReport struct {
-		URL         string            `json:"url"`
-		TotalIssues int               `json:"total_issues"`
-		Critical    int               `json:"critical"`
-		Serious     int               `json:"serious"`
-		Moderate    int               `json:"moderate"`
-		Minor       int               `json:"minor"`
-		Issues      []SimplifiedIssue `json:"issues"`
-	}
-
-	// Собираем упрощённые данные
-	var simplifiedIssues []SimplifiedIssue
-	for _, issues := range report.IssuesByImpact {
-		for _, issue := range issues {
-			simplifiedIssues = append(simplifiedIssues, SimplifiedIssue{
-				Impact:      issue.Impact,
-				Title:       issue.Title,
-				Description: issue.Description,
-				Count:       issue.AffectedElements,
-			})
-		}
+// respondQueueFull отвечает 503 с заголовком Retry-After
+func (h *Handler) respondQueueFull(c *gin.Context) {
+	c.Header("Retry-After", retryAfterSeconds(h.translator.RetryAfter()))
+	c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+		Error:   "queue_full",
+		Message: "Too many analyses in progress, try again later",
+	})
+}
+
+// respondShuttingDown отвечает 503, пока сервер останавливается
+func (h *Handler) respondShuttingDown(c *gin.Context) {
+	c.Header("Retry-After", "5")
+	c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+		Error:   "shutting_down",
+		Message: "Server is shutting down, try again shortly",
+	})
+}
+
+// respondAIUnavailable отвечает 503, пока circuit breaker считает провайдера LLM недоступным
+func (h *Handler) respondAIUnavailable(c *gin.Context) {
+	if circuit := h.translator.AIStatus().Circuit; circuit != nil && circuit.RetryAt != nil {
+		c.Header("Retry-After", retryAfterSeconds(time.Until(*circuit.RetryAt)))
 	}
+	c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+		Error:   "ai_unavailable",
+		Message: "AI provider is temporarily unavailable, try again later",
+	})
+}
 
-	simplifiedReport := SimplifiedReport{
-		URL:         report.URL,
-		TotalIssues: report.Summary.TotalIssues,
-		Critical:    report.Summary.Critical,
-		Serious:     report.Summary.Serious,
-		Moderate:    report.Summary.Moderate,
-		Minor:       report.Summary.Minor,
-		Issues:      simplifiedIssues,
+// retryAfterSeconds форматирует паузу для заголовка Retry-After (не меньше секунды)
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
 	}
+	return strconv.Itoa(seconds)
+}
 
-	// Конвертируем упрощённый отчёт в JSON для отправки в AI
-	reportJSON, err := json.Marshal(simplifiedReport)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to serialize report",
+// DeleteJob удаляет задачу. Незавершенная задача предварительно отменяется.
+func (h *Handler) DeleteJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	err := h.translator.Delete(jobID)
+	if errors.Is(err, service.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Job not found",
 		})
 		return
 	}
-
-	// Генерируем резюме через AI
-	summary, err := h.translator.GenerateSummary(string(reportJSON))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "summary_generation_failed",
-			Message: fmt.Sprintf("Failed to generate summary: %v", err),
+			Error:   "internal_error",
+			Message: "Failed to delete job",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"job_id":  jobID,
-		"url":     report.URL,
-		"summary": summary,
+	c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Job deleted successfully",
 	})
 }
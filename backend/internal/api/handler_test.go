@@ -0,0 +1,578 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/service"
+	"github.com/danil/accessibility-analyzer/internal/translator"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter собирает роутер поверх хранилища в памяти и демо-режима AI
+func newTestRouter(t *testing.T) (*gin.Engine, service.Storage) {
+	t.Helper()
+	return newTestRouterWithOptions(t, Options{IdempotencyTTL: time.Hour})
+}
+
+// newTestRouterWithOptions собирает тестовый роутер с заданными настройками обработчика
+func newTestRouterWithOptions(t *testing.T, opts Options) (*gin.Engine, service.Storage) {
+	t.Helper()
+
+	storage := service.NewMemoryStorage()
+	trans := translator.NewTranslator(nil, storage, translator.Options{Workers: 2, QueueLength: 100})
+	janitor := service.NewJanitor(storage, service.RetentionPolicy{}, time.Minute)
+	handler := NewHandler(storage, trans, janitor, opts)
+
+	return SetupRouter(handler, gin.TestMode), storage
+}
+
+// loadDemoRequest формирует тело POST /analyze из testdata
+func loadDemoRequest(t *testing.T) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "axe_response_demo.json"))
+	if err != nil {
+		t.Fatalf("failed to load demo json: %v", err)
+	}
+
+	var violations []domain.AxeViolation
+	if err := json.Unmarshal(data, &violations); err != nil {
+		t.Fatalf("failed to parse demo json: %v", err)
+	}
+
+	body, _ := json.Marshal(domain.AnalysisRequest{URL: "https://example.com", Violations: violations})
+	return body
+}
+
+// TestJobStatusPollingDuringProcessing опрашивает статус задачи из нескольких горутин,
+// пока она обрабатывается. Запускать с -race.
+func TestJobStatusPollingDuringProcessing(t *testing.T) {
+	router, _ := newTestRouter(t)
+	body := loadDemoRequest(t)
+
+	const jobs, pollers = 5, 8
+
+	var wg sync.WaitGroup
+	for n := 0; n < jobs; n++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+
+		var created JobResponse
+		json.Unmarshal(w.Body.Bytes(), &created)
+
+		for p := 0; p < pollers; p++ {
+			wg.Add(1)
+			go func(jobID string) {
+				defer wg.Done()
+
+				deadline := time.Now().Add(10 * time.Second)
+				lastProgress := -1
+				for time.Now().Before(deadline) {
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil))
+					if w.Code != http.StatusOK {
+						t.Errorf("unexpected status %d", w.Code)
+						return
+					}
+
+					var job JobResponse
+					json.Unmarshal(w.Body.Bytes(), &job)
+
+					if job.Progress < lastProgress {
+						t.Errorf("progress went backwards: %d -> %d", lastProgress, job.Progress)
+						return
+					}
+					lastProgress = job.Progress
+
+					switch job.Status {
+					case string(service.StatusCompleted):
+						if job.Progress != 100 {
+							t.Errorf("completed job with progress %d", job.Progress)
+						}
+						return
+					case string(service.StatusFailed):
+						t.Errorf("job failed: %s", job.Error)
+						return
+					}
+				}
+				t.Errorf("job %s did not complete in time", jobID)
+			}(created.ID)
+		}
+	}
+
+	wg.Wait()
+}
+
+// doRequest выполняет запрос к роутеру
+func doRequest(router *gin.Engine, method, path string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// waitForStatus ждет, пока задача перейдет в указанный статус
+func waitForStatus(t *testing.T, router *gin.Engine, jobID string, status service.JobStatus) JobResponse {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var job JobResponse
+		json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+jobID, nil).Body.Bytes(), &job)
+		if job.Status == string(status) {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach status %s", jobID, status)
+	return JobResponse{}
+}
+
+// TestReprocessKeepsPreviousRevision проверяет повторную обработку по сохраненному запросу
+func TestReprocessKeepsPreviousRevision(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doRequest(router, http.MethodPost, "/api/v1/analyze", loadDemoRequest(t))
+	var created JobResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+	// Retry доступен только для задач с ошибкой
+	if w := doRequest(router, http.MethodPost, "/api/v1/jobs/"+created.ID+"/retry", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for retry of completed job, got %d", w.Code)
+	}
+
+	if w := doRequest(router, http.MethodPost, "/api/v1/jobs/"+created.ID+"/reprocess", nil); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for reprocess, got %d: %s", w.Code, w.Body.String())
+	}
+	waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+	var report domain.Report
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+created.ID+"/report", nil).Body.Bytes(), &report)
+	if report.Revision != 2 {
+		t.Errorf("expected current report revision 2, got %d", report.Revision)
+	}
+
+	var revisions struct {
+		Revisions []domain.Report `json:"revisions"`
+	}
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+created.ID+"/report/revisions", nil).Body.Bytes(), &revisions)
+	if len(revisions.Revisions) != 1 || revisions.Revisions[0].Revision != 1 {
+		t.Errorf("expected one archived revision 1, got %+v", revisions.Revisions)
+	}
+}
+
+// TestRetryFailedJob проверяет повтор задачи, завершившейся ошибкой
+func TestRetryFailedJob(t *testing.T) {
+	router, storage := newTestRouter(t)
+
+	w := doRequest(router, http.MethodPost, "/api/v1/analyze", loadDemoRequest(t))
+	var created JobResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+	// Имитируем сбой обработки
+	storage.UpdateJob(created.ID, func(j *service.Job) error {
+		j.SetError("AI provider unavailable")
+		return nil
+	})
+
+	if w := doRequest(router, http.MethodPost, "/api/v1/jobs/"+created.ID+"/retry", nil); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for retry, got %d: %s", w.Code, w.Body.String())
+	}
+
+	job := waitForStatus(t, router, created.ID, service.StatusCompleted)
+	if job.Error != "" {
+		t.Errorf("expected error to be cleared after retry, got %q", job.Error)
+	}
+}
+
+// sseEvent - событие, прочитанное из потока Server-Sent Events
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// readSSE читает события из потока до итогового события done
+func readSSE(t *testing.T, url string, lastEventID string) []sseEvent {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			current.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.Data = strings.TrimPrefix(line, "data: ")
+		case line == "" && current.Type != "":
+			events = append(events, current)
+			if current.Type == service.EventDone {
+				return events
+			}
+			current = sseEvent{}
+		}
+	}
+	t.Fatalf("stream ended without done event: %+v", events)
+	return nil
+}
+
+// TestJobEventsStream проверяет SSE-поток задачи: итоговое событие со ссылкой на отчет
+// и повторное подключение с Last-Event-ID
+func TestJobEventsStream(t *testing.T) {
+	router, _ := newTestRouter(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := doRequest(router, http.MethodPost, "/api/v1/analyze", loadDemoRequest(t))
+	var created JobResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	eventsURL := server.URL + "/api/v1/jobs/" + created.ID + "/events"
+	events := readSSE(t, eventsURL, "")
+
+	var done JobDoneEvent
+	if err := json.Unmarshal([]byte(events[len(events)-1].Data), &done); err != nil {
+		t.Fatalf("failed to parse done event: %v", err)
+	}
+	if done.Status != string(service.StatusCompleted) || done.Progress != 100 {
+		t.Errorf("unexpected final state: %+v", done)
+	}
+	if done.ReportURL != "/api/v1/jobs/"+created.ID+"/report" {
+		t.Errorf("unexpected report url %q", done.ReportURL)
+	}
+
+	// После завершения переподключение сразу получает итоговое событие
+	resumed := readSSE(t, eventsURL, events[len(events)-1].ID)
+	if len(resumed) != 1 || resumed[0].Type != service.EventDone {
+		t.Errorf("expected single done event after reconnect, got %+v", resumed)
+	}
+
+	if w := doRequest(router, http.MethodGet, "/api/v1/jobs/unknown/events", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", w.Code)
+	}
+}
+
+// TestCreateAnalysisRejectsInvalidCallbackURL проверяет валидацию callback_url
+func TestCreateAnalysisRejectsInvalidCallbackURL(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	body, _ := json.Marshal(domain.AnalysisRequest{
+		URL:         "https://example.com",
+		Violations:  []domain.AxeViolation{},
+		CallbackURL: "ftp://ci.example.com/hook",
+	})
+	w := doRequest(router, http.MethodPost, "/api/v1/analyze", body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_callback_url") {
+		t.Errorf("expected 400 invalid_callback_url, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestCreateAnalysisLocale проверяет выбор языка отчета из поля locale и Accept-Language
+func TestCreateAnalysisLocale(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	var request domain.AnalysisRequest
+	json.Unmarshal(loadDemoRequest(t), &request)
+
+	reportLocale := func(w *httptest.ResponseRecorder) domain.Report {
+		t.Helper()
+		if w.Code != http.StatusCreated {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+		var created JobResponse
+		json.Unmarshal(w.Body.Bytes(), &created)
+		waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+		var report domain.Report
+		json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+created.ID+"/report", nil).Body.Bytes(), &report)
+		return report
+	}
+
+	request.Locale = "en-US"
+	body, _ := json.Marshal(request)
+	report := reportLocale(doRequest(router, http.MethodPost, "/api/v1/analyze", body))
+	if report.Locale != "en" || len(report.Recommendations) == 0 || !strings.Contains(report.Recommendations[0], "Found") {
+		t.Errorf("expected English report, got locale %q: %v", report.Locale, report.Recommendations)
+	}
+
+	// Без поля locale язык берется из Accept-Language
+	request.Locale = ""
+	body, _ = json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "de-DE,en;q=0.8,ru;q=0.5")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if report := reportLocale(w); report.Locale != "en" {
+		t.Errorf("expected locale from Accept-Language, got %q", report.Locale)
+	}
+
+	request.Locale = "xx"
+	body, _ = json.Marshal(request)
+	w = doRequest(router, http.MethodPost, "/api/v1/analyze", body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_locale") {
+		t.Errorf("expected 400 invalid_locale, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestListJobs проверяет список задач со статистикой отчета и валидацию параметров
+func TestListJobs(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doRequest(router, http.MethodPost, "/api/v1/analyze", loadDemoRequest(t))
+	var created JobResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+	w = doRequest(router, http.MethodGet, "/api/v1/jobs?status=completed&domain=example.com&sort=critical", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var list JobListResponse
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Jobs) != 1 || list.Jobs[0].ID != created.ID {
+		t.Fatalf("unexpected jobs: %+v", list.Jobs)
+	}
+	if list.Jobs[0].Summary == nil || list.Jobs[0].Summary.TotalIssues == 0 {
+		t.Errorf("expected report summary for completed job, got %+v", list.Jobs[0].Summary)
+	}
+
+	for _, query := range []string{"status=done", "sort=url", "limit=0", "from=yesterday", "cursor=%21"} {
+		if w := doRequest(router, http.MethodGet, "/api/v1/jobs?"+query, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %q, got %d", query, w.Code)
+		}
+	}
+}
+
+// postAnalysis отправляет запрос на анализ с необязательным Idempotency-Key
+func postAnalysis(router *gin.Engine, body []byte, idempotencyKey string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateAnalysisIdempotencyKey проверяет повтор запроса с тем же Idempotency-Key
+func TestCreateAnalysisIdempotencyKey(t *testing.T) {
+	router, storage := newTestRouter(t)
+	body := loadDemoRequest(t)
+
+	first := postAnalysis(router, body, "click-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("unexpected status %d: %s", first.Code, first.Body.String())
+	}
+	var created JobResponse
+	json.Unmarshal(first.Body.Bytes(), &created)
+
+	// Одновременные повторы возвращают ту же задачу
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := postAnalysis(router, body, "click-1")
+			var replayed JobResponse
+			json.Unmarshal(w.Body.Bytes(), &replayed)
+			if w.Code != http.StatusOK || replayed.ID != created.ID || w.Header().Get("Idempotent-Replayed") != "true" {
+				t.Errorf("expected replay of job %s, got %d %s", created.ID, w.Code, w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	other, _ := json.Marshal(domain.AnalysisRequest{URL: "https://other.example.com", Violations: []domain.AxeViolation{}})
+	if w := postAnalysis(router, other, "click-1"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for key reuse with another payload, got %d", w.Code)
+	}
+
+	// Без ключа и без окна дедупликации создается новая задача
+	if w := postAnalysis(router, body, ""); w.Code != http.StatusCreated {
+		t.Errorf("expected new job without Idempotency-Key, got %d", w.Code)
+	}
+	if jobs, _ := storage.ListJobs(); len(jobs) != 2 {
+		t.Errorf("expected 2 jobs, got %d", len(jobs))
+	}
+}
+
+// TestCreateAnalysisDeduplicatesContent проверяет возврат существующей задачи для одинакового содержимого
+func TestCreateAnalysisDeduplicatesContent(t *testing.T) {
+	router, storage := newTestRouterWithOptions(t, Options{DedupWindow: time.Hour})
+	body := loadDemoRequest(t)
+
+	var created JobResponse
+	json.Unmarshal(postAnalysis(router, body, "").Body.Bytes(), &created)
+	waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+	w := postAnalysis(router, body, "")
+	var duplicate JobResponse
+	json.Unmarshal(w.Body.Bytes(), &duplicate)
+	if w.Code != http.StatusOK || duplicate.ID != created.ID || w.Header().Get("X-Deduplicated") != "true" {
+		t.Errorf("expected deduplicated job %s, got %d %s", created.ID, w.Code, w.Body.String())
+	}
+
+	// Задача с ошибкой больше не используется как оригинал
+	storage.UpdateJob(created.ID, func(j *service.Job) error {
+		j.SetError("AI provider unavailable")
+		return nil
+	})
+	if w := postAnalysis(router, body, ""); w.Code != http.StatusCreated {
+		t.Errorf("expected new job after original failed, got %d", w.Code)
+	}
+}
+
+// TestHealthReportsAIStatus проверяет состояние AI в /health
+func TestHealthReportsAIStatus(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doRequest(router, http.MethodGet, "/health", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+
+	var health struct {
+		Status string              `json:"status"`
+		AI     translator.AIStatus `json:"ai"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if health.Status != "ok" || health.AI.Mode != "demo" || health.AI.Circuit != nil {
+		t.Errorf("unexpected health response: %s", w.Body.String())
+	}
+}
+
+// TestGetUsage проверяет расход токенов в задаче и сводку по дням
+func TestGetUsage(t *testing.T) {
+	router, storage := newTestRouter(t)
+
+	job := service.NewJob("https://example.com")
+	job.CreatedAt = time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	job.AddUsage(service.TokenUsage{Requests: 2, PromptTokens: 300, CompletionTokens: 100, TotalTokens: 400, CostUSD: 0.01})
+	storage.SaveJob(job)
+	storage.AddDailyUsage("2025-01-10", *job.Usage)
+
+	var status JobResponse
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+job.ID, nil).Body.Bytes(), &status)
+	if status.Usage == nil || status.Usage.TotalTokens != 400 {
+		t.Errorf("expected usage in job response, got %+v", status.Usage)
+	}
+
+	w := doRequest(router, http.MethodGet, "/api/v1/usage?from=2025-01-01&to=2025-01-31", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var usage UsageResponse
+	json.Unmarshal(w.Body.Bytes(), &usage)
+	if len(usage.Days) != 1 || usage.Days[0].Date != "2025-01-10" || usage.Total.Requests != 2 || usage.Total.CostUSD != 0.01 {
+		t.Errorf("unexpected usage response: %s", w.Body.String())
+	}
+
+	// По умолчанию учитываются последние 30 дней
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/usage", nil).Body.Bytes(), &usage)
+	if len(usage.Days) != 0 {
+		t.Errorf("expected old job to be outside the default period, got %+v", usage.Days)
+	}
+
+	// Расход остается в сводке после удаления задачи
+	storage.DeleteJob(job.ID)
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/usage?from=2025-01-10&to=2025-01-10", nil).Body.Bytes(), &usage)
+	if len(usage.Days) != 1 || usage.Total.TotalTokens != 400 {
+		t.Errorf("expected usage of deleted job to be kept, got %+v", usage)
+	}
+
+	if w := doRequest(router, http.MethodGet, "/api/v1/usage?from=yesterday", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid from, got %d", w.Code)
+	}
+}
+
+// TestReportSummaryStream проверяет потоковое резюме и повторную выдачу сохраненного резюме
+func TestReportSummaryStream(t *testing.T) {
+	router, storage := newTestRouter(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	w := doRequest(router, http.MethodPost, "/api/v1/analyze", loadDemoRequest(t))
+	var created JobResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	waitForStatus(t, router, created.ID, service.StatusCompleted)
+
+	streamURL := server.URL + "/api/v1/jobs/" + created.ID + "/report/summary/stream"
+	events := readSSE(t, streamURL, "")
+	if len(events) != 2 || events[0].Type != "delta" {
+		t.Fatalf("expected delta and done events, got %+v", events)
+	}
+	var done SummaryResponse
+	json.Unmarshal([]byte(events[1].Data), &done)
+	if done.JobID != created.ID || done.Summary == "" || done.Cached {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+
+	// Сохраненное резюме возвращается без генерации, пока не запрошено обновление
+	generatedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := storage.SaveReportSummary(created.ID, "Сохраненное резюме", generatedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	var summary SummaryResponse
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+created.ID+"/report/summary", nil).Body.Bytes(), &summary)
+	if !summary.Cached || summary.Summary != "Сохраненное резюме" || summary.GeneratedAt != "2025-03-01T10:00:00Z" {
+		t.Errorf("expected saved summary, got %+v", summary)
+	}
+
+	events = readSSE(t, streamURL, "")
+	if len(events) != 2 || events[0].Data != `{"text":"Сохраненное резюме"}` {
+		t.Errorf("expected saved summary in a single delta, got %+v", events)
+	}
+
+	json.Unmarshal(doRequest(router, http.MethodGet, "/api/v1/jobs/"+created.ID+"/report/summary?refresh=true", nil).Body.Bytes(), &summary)
+	if summary.Cached || summary.Summary == "Сохраненное резюме" {
+		t.Errorf("expected regenerated summary, got %+v", summary)
+	}
+
+	if w := doRequest(router, http.MethodGet, "/api/v1/jobs/unknown/report/summary/stream", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", w.Code)
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobs возвращает страницу задач с фильтрами и сортировкой.
+// Параметры: status (через запятую), url (префикс), domain, from, to (RFC3339 или YYYY-MM-DD),
+// min_critical, sort (created_at, total_issues, critical), order (asc, desc), limit, cursor.
+func (h *Handler) ListJobs(c *gin.Context) {
+	query, err := parseJobQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_query",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	page, err := h.storage.QueryJobs(query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_cursor",
+				Message: "Cursor is malformed or expired",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to list jobs",
+		})
+		return
+	}
+
+	response := JobListResponse{
+		Jobs:       make([]JobListItem, 0, len(page.Entries)),
+		NextCursor: page.NextCursor,
+	}
+	for _, entry := range page.Entries {
+		item := JobListItem{JobResponse: h.jobResponse(entry.Job)}
+		if entry.Job.Status == service.StatusCompleted && entry.Summary != nil {
+			item.Summary = &CompactSummary{
+				TotalIssues: entry.Summary.TotalIssues,
+				Critical:    entry.Summary.Critical,
+				Serious:     entry.Summary.Serious,
+				Moderate:    entry.Summary.Moderate,
+				Minor:       entry.Summary.Minor,
+			}
+		}
+		response.Jobs = append(response.Jobs, item)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseJobQuery разбирает параметры списка задач
+func parseJobQuery(c *gin.Context) (service.JobQuery, error) {
+	query := service.JobQuery{
+		URLPrefix: c.Query("url"),
+		Domain:    c.Query("domain"),
+		Cursor:    c.Query("cursor"),
+	}
+
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			switch s := service.JobStatus(strings.TrimSpace(status)); s {
+			case service.StatusPending, service.StatusProcessing, service.StatusCompleted,
+				service.StatusFailed, service.StatusCancelled:
+				query.Statuses = append(query.Statuses, s)
+			default:
+				return query, fmt.Errorf("unknown status %q", status)
+			}
+		}
+	}
+
+	var err error
+	if query.CreatedFrom, err = parseQueryTime(c.Query("from"), false); err != nil {
+		return query, fmt.Errorf("invalid from: %w", err)
+	}
+	if query.CreatedTo, err = parseQueryTime(c.Query("to"), true); err != nil {
+		return query, fmt.Errorf("invalid to: %w", err)
+	}
+
+	if value := c.Query("min_critical"); value != "" {
+		if query.MinCritical, err = strconv.Atoi(value); err != nil || query.MinCritical < 0 {
+			return query, fmt.Errorf("min_critical must be a non-negative integer")
+		}
+	}
+
+	switch sortBy := c.DefaultQuery("sort", service.SortByCreatedAt); sortBy {
+	case service.SortByCreatedAt, service.SortByTotalIssues, service.SortByCritical:
+		query.SortBy = sortBy
+	default:
+		return query, fmt.Errorf("unknown sort field %q", sortBy)
+	}
+
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		query.Ascending = true
+	case "desc":
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 1 {
+			return query, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+
+	return query, nil
+}
+
+// parseQueryTime разбирает время в формате RFC3339 или дату YYYY-MM-DD.
+// Для верхней границы дата означает конец дня, чтобы to=2025-01-31 включал весь день.
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 time or YYYY-MM-DD date")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
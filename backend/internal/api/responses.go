@@ -1,5 +1,11 @@
 package api
 
+import (
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
 // ErrorResponse представляет ответ с ошибкой
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -22,4 +28,63 @@ type JobResponse struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 	Error     string `json:"error,omitempty"`
+	// QueuePosition - позиция в очереди (с 1), пока задача ожидает обработки
+	QueuePosition int `json:"queue_position,omitempty"`
+	// Usage - расход токенов LLM на задачу
+	Usage *service.TokenUsage `json:"usage,omitempty"`
+}
+
+// newJobResponse формирует JobResponse по снимку задачи
+func newJobResponse(job *service.Job, queuePosition int) JobResponse {
+	return JobResponse{
+		ID:            job.ID,
+		URL:           job.URL,
+		Status:        string(job.Status),
+		Progress:      job.Progress,
+		CreatedAt:     job.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     job.UpdatedAt.Format(time.RFC3339),
+		Error:         job.Error,
+		QueuePosition: queuePosition,
+		Usage:         job.Usage,
+	}
+}
+
+// CompactSummary - краткая статистика отчета для списка задач
+type CompactSummary struct {
+	TotalIssues int `json:"total_issues"`
+	Critical    int `json:"critical"`
+	Serious     int `json:"serious"`
+	Moderate    int `json:"moderate"`
+	Minor       int `json:"minor"`
+}
+
+// JobListItem - задача в списке. Summary заполняется для завершенных задач.
+type JobListItem struct {
+	JobResponse
+	Summary *CompactSummary `json:"summary,omitempty"`
+}
+
+// JobListResponse представляет страницу списка задач
+type JobListResponse struct {
+	Jobs []JobListItem `json:"jobs"`
+	// NextCursor передается в параметре cursor для получения следующей страницы
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UsageResponse - расход токенов по дням за период
+type UsageResponse struct {
+	From  string               `json:"from"`
+	To    string               `json:"to"`
+	Days  []service.DailyUsage `json:"days"`
+	Total service.TokenUsage   `json:"total"`
+}
+
+// SummaryResponse - резюме отчёта
+type SummaryResponse struct {
+	JobID   string `json:"job_id"`
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+	// Cached - резюме сгенерировано ранее и взято из отчёта
+	Cached      bool   `json:"cached"`
+	GeneratedAt string `json:"generated_at,omitempty"`
 }
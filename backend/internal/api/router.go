@@ -16,8 +16,8 @@ func SetupRouter(handler *Handler, ginMode string) *gin.Engine {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Last-Event-ID", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "Idempotent-Replayed", "X-Deduplicated"},
 		AllowCredentials: true,
 	}))
 
@@ -30,9 +30,15 @@ func SetupRouter(handler *Handler, ginMode string) *gin.Engine {
 		// POST /api/v1/analyze - создать задачу анализа
 		v1.POST("/analyze", handler.CreateAnalysis)
 
+		// GET /api/v1/jobs - список задач с фильтрами и пагинацией
+		v1.GET("/jobs", handler.ListJobs)
+
 		// GET /api/v1/jobs/:id - получить статус задачи
 		v1.GET("/jobs/:id", handler.GetJobStatus)
 
+		// GET /api/v1/jobs/:id/events - поток событий задачи (Server-Sent Events)
+		v1.GET("/jobs/:id/events", handler.StreamJobEvents)
+
 		// GET /api/v1/jobs/:id/report - получить готовый отчет
 		v1.GET("/jobs/:id/report", handler.GetReport)
 
@@ -42,8 +48,29 @@ func SetupRouter(handler *Handler, ginMode string) *gin.Engine {
 		// GET /api/v1/jobs/:id/report/summary - получить комплексное резюме с рекомендациями
 		v1.GET("/jobs/:id/report/summary", handler.GetReportSummary)
 
-		// DELETE /api/v1/jobs/:id - удалить задачу
+		// GET /api/v1/jobs/:id/report/summary/stream - получить резюме потоком Server-Sent Events
+		v1.GET("/jobs/:id/report/summary/stream", handler.StreamReportSummary)
+
+		// GET /api/v1/jobs/:id/report/revisions - получить предыдущие версии отчета
+		v1.GET("/jobs/:id/report/revisions", handler.GetReportRevisions)
+
+		// GET /api/v1/jobs/:id/webhooks - получить историю доставки уведомлений
+		v1.GET("/jobs/:id/webhooks", handler.GetWebhookDeliveries)
+
+		// POST /api/v1/jobs/:id/retry - повторить задачу, завершившуюся ошибкой
+		v1.POST("/jobs/:id/retry", handler.RetryJob)
+
+		// POST /api/v1/jobs/:id/reprocess - заново обработать завершенную задачу
+		v1.POST("/jobs/:id/reprocess", handler.ReprocessJob)
+
+		// POST /api/v1/jobs/:id/cancel - отменить задачу
+		v1.POST("/jobs/:id/cancel", handler.CancelJob)
+
+		// DELETE /api/v1/jobs/:id - удалить задачу (незавершенная задача отменяется)
 		v1.DELETE("/jobs/:id", handler.DeleteJob)
+
+		// GET /api/v1/usage - расход токенов LLM и стоимость по дням
+		v1.GET("/usage", handler.GetUsage)
 	}
 
 	return router
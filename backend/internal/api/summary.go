@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/service"
+	"github.com/danil/accessibility-analyzer/internal/translator"
+	"github.com/gin-gonic/gin"
+)
+
+// GetReportSummary возвращает комплексное резюме с рекомендациями по всему отчёту.
+// Резюме генерируется через AI один раз и сохраняется в отчёте; параметр refresh=true
+// генерирует его заново.
+func (h *Handler) GetReportSummary(c *gin.Context) {
+	jobID := c.Param("id")
+
+	report, ok := h.summaryReport(c, jobID)
+	if !ok {
+		return
+	}
+	if cached, ok := cachedSummary(c, jobID, report); ok {
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	reportJSON, err := translator.SummaryReportJSON(report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to serialize report",
+		})
+		return
+	}
+
+	// Генерируем резюме через AI
+	// Контекст запроса прерывает генерацию, если клиент отключился
+	summary, err := h.translator.GenerateSummary(c.Request.Context(), jobID, report.Locale, string(reportJSON))
+	if err != nil {
+		h.respondSummaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SummaryResponse{
+		JobID:       jobID,
+		URL:         report.URL,
+		Summary:     summary,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	})
+}
+
+// StreamReportSummary генерирует резюме отчёта и транслирует его через Server-Sent Events
+// по мере генерации. События: delta (фрагмент текста), done (SummaryResponse с полным
+// резюме) и error (ErrorResponse, если генерация прервалась). Сохраненное резюме
+// отправляется одним фрагментом; refresh=true генерирует его заново.
+// Если AI недоступен до начала ответа, возвращается обычная ошибка с кодом статуса.
+func (h *Handler) StreamReportSummary(c *gin.Context) {
+	jobID := c.Param("id")
+
+	report, ok := h.summaryReport(c, jobID)
+	if !ok {
+		return
+	}
+	if cached, ok := cachedSummary(c, jobID, report); ok {
+		startSSE(c)
+		if writeSSE(c, "delta", gin.H{"text": cached.Summary}) {
+			writeSSE(c, "done", cached)
+		}
+		return
+	}
+
+	reportJSON, err := translator.SummaryReportJSON(report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to serialize report",
+		})
+		return
+	}
+
+	// Заголовки потока отправляются с первым фрагментом: до этого ошибку
+	// можно вернуть обычным ответом с кодом статуса
+	started := false
+	summary, err := h.translator.StreamSummary(c.Request.Context(), jobID, report.Locale, string(reportJSON), func(text string) {
+		if !started {
+			startSSE(c)
+			started = true
+		}
+		writeSSE(c, "delta", gin.H{"text": text})
+	})
+	if err != nil {
+		if !started {
+			h.respondSummaryError(c, err)
+			return
+		}
+		writeSSE(c, "error", summaryErrorResponse(err))
+		return
+	}
+
+	if !started {
+		startSSE(c)
+	}
+	writeSSE(c, "done", SummaryResponse{
+		JobID:       jobID,
+		URL:         report.URL,
+		Summary:     summary,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	})
+}
+
+// summaryReport загружает отчёт завершенной задачи. Если отчёт недоступен,
+// отвечает клиенту ошибкой и возвращает false.
+func (h *Handler) summaryReport(c *gin.Context, jobID string) (*domain.Report, bool) {
+	// Проверяем статус задачи
+	job, err := h.storage.GetJob(jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Job not found",
+		})
+		return nil, false
+	}
+
+	if job.Status != service.StatusCompleted {
+		c.JSON(http.StatusAccepted, ErrorResponse{
+			Error:   "not_ready",
+			Message: "Report is not ready yet",
+		})
+		return nil, false
+	}
+
+	// Получаем отчет
+	report, err := h.storage.GetReport(jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Report not found",
+		})
+		return nil, false
+	}
+	return report, true
+}
+
+// cachedSummary возвращает сохраненное резюме, если оно есть и не запрошена повторная генерация
+func cachedSummary(c *gin.Context, jobID string, report *domain.Report) (SummaryResponse, bool) {
+	if report.ExecutiveSummary == "" || c.Query("refresh") == "true" {
+		return SummaryResponse{}, false
+	}
+
+	response := SummaryResponse{
+		JobID:   jobID,
+		URL:     report.URL,
+		Summary: report.ExecutiveSummary,
+		Cached:  true,
+	}
+	if report.ExecutiveSummaryAt != nil {
+		response.GeneratedAt = report.ExecutiveSummaryAt.Format(time.RFC3339)
+	}
+	return response, true
+}
+
+// respondSummaryError отвечает ошибкой генерации резюме
+func (h *Handler) respondSummaryError(c *gin.Context, err error) {
+	if errors.Is(err, translator.ErrCircuitOpen) {
+		h.respondAIUnavailable(c)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, summaryErrorResponse(err))
+}
+
+// summaryErrorResponse описывает ошибку генерации резюме
+func summaryErrorResponse(err error) ErrorResponse {
+	if errors.Is(err, translator.ErrCircuitOpen) {
+		return ErrorResponse{
+			Error:   "ai_unavailable",
+			Message: "AI provider is temporarily unavailable, try again later",
+		}
+	}
+	return ErrorResponse{
+		Error:   "summary_generation_failed",
+		Message: fmt.Sprintf("Failed to generate summary: %v", err),
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUsagePeriod - период отчета о расходе, если from не задан
+const defaultUsagePeriod = 30 * 24 * time.Hour
+
+// GetUsage возвращает расход токенов и оценочную стоимость по дням.
+// Параметры from и to (RFC3339 или YYYY-MM-DD) задают период с точностью до дня (UTC),
+// по умолчанию - последние 30 дней. Расход берется из журнала по дням обращений к модели,
+// поэтому в нем остаются и задачи, удаленные очисткой.
+func (h *Handler) GetUsage(c *gin.Context) {
+	from, err := parseQueryTime(c.Query("from"), false)
+	if err != nil {
+		respondInvalidUsageQuery(c, "invalid from: "+err.Error())
+		return
+	}
+	to, err := parseQueryTime(c.Query("to"), true)
+	if err != nil {
+		respondInvalidUsageQuery(c, "invalid to: "+err.Error())
+		return
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultUsagePeriod)
+	}
+
+	fromDate, toDate := service.UsageRange(from, to)
+	days, err := h.storage.ListDailyUsage(fromDate, toDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to load usage",
+		})
+		return
+	}
+
+	var total service.TokenUsage
+	for _, day := range days {
+		total.Add(day.TokenUsage)
+	}
+	c.JSON(http.StatusOK, UsageResponse{
+		From:  from.UTC().Format(time.RFC3339),
+		To:    to.UTC().Format(time.RFC3339),
+		Days:  days,
+		Total: total,
+	})
+}
+
+func respondInvalidUsageQuery(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error:   "invalid_query",
+		Message: message,
+	})
+}
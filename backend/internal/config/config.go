@@ -2,14 +2,111 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config содержит конфигурацию приложения
 type Config struct {
 	ServerPort string
 	GinMode    string
-	OpenAIKey  string
+
+	// LLMProvider - API модели: "openai" (любой OpenAI-совместимый), "ollama" или "anthropic"
+	LLMProvider string
+	// LLMAPIKey - ключ API модели (без него облачные провайдеры работают в демо-режиме)
+	LLMAPIKey string
+	// LLMBaseURL - адрес API (пустой - адрес провайдера по умолчанию)
+	LLMBaseURL string
+	// LLMModel - модель (пустая - модель провайдера по умолчанию)
+	LLMModel string
+	// LLMMaxTokens - верхняя граница длины ответа модели (0 - без ограничения)
+	LLMMaxTokens int
+	// LLMTemperature - температура генерации (отрицательная - значение модели по умолчанию)
+	LLMTemperature float64
+	// LLMTimeout - таймаут одной попытки запроса к модели
+	LLMTimeout time.Duration
+	// LLMMaxAttempts - максимальное число попыток запроса при временных ошибках (429, 5xx, сеть)
+	LLMMaxAttempts int
+	// LLMBackoff - пауза перед повторной попыткой, удваивается с каждой попыткой
+	LLMBackoff time.Duration
+	// LLMMaxBackoff - верхняя граница паузы между попытками
+	LLMMaxBackoff time.Duration
+	// LLMBreakerThreshold - сколько запросов подряд должно завершиться ошибкой, чтобы перейти на статические описания
+	LLMBreakerThreshold int
+	// LLMBreakerCooldown - через сколько после отказа провайдера выполнить пробный запрос
+	LLMBreakerCooldown time.Duration
+	// LLMCacheTTL - сколько хранить описания нарушений от AI в кэше (0 - кэш отключен)
+	LLMCacheTTL time.Duration
+	// LLMConcurrency - сколько батчей одного анализа отправляется в модель одновременно
+	LLMConcurrency int
+	// LLMRequestsPerMinute - общий лимит запросов к модели в минуту (0 - без ограничения)
+	LLMRequestsPerMinute int
+	// LLMTokensPerMinute - общий лимит токенов в минуту (0 - без ограничения)
+	LLMTokensPerMinute int
+	// LLMNodeFixesPerRule - для скольких элементов каждого правила запрашивать исправленный HTML (0 - отключено)
+	LLMNodeFixesPerRule int
+	// LLMNodeFixesMax - общий лимит исправляемых элементов на анализ (0 - без ограничения)
+	LLMNodeFixesMax int
+	// LLMPrices - цены моделей в долларах за миллион токенов: "model=input:output,..."
+	LLMPrices string
+	// LLMPromptsDir - каталог с файлами <язык>.tmpl, переопределяющими встроенные промпты (пустой - без них)
+	LLMPromptsDir string
+	// LLMFixtureMode - "record" сохраняет ответы модели в LLMFixtureDir, "replay" отвечает из сохраненных
+	// файлов без обращения к модели (пустой - обычная работа)
+	LLMFixtureMode string
+	LLMFixtureDir  string
+
+	// RedactEnabled - убирать персональные данные и секреты из промптов, логов и уведомлений
+	RedactEnabled bool
+	// RedactPatterns - дополнительные регулярные выражения для удаления, по одному на строку
+	RedactPatterns string
+
+	// StorageBackend определяет хранилище задач и отчетов: "file", "sqlite" или "memory"
+	StorageBackend string
+	// DataDir - каталог, в котором файловое хранилище держит задачи и отчеты
+	DataDir string
+	// SQLitePath - путь к файлу базы для хранилища "sqlite"
+	SQLitePath string
+
+	// RetentionMaxAge - сколько хранить завершенные задачи (0 - без ограничения)
+	RetentionMaxAge time.Duration
+	// RetentionMaxCount - максимальное количество задач в хранилище (0 - без ограничения)
+	RetentionMaxCount int
+	// RetentionMaxBytes - максимальный размер задач, их запросов, отчетов, истории вебхуков и кэша описаний в байтах (0 - без ограничения)
+	RetentionMaxBytes int64
+	// RetentionInterval - период запуска очистки
+	RetentionInterval time.Duration
+
+	// WorkerCount - число анализов, обрабатываемых одновременно
+	WorkerCount int
+	// QueueMaxLength - максимальное число задач в очереди (0 - без ограничения)
+	QueueMaxLength int
+	// QueueRetryAfter - значение заголовка Retry-After при заполненной очереди
+	QueueRetryAfter time.Duration
+	// ShutdownTimeout - сколько ждать завершения запросов и, отдельно, текущих анализов при остановке
+	ShutdownTimeout time.Duration
+	// ResumeInterruptedJobs - возобновлять при запуске задачи, прерванные остановкой сервера
+	// (иначе они помечаются как failed)
+	ResumeInterruptedJobs bool
+
+	// IdempotencyKeyTTL - сколько хранится связь Idempotency-Key с созданной задачей
+	IdempotencyKeyTTL time.Duration
+	// DedupWindow - окно поиска одинаковых запросов на анализ (0 - отключено)
+	DedupWindow time.Duration
+
+	// WebhookSecret - ключ HMAC-подписи уведомлений на callback_url
+	WebhookSecret string
+	// WebhookMaxAttempts - максимальное число попыток доставки уведомления
+	WebhookMaxAttempts int
+	// WebhookTimeout - таймаут одного запроса к callback_url
+	WebhookTimeout time.Duration
+	// WebhookBackoff - пауза перед повторной доставкой, удваивается с каждой попыткой
+	WebhookBackoff time.Duration
+	// WebhookAllowedHosts - хосты, IP-адреса и сети CIDR, на которые разрешены уведомления
+	// (пустой - любые публичные адреса, внутренние запрещены)
+	WebhookAllowedHosts []string
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -17,8 +114,61 @@ func Load() *Config {
 	cfg := &Config{
 		ServerPort: getEnvWithFallback("PORT", "SERVER_PORT", "3001"),
 		GinMode:    getEnv("GIN_MODE", "release"),
-		OpenAIKey:  getEnv("OPENAI_API_KEY", ""),
+
+		LLMProvider:    getEnv("LLM_PROVIDER", "openai"),
+		LLMAPIKey:      getEnvWithFallback("LLM_API_KEY", "OPENAI_API_KEY", ""),
+		LLMBaseURL:     getEnv("LLM_BASE_URL", ""),
+		LLMModel:       getEnv("LLM_MODEL", ""),
+		LLMMaxTokens:   getEnvAsInt("LLM_MAX_TOKENS", 0),
+		LLMTemperature: getEnvAsFloat("LLM_TEMPERATURE", -1),
+
+		LLMTimeout:          getEnvAsDuration("LLM_TIMEOUT", 60*time.Second),
+		LLMMaxAttempts:      getEnvAsInt("LLM_MAX_ATTEMPTS", 3),
+		LLMBackoff:          getEnvAsDuration("LLM_BACKOFF", time.Second),
+		LLMMaxBackoff:       getEnvAsDuration("LLM_MAX_BACKOFF", 30*time.Second),
+		LLMBreakerThreshold: getEnvAsInt("LLM_BREAKER_THRESHOLD", 5),
+		LLMBreakerCooldown:  getEnvAsDuration("LLM_BREAKER_COOLDOWN", 30*time.Second),
+		LLMCacheTTL:         getEnvAsDuration("LLM_CACHE_TTL", 30*24*time.Hour),
+
+		LLMConcurrency:       getEnvAsInt("LLM_CONCURRENCY", 3),
+		LLMRequestsPerMinute: getEnvAsInt("LLM_REQUESTS_PER_MINUTE", 0),
+		LLMTokensPerMinute:   getEnvAsInt("LLM_TOKENS_PER_MINUTE", 0),
+		LLMNodeFixesPerRule:  getEnvAsInt("LLM_NODE_FIXES_PER_RULE", 0),
+		LLMNodeFixesMax:      getEnvAsInt("LLM_NODE_FIXES_MAX", 30),
+		LLMPrices:            getEnv("LLM_PRICES", ""),
+		LLMPromptsDir:        getEnv("LLM_PROMPTS_DIR", ""),
+		LLMFixtureMode:       getEnv("LLM_FIXTURE_MODE", ""),
+		LLMFixtureDir:        getEnv("LLM_FIXTURE_DIR", "testdata/llm_fixtures"),
+
+		RedactEnabled:  getEnvAsBool("REDACT_ENABLED", true),
+		RedactPatterns: getEnv("REDACT_PATTERNS", ""),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", "file"),
+		DataDir:        getEnv("DATA_DIR", "data"),
+
+		RetentionMaxAge:   getEnvAsDuration("RETENTION_MAX_AGE", 0),
+		RetentionMaxCount: getEnvAsInt("RETENTION_MAX_COUNT", 0),
+		RetentionMaxBytes: getEnvAsInt64("RETENTION_MAX_BYTES", 0),
+		RetentionInterval: getEnvAsDuration("RETENTION_INTERVAL", 10*time.Minute),
+
+		WorkerCount:     getEnvAsInt("WORKER_COUNT", 2),
+		QueueMaxLength:  getEnvAsInt("QUEUE_MAX_LENGTH", 100),
+		QueueRetryAfter: getEnvAsDuration("QUEUE_RETRY_AFTER", 30*time.Second),
+
+		ShutdownTimeout:       getEnvAsDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		ResumeInterruptedJobs: getEnvAsBool("RESUME_INTERRUPTED_JOBS", true),
+
+		IdempotencyKeyTTL: getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		DedupWindow:       getEnvAsDuration("DEDUP_WINDOW", 0),
+
+		WebhookSecret:      getEnv("WEBHOOK_SECRET", ""),
+		WebhookMaxAttempts: getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookTimeout:     getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookBackoff:     getEnvAsDuration("WEBHOOK_BACKOFF", 5*time.Second),
+
+		WebhookAllowedHosts: getEnvAsList("WEBHOOK_ALLOWED_HOSTS"),
 	}
+	cfg.SQLitePath = getEnv("SQLITE_PATH", filepath.Join(cfg.DataDir, "analyzer.db"))
 
 	return cfg
 }
@@ -48,3 +198,48 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseInt(valueStr, 10, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsBool разбирает значения вида "true", "false", "1", "0"
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsList разбирает значения, перечисленные через запятую
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsDuration разбирает значения вида "30s", "15m", "720h"
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -41,4 +41,8 @@ type AxeRelated struct {
 type AnalysisRequest struct {
 	URL        string         `json:"url" binding:"required"`
 	Violations []AxeViolation `json:"violations" binding:"required"`
+	// CallbackURL - адрес, на который придет подписанное уведомление о завершении задачи
+	CallbackURL string `json:"callback_url,omitempty"`
+	// Locale - язык отчета ("ru", "en"). Если не указан, выбирается по заголовку Accept-Language
+	Locale string `json:"locale,omitempty"`
 }
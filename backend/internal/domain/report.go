@@ -2,14 +2,36 @@ package domain
 
 import "time"
 
+// ImpactLevels - уровни важности нарушений axe-core от критического к незначительному.
+// В этом порядке проблемы группируются в отчете и получают исправления от AI.
+var ImpactLevels = []string{"critical", "serious", "moderate", "minor"}
+
+// ImpactRank возвращает позицию уровня важности в ImpactLevels (неизвестные - в конце)
+func ImpactRank(impact string) int {
+	for i, level := range ImpactLevels {
+		if level == impact {
+			return i
+		}
+	}
+	return len(ImpactLevels)
+}
+
 // Report представляет итоговый отчет о доступности
 type Report struct {
 	ID              string             `json:"id"`
 	URL             string             `json:"url"`
+	Revision        int                `json:"revision"`
 	CreatedAt       time.Time          `json:"created_at"`
 	Summary         ReportSummary      `json:"summary"`
 	IssuesByImpact  map[string][]Issue `json:"issues_by_impact"`
 	Recommendations []string           `json:"recommendations"`
+	// Locale - язык текстов отчета
+	Locale string `json:"locale"`
+	// PromptVersion - версия промптов, по которым получены тексты от AI
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// ExecutiveSummary - сохраненное резюме от AI (GET /api/v1/jobs/:id/report/summary)
+	ExecutiveSummary   string     `json:"executive_summary,omitempty"`
+	ExecutiveSummaryAt *time.Time `json:"executive_summary_at,omitempty"`
 }
 
 // ReportSummary содержит общую статистику
@@ -33,4 +55,24 @@ type Issue struct {
 	Tags             []string `json:"tags"`
 	HelpURL          string   `json:"help_url"`
 	Examples         []string `json:"examples"`
+	// CodeExample - пример исправленного кода от AI
+	CodeExample string `json:"code_example,omitempty"`
+	// Confidence - уверенность AI в описании от 0 до 1 (0 - описание не от AI)
+	Confidence float64 `json:"confidence,omitempty"`
+	// NodeFixes - исправления отдельных элементов от AI (только для части элементов)
+	NodeFixes []NodeFix `json:"node_fixes,omitempty"`
+	// AIRejected - ответ AI не прошел проверку на prompt injection, показано стандартное описание
+	AIRejected bool `json:"ai_rejected,omitempty"`
+}
+
+// NodeFix - исправленный AI HTML одного элемента страницы
+type NodeFix struct {
+	// Target - селектор элемента из axe-core
+	Target []string `json:"target"`
+	// HTML - исходный HTML элемента
+	HTML string `json:"html"`
+	// FixedHTML - минимально исправленный HTML
+	FixedHTML string `json:"fixed_html"`
+	// Explanation - что изменено и почему
+	Explanation string `json:"explanation"`
 }
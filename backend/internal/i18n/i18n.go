@@ -0,0 +1,95 @@
+// Package i18n содержит языки отчетов и тексты на каждом из них
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Поддерживаемые языки
+const (
+	Russian = "ru"
+	English = "en"
+)
+
+// Default - язык, если клиент его не указал и Accept-Language не подошел
+const Default = Russian
+
+// Supported возвращает коды языков, для которых есть каталог
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Normalize приводит тег языка ("en-US", "EN", "ru_RU") к поддерживаемому коду.
+// Возвращает false, если язык не поддерживается.
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[tag]; !ok {
+		return "", false
+	}
+	return tag, true
+}
+
+// Resolve возвращает поддерживаемый язык для сохраненного значения.
+// Пустое или неизвестное значение (например, в отчетах до появления языков) дает Default.
+func Resolve(locale string) string {
+	if normalized, ok := Normalize(locale); ok {
+		return normalized
+	}
+	return Default
+}
+
+// FromAcceptLanguage выбирает поддерживаемый язык из заголовка Accept-Language
+// с учетом весов q. Возвращает false, если ни один язык не подошел.
+func FromAcceptLanguage(header string) (string, bool) {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if locale, ok := Normalize(tag); ok && q > 0 {
+			candidates = append(candidates, candidate{locale, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale, true
+}
+
+// T возвращает текст сообщения key на языке locale, подставляя args через fmt.Sprintf.
+// Если сообщения нет в каталоге языка, используется каталог Default.
+func T(locale, key string, args ...interface{}) string {
+	text, ok := catalogs[Resolve(locale)][key]
+	if !ok {
+		if text, ok = catalogs[Default][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import "testing"
+
+// TestCatalogsComplete проверяет, что каталоги всех языков содержат одинаковые ключи
+func TestCatalogsComplete(t *testing.T) {
+	for locale, catalog := range catalogs {
+		for other, otherCatalog := range catalogs {
+			for key := range otherCatalog {
+				if catalog[key] == "" {
+					t.Errorf("catalog %q has no key %q present in %q", locale, key, other)
+				}
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"en":      English,
+		"EN-us":   English,
+		"ru_RU":   Russian,
+		" ru ":    Russian,
+		"de":      "",
+		"":        "",
+		"english": "",
+	}
+	for tag, want := range tests {
+		got, ok := Normalize(tag)
+		if got != want || ok != (want != "") {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tag, got, ok, want)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"en-US,en;q=0.9", English},
+		{"de-DE,en-US;q=0.8,ru;q=0.5", English},
+		{"en;q=0.3,ru-RU;q=0.7", Russian},
+		{"ru;q=0,en;q=0.1", English},
+		{"de,fr;q=0.9", ""},
+		{"en;q=abc", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, ok := FromAcceptLanguage(tt.header)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("FromAcceptLanguage(%q) = %q, %v; want %q", tt.header, got, ok, tt.want)
+		}
+	}
+}
+
+func TestTFallback(t *testing.T) {
+	if got := T(English, "pdf.total_issues", 3); got != "Total issues: 3" {
+		t.Errorf("unexpected English text %q", got)
+	}
+	if got := T("xx", "pdf.summary"); got != catalogs[Default]["pdf.summary"] {
+		t.Errorf("unknown locale should use default catalog, got %q", got)
+	}
+	if got := T(English, "missing.key"); got != "missing.key" {
+		t.Errorf("missing key should be returned as is, got %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package i18n
+
+// catalogs - тексты отчетов и сообщений для AI по языкам. Каталог каждого языка должен содержать все ключи.
+var catalogs = map[string]map[string]string{
+	Russian: {
+		// Общие рекомендации отчета
+		"recommendation.critical":      "[!] Обнаружено %d критических проблем. Рекомендуется исправить их в первую очередь.",
+		"recommendation.serious":       "[!!] Найдено %d серьезных проблем, которые могут значительно затруднить использование сайта.",
+		"recommendation.audit":         "[i] Рекомендуется провести комплексный аудит доступности с участием экспертов.",
+		"recommendation.common_issues": "[*] Частые проблемы: %s. Рассмотрите возможность автоматизации проверок.",
+		"recommendation.no_issues":     "[OK] Отличная работа! Серьезных проблем с доступностью не обнаружено.",
+		"issue.default_fix":            "Изучите документацию по ссылке для получения рекомендаций по исправлению.",
+
+		// PDF-отчет
+		"pdf.title":             "Отчёт о доступности",
+		"pdf.analyzed_at":       "Дата анализа: %s",
+		"pdf.date_format":       "02.01.2006 15:04",
+		"pdf.summary":           "Сводка",
+		"pdf.count.critical":    "Критических",
+		"pdf.count.serious":     "Серьёзных",
+		"pdf.count.moderate":    "Умеренных",
+		"pdf.count.minor":       "Незначительных",
+		"pdf.total_issues":      "Всего проблем: %d",
+		"pdf.recommendations":   "Рекомендации",
+		"pdf.impact.critical":   "Критические проблемы",
+		"pdf.impact.serious":    "Серьёзные проблемы",
+		"pdf.impact.moderate":   "Умеренные проблемы",
+		"pdf.impact.minor":      "Незначительные проблемы",
+		"pdf.description":       "Описание: %s",
+		"pdf.how_to_fix":        "Как исправить:",
+		"pdf.affected_elements": "Затронуто элементов: %d",
+		"pdf.html_examples":     "Примеры HTML:",
+		"pdf.node_fixes":        "Исправления элементов:",
+		"pdf.footer":            "Сгенерировано %s | Accessibility Analyzer",
+
+		// Ошибки проверки ответа AI, которые перечисляются модели при повторном запросе
+		"validation.invalid_json":             "ответ не является корректным JSON: %v",
+		"validation.unknown_rule":             "правило %q отсутствует в запросе",
+		"validation.duplicate_rule":           "правило %q описано несколько раз",
+		"validation.empty_fields":             "правило %q: поля description и how_to_fix не должны быть пустыми",
+		"validation.confidence_range":         "правило %q: confidence должен быть в диапазоне от 0 до 1",
+		"validation.unsafe":                   "правило %q: %s",
+		"validation.missing_rule":             "нет корректного описания для правила %q",
+		"validation.unsafe.instructions":      "ответ содержит инструкции для модели",
+		"validation.unsafe.links":             "ответ содержит ссылки на неизвестные сайты",
+		"validation.unsafe.code_script":       "пример кода содержит скрипт",
+		"validation.unsafe.code_instructions": "пример кода содержит инструкции для модели",
+	},
+	English: {
+		"recommendation.critical":      "[!] Found %d critical issues. Fix them first.",
+		"recommendation.serious":       "[!!] Found %d serious issues that can make the site significantly harder to use.",
+		"recommendation.audit":         "[i] Consider a comprehensive accessibility audit with experts.",
+		"recommendation.common_issues": "[*] Common issues: %s. Consider automating these checks.",
+		"recommendation.no_issues":     "[OK] Great job! No serious accessibility issues found.",
+		"issue.default_fix":            "See the linked documentation for remediation guidance.",
+
+		"pdf.title":             "Accessibility Report",
+		"pdf.analyzed_at":       "Analyzed: %s",
+		"pdf.date_format":       "Jan 2, 2006 15:04",
+		"pdf.summary":           "Summary",
+		"pdf.count.critical":    "Critical",
+		"pdf.count.serious":     "Serious",
+		"pdf.count.moderate":    "Moderate",
+		"pdf.count.minor":       "Minor",
+		"pdf.total_issues":      "Total issues: %d",
+		"pdf.recommendations":   "Recommendations",
+		"pdf.impact.critical":   "Critical issues",
+		"pdf.impact.serious":    "Serious issues",
+		"pdf.impact.moderate":   "Moderate issues",
+		"pdf.impact.minor":      "Minor issues",
+		"pdf.description":       "Description: %s",
+		"pdf.how_to_fix":        "How to fix:",
+		"pdf.affected_elements": "Affected elements: %d",
+		"pdf.html_examples":     "HTML examples:",
+		"pdf.node_fixes":        "Element fixes:",
+		"pdf.footer":            "Generated %s | Accessibility Analyzer",
+
+		"validation.invalid_json":             "the answer is not valid JSON: %v",
+		"validation.unknown_rule":             "rule %q is not in the request",
+		"validation.duplicate_rule":           "rule %q is described more than once",
+		"validation.empty_fields":             "rule %q: description and how_to_fix must not be empty",
+		"validation.confidence_range":         "rule %q: confidence must be between 0 and 1",
+		"validation.unsafe":                   "rule %q: %s",
+		"validation.missing_rule":             "no valid description for rule %q",
+		"validation.unsafe.instructions":      "the answer contains instructions for the model",
+		"validation.unsafe.links":             "the answer contains links to unknown sites",
+		"validation.unsafe.code_script":       "the code example contains a script",
+		"validation.unsafe.code_instructions": "the code example contains instructions for the model",
+	},
+}
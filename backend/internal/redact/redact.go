@@ -0,0 +1,164 @@
+// Package redact убирает персональные данные и секреты из текста, который покидает процесс:
+// промптов LLM, логов и уведомлений на callback_url
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Метки, которыми заменяются найденные данные
+const (
+	Placeholder      = "[REDACTED]"
+	EmailPlaceholder = "[REDACTED_EMAIL]"
+	PhonePlaceholder = "[REDACTED_PHONE]"
+	TokenPlaceholder = "[REDACTED_TOKEN]"
+)
+
+// rule - один детектор: шаблон и замена найденного фрагмента
+type rule struct {
+	pattern *regexp.Regexp
+	// template - замена с подстановками $1 (используется, если replace равен nil)
+	template string
+	// replace вычисляет замену по найденному фрагменту
+	replace func(match string) string
+}
+
+func (r rule) apply(s string) string {
+	if r.replace != nil {
+		return r.pattern.ReplaceAllStringFunc(s, r.replace)
+	}
+	return r.pattern.ReplaceAllString(s, r.template)
+}
+
+var (
+	inputTagPattern   = regexp.MustCompile(`(?i)<input\b[^>]*>`)
+	buttonTypePattern = regexp.MustCompile(`(?i)\stype\s*=\s*["']?(?:submit|button|reset|image)\b`)
+	inputValuePattern = regexp.MustCompile(`(?i)(\svalue\s*=\s*)(?:"[^"]+"|'[^']+'|[^\s"'>]+)`)
+	imageFilePattern  = regexp.MustCompile(`(?i)\.(?:png|jpe?g|gif|svg|webp|avif|ico)$`)
+)
+
+// builtin - встроенные детекторы в порядке применения. Значения полей и секреты
+// обрабатываются раньше email и телефонов, чтобы метки не дробились.
+var builtin = []rule{
+	// Значения полей ввода. Текст кнопок виден пользователю и нужен для проверок, он не меняется
+	{pattern: inputTagPattern, replace: func(tag string) string {
+		if buttonTypePattern.MatchString(tag) {
+			return tag
+		}
+		return inputValuePattern.ReplaceAllString(tag, `${1}"`+Placeholder+`"`)
+	}},
+	{pattern: regexp.MustCompile(`(?is)(<textarea\b[^>]*>)[^<]+(</textarea>)`), template: "${1}" + Placeholder + "${2}"},
+
+	// JWT: три части base64url, первые две начинаются с {"
+	{pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), template: TokenPlaceholder},
+	// Ключи API с известными префиксами (Stripe, OpenAI, Anthropic, GitHub, GitLab, Slack, AWS, Google, Groq)
+	{pattern: regexp.MustCompile(`\b(?:(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}|sk-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{30,}|glpat-[A-Za-z0-9_-]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|gsk_[A-Za-z0-9]{20,})`), template: TokenPlaceholder},
+	{pattern: regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]{16,}=*`), template: "${1}" + TokenPlaceholder},
+	// Параметры URL с токенами и учетными данными, в том числе после &amp;
+	{pattern: regexp.MustCompile(`(?i)([?&;](?:access_token|refresh_token|id_token|token|auth|api_?key|key|secret|client_secret|password|passwd|pwd|session|session_?id|sid|code|signature|sig|email|phone)=)[^&#"'\s<>\\]+`), template: "${1}" + Placeholder},
+
+	{pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`), replace: func(match string) string {
+		// Имена файлов вида logo@2x.png похожи на адреса, но ими не являются
+		if imageFilePattern.MatchString(match) {
+			return match
+		}
+		return EmailPlaceholder
+	}},
+	{pattern: regexp.MustCompile(`(?i)(tel:)[^"'<>&\\]+`), template: "${1}" + PhonePlaceholder},
+	// Международный формат, российский с 8 и американский (XXX) XXX-XXXX
+	{pattern: regexp.MustCompile(`\+\d[\d\s().-]{7,}\d|\b8[\s(.-]*\d{3}[\s).-]*\d{3}[\s.-]*\d{2}[\s.-]*\d{2}\b|\(\d{3}\)\s?\d{3}-\d{4}\b`), template: PhonePlaceholder},
+}
+
+// Redactor заменяет найденные персональные данные и секреты метками вида [REDACTED_EMAIL].
+// Методы nil-указателя возвращают данные без изменений. Безопасен для одновременного использования.
+type Redactor struct {
+	rules []rule
+}
+
+// New создает Redactor со встроенными детекторами и дополнительными шаблонами patterns -
+// регулярными выражениями по одному на строку. Совпадения с ними заменяются на [REDACTED].
+func New(patterns string) (*Redactor, error) {
+	rules := append([]rule(nil), builtin...)
+	for _, line := range strings.Split(patterns, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", line, err)
+		}
+		rules = append(rules, rule{pattern: pattern, template: Placeholder})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// String возвращает s с замененными персональными данными и секретами
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = rule.apply(s)
+	}
+	return s
+}
+
+// JSON применяет String ко всем строковым значениям JSON-документа (ключи не меняются).
+// Строки проверяются после декодирования, поэтому экранирование HTML в JSON не мешает поиску.
+func (r *Redactor) JSON(data []byte) ([]byte, error) {
+	if r == nil {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(r.value(value), "", "  ")
+}
+
+// value рекурсивно обрабатывает строки декодированного JSON
+func (r *Redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = r.value(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = r.value(v[key])
+		}
+	}
+	return v
+}
+
+// Writer возвращает io.Writer, который убирает данные из каждой записи перед передачей в w.
+// Подходит для log.SetOutput: пакет log пишет каждое сообщение одним вызовом Write.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &writer{redactor: r, w: w}
+}
+
+type writer struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redactor.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrEnrichmentNotCached возвращается, если в кэше нет описания с таким ключом
+var ErrEnrichmentNotCached = errors.New("enrichment is not cached")
+
+// CachedEnrichment - описание нарушения от AI, сохраненное для повторного использования.
+// Key однозначно определяется правилом, текстом axe, языком, моделью и версией промпта.
+type CachedEnrichment struct {
+	Key           string    `json:"key"`
+	RuleID        string    `json:"rule_id"`
+	Locale        string    `json:"locale"`
+	Model         string    `json:"model"`
+	PromptVersion string    `json:"prompt_version"`
+	Description   string    `json:"description"`
+	HowToFix      string    `json:"how_to_fix"`
+	CodeExample   string    `json:"code_example,omitempty"`
+	Confidence    float64   `json:"confidence"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// Expired сообщает, истек ли срок жизни записи к моменту now
+func (e *CachedEnrichment) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// sortCachedEnrichments упорядочивает записи кэша по возрастанию срока жизни
+func sortCachedEnrichments(entries []*CachedEnrichment) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].ExpiresAt.Equal(entries[j].ExpiresAt) {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].ExpiresAt.Before(entries[j].ExpiresAt)
+	})
+}
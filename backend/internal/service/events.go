@@ -0,0 +1,192 @@
+package service
+
+import (
+	"sync"
+)
+
+// Типы событий задачи
+const (
+	// EventStatus - изменение статуса или прогресса задачи
+	EventStatus = "status"
+	// EventProgress - обработан очередной батч нарушений
+	EventProgress = "progress"
+	// EventDone - задача завершилась (completed, failed или cancelled)
+	EventDone = "done"
+)
+
+// JobEvent - событие в ходе обработки задачи.
+// ID монотонно растут в пределах процесса и используются как Last-Event-ID в SSE.
+type JobEvent struct {
+	ID    int64
+	JobID string
+	Type  string
+	Data  interface{}
+}
+
+// Subscription - подписка на события одной задачи
+type Subscription struct {
+	// Replay - события из истории, пропущенные клиентом после Last-Event-ID
+	Replay []JobEvent
+	// Gap - история не содержит Last-Event-ID клиента, часть событий потеряна
+	Gap bool
+	// LastID - ID последнего опубликованного события задачи (0, если событий не было)
+	LastID int64
+	// Events - новые события. Канал закрывается после EventDone, при отписке
+	// или если подписчик не успевает читать (клиенту следует переподключиться)
+	Events <-chan JobEvent
+
+	bus *EventBus
+	ch  chan JobEvent
+}
+
+// Close отменяет подписку
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s.ch)
+}
+
+// jobStream хранит последние события задачи и ее подписчиков
+type jobStream struct {
+	history     []JobEvent
+	subscribers map[chan JobEvent]struct{}
+}
+
+// EventBus раздает события задач подписчикам (например, SSE-клиентам)
+// и хранит короткую историю для переподключения по Last-Event-ID.
+type EventBus struct {
+	historySize int
+	bufferSize  int
+
+	mu      sync.Mutex
+	lastID  int64
+	streams map[string]*jobStream
+	owners  map[chan JobEvent]string
+	closed  bool
+}
+
+// NewEventBus создает шину событий, хранящую до historySize последних событий каждой задачи
+func NewEventBus(historySize int) *EventBus {
+	return &EventBus{
+		historySize: historySize,
+		bufferSize:  64,
+		streams:     make(map[string]*jobStream),
+		owners:      make(map[chan JobEvent]string),
+	}
+}
+
+// Publish рассылает событие подписчикам задачи. После EventDone история задачи очищается,
+// а подписки закрываются: итоговое состояние дальше берется из хранилища.
+func (b *EventBus) Publish(jobID, eventType string, data interface{}) JobEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := JobEvent{ID: b.lastID, JobID: jobID, Type: eventType, Data: data}
+
+	stream := b.stream(jobID)
+	stream.history = append(stream.history, event)
+	if len(stream.history) > b.historySize {
+		stream.history = stream.history[len(stream.history)-b.historySize:]
+	}
+
+	for ch := range stream.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Медленный подписчик: закрываем канал, клиент переподключится с Last-Event-ID
+			b.closeLocked(ch)
+		}
+	}
+
+	if eventType == EventDone {
+		for ch := range stream.subscribers {
+			b.closeLocked(ch)
+		}
+		delete(b.streams, jobID)
+	}
+
+	return event
+}
+
+// Subscribe подписывается на события задачи, начиная после события afterID (0 - только новые)
+func (b *EventBus) Subscribe(jobID string, afterID int64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan JobEvent, b.bufferSize)
+	sub := &Subscription{Events: ch, bus: b, ch: ch}
+	if b.closed {
+		close(ch)
+		return sub
+	}
+
+	stream := b.stream(jobID)
+	stream.subscribers[ch] = struct{}{}
+	b.owners[ch] = jobID
+
+	if n := len(stream.history); n > 0 {
+		sub.LastID = stream.history[n-1].ID
+	}
+
+	if afterID > 0 {
+		found := false
+		for _, event := range stream.history {
+			if found {
+				sub.Replay = append(sub.Replay, event)
+			}
+			if event.ID == afterID {
+				found = true
+			}
+		}
+		sub.Gap = !found
+		if sub.Gap {
+			sub.Replay = nil
+		}
+	}
+
+	return sub
+}
+
+// Close закрывает все подписки, например при остановке сервера,
+// чтобы долгие SSE-соединения завершились. Новые подписки сразу закрыты.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.owners {
+		b.closeLocked(ch)
+	}
+}
+
+func (b *EventBus) unsubscribe(ch chan JobEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeLocked(ch)
+}
+
+// closeLocked закрывает канал подписчика и удаляет пустой поток. Вызывается под b.mu.
+func (b *EventBus) closeLocked(ch chan JobEvent) {
+	jobID, ok := b.owners[ch]
+	if !ok {
+		return
+	}
+	delete(b.owners, ch)
+	close(ch)
+
+	if stream, exists := b.streams[jobID]; exists {
+		delete(stream.subscribers, ch)
+		if len(stream.subscribers) == 0 && len(stream.history) == 0 {
+			delete(b.streams, jobID)
+		}
+	}
+}
+
+// stream возвращает поток задачи, создавая его при необходимости. Вызывается под b.mu.
+func (b *EventBus) stream(jobID string) *jobStream {
+	stream, exists := b.streams[jobID]
+	if !exists {
+		stream = &jobStream{subscribers: make(map[chan JobEvent]struct{})}
+		b.streams[jobID] = stream
+	}
+	return stream
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+)
+
+// TestEventBusReplayAfterLastEventID проверяет доставку новых событий и повтор пропущенных
+func TestEventBusReplayAfterLastEventID(t *testing.T) {
+	bus := NewEventBus(10)
+
+	first := bus.Publish("job-1", EventStatus, 1)
+	bus.Publish("job-2", EventStatus, "other job")
+	second := bus.Publish("job-1", EventProgress, 2)
+
+	live := bus.Subscribe("job-1", 0)
+	defer live.Close()
+	if len(live.Replay) != 0 || live.Gap {
+		t.Errorf("new subscriber should not get replay, got %+v", live)
+	}
+	if live.LastID != second.ID {
+		t.Errorf("expected LastID %d, got %d", second.ID, live.LastID)
+	}
+
+	resumed := bus.Subscribe("job-1", first.ID)
+	defer resumed.Close()
+	if resumed.Gap || len(resumed.Replay) != 1 || resumed.Replay[0].ID != second.ID {
+		t.Errorf("expected replay of event %d, got %+v", second.ID, resumed.Replay)
+	}
+
+	third := bus.Publish("job-1", EventStatus, 3)
+	for _, sub := range []*Subscription{live, resumed} {
+		if event := <-sub.Events; event.ID != third.ID {
+			t.Errorf("expected live event %d, got %d", third.ID, event.ID)
+		}
+	}
+}
+
+// TestEventBusGapAndDone проверяет потерю истории и закрытие подписок после итогового события
+func TestEventBusGapAndDone(t *testing.T) {
+	bus := NewEventBus(2)
+
+	first := bus.Publish("job", EventStatus, 1)
+	bus.Publish("job", EventStatus, 2)
+	bus.Publish("job", EventStatus, 3)
+
+	// Событие first вытеснено из истории
+	sub := bus.Subscribe("job", first.ID)
+	if !sub.Gap || len(sub.Replay) != 0 {
+		t.Errorf("expected gap without replay, got %+v", sub)
+	}
+
+	done := bus.Publish("job", EventDone, nil)
+	if event := <-sub.Events; event.ID != done.ID || event.Type != EventDone {
+		t.Errorf("expected done event, got %+v", event)
+	}
+	if _, ok := <-sub.Events; ok {
+		t.Error("expected subscription to be closed after done")
+	}
+	sub.Close()
+
+	// После завершения история задачи очищается
+	if after := bus.Subscribe("job", done.ID); !after.Gap || after.LastID != 0 {
+		t.Errorf("expected empty history after done, got %+v", after)
+	}
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+const (
+	jobsDirName        = "jobs"
+	reportsDirName     = "reports"
+	requestsDirName    = "requests"
+	revisionsDirName   = "revisions"
+	webhooksDirName    = "webhooks"
+	enrichmentsDirName = "enrichments"
+	usageDirName       = "usage"
+)
+
+// FileStorage хранит задачи и отчеты в JSON-файлах внутри каталога данных.
+// Все данные дублируются в памяти: чтение идет из памяти, запись — сначала на диск.
+type FileStorage struct {
+	dir   string
+	cache *MemoryStorage
+	// mu сериализует запись на диск, чтобы порядок файлов совпадал с порядком в памяти
+	mu sync.Mutex
+}
+
+// NewFileStorage создает файловое хранилище и загружает ранее сохраненные данные
+func NewFileStorage(dir string) (*FileStorage, error) {
+	// Журнал расхода появился позже задач: если его еще нет, заполняем его из сохраненных задач
+	_, err := os.Stat(filepath.Join(dir, usageDirName))
+	backfillUsage := errors.Is(err, os.ErrNotExist)
+
+	for _, sub := range []string{jobsDirName, reportsDirName, requestsDirName, revisionsDirName, webhooksDirName, enrichmentsDirName, usageDirName} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+	}
+
+	s := &FileStorage{
+		dir:   dir,
+		cache: NewMemoryStorage(),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if backfillUsage {
+		if err := s.backfillUsage(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// SaveJob сохраняет задачу
+func (s *FileStorage) SaveJob(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeJSONAtomic(s.jobPath(job.ID), job); err != nil {
+		return fmt.Errorf("failed to persist job: %w", err)
+	}
+	return s.cache.SaveJob(job)
+}
+
+// GetJob получает задачу по ID
+func (s *FileStorage) GetJob(id string) (*Job, error) {
+	return s.cache.GetJob(id)
+}
+
+// UpdateJob атомарно изменяет задачу и сохраняет результат на диск
+func (s *FileStorage) UpdateJob(id string, update JobUpdate) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.cache.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(job); err != nil {
+		return nil, err
+	}
+
+	if err := writeJSONAtomic(s.jobPath(id), job); err != nil {
+		return nil, fmt.Errorf("failed to persist job: %w", err)
+	}
+	if err := s.cache.SaveJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// DeleteJob удаляет задачу вместе с отчетом
+func (s *FileStorage) DeleteJob(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteJob(id)
+}
+
+// DeleteJobIf удаляет задачу, если ее текущее состояние удовлетворяет cond
+func (s *FileStorage) DeleteJobIf(id string, cond func(job *Job) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.cache.GetJob(id)
+	if errors.Is(err, ErrJobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !cond(job) {
+		return false, nil
+	}
+	if err := s.deleteJob(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteJob удаляет файлы задачи и связанных с ней данных; вызывается под s.mu
+func (s *FileStorage) deleteJob(id string) error {
+	for _, path := range []string{s.jobPath(id), s.reportPath(id), s.requestPath(id)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+	}
+	if err := os.RemoveAll(s.revisionsDir(id)); err != nil {
+		return fmt.Errorf("failed to delete revisions: %w", err)
+	}
+	if err := os.RemoveAll(s.webhooksDir(id)); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return s.cache.DeleteJob(id)
+}
+
+// ListJobs возвращает все задачи, отсортированные по времени создания
+func (s *FileStorage) ListJobs() ([]*Job, error) {
+	return s.cache.ListJobs()
+}
+
+// QueryJobs возвращает страницу задач согласно запросу
+func (s *FileStorage) QueryJobs(query JobQuery) (*JobPage, error) {
+	return s.cache.QueryJobs(query)
+}
+
+// SaveReport сохраняет отчет
+func (s *FileStorage) SaveReport(report *domain.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeJSONAtomic(s.reportPath(report.ID), report); err != nil {
+		return fmt.Errorf("failed to persist report: %w", err)
+	}
+	return s.cache.SaveReport(report)
+}
+
+// SaveReportSummary сохраняет резюме от AI в отчете
+func (s *FileStorage) SaveReportSummary(id, summary string, generatedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, err := s.cache.GetReport(id)
+	if err != nil {
+		return err
+	}
+	updated := *report
+	updated.ExecutiveSummary = summary
+	updated.ExecutiveSummaryAt = &generatedAt
+	if err := writeJSONAtomic(s.reportPath(id), &updated); err != nil {
+		return fmt.Errorf("failed to persist report summary: %w", err)
+	}
+	return s.cache.SaveReport(&updated)
+}
+
+// GetReport получает отчет по ID
+func (s *FileStorage) GetReport(id string) (*domain.Report, error) {
+	return s.cache.GetReport(id)
+}
+
+// ListReports возвращает все отчеты, отсортированные по времени создания
+func (s *FileStorage) ListReports() ([]*domain.Report, error) {
+	return s.cache.ListReports()
+}
+
+// SaveRequest сохраняет исходный запрос задачи
+func (s *FileStorage) SaveRequest(jobID string, req *domain.AnalysisRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeJSONAtomic(s.requestPath(jobID), req); err != nil {
+		return fmt.Errorf("failed to persist request: %w", err)
+	}
+	return s.cache.SaveRequest(jobID, req)
+}
+
+// GetRequest получает исходный запрос задачи
+func (s *FileStorage) GetRequest(jobID string) (*domain.AnalysisRequest, error) {
+	return s.cache.GetRequest(jobID)
+}
+
+// SaveReportRevision архивирует версию отчета
+func (s *FileStorage) SaveReportRevision(report *domain.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.revisionsDir(report.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create revisions directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d.json", report.Revision))
+	if err := writeJSONAtomic(path, report); err != nil {
+		return fmt.Errorf("failed to persist report revision: %w", err)
+	}
+	return s.cache.SaveReportRevision(report)
+}
+
+// ListReportRevisions возвращает архивные версии отчета
+func (s *FileStorage) ListReportRevisions(id string) ([]*domain.Report, error) {
+	return s.cache.ListReportRevisions(id)
+}
+
+// SaveWebhookDelivery записывает попытку доставки вебхука
+func (s *FileStorage) SaveWebhookDelivery(delivery *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.webhooksDir(delivery.JobID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create webhooks directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%d.json", safeFileName(delivery.ID), delivery.Attempt)
+	if err := writeJSONAtomic(filepath.Join(dir, name), delivery); err != nil {
+		return fmt.Errorf("failed to persist webhook delivery: %w", err)
+	}
+	return s.cache.SaveWebhookDelivery(delivery)
+}
+
+// ListWebhookDeliveries возвращает попытки доставки вебхуков задачи
+func (s *FileStorage) ListWebhookDeliveries(jobID string) ([]*WebhookDelivery, error) {
+	return s.cache.ListWebhookDeliveries(jobID)
+}
+
+// AddDailyUsage прибавляет расход токенов к дню
+func (s *FileStorage) AddDailyUsage(date string, usage TokenUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days, err := s.cache.ListDailyUsage(date, date)
+	if err != nil {
+		return err
+	}
+	day := DailyUsage{Date: date}
+	if len(days) == 1 {
+		day = days[0]
+	}
+	day.Add(usage)
+	if err := writeJSONAtomic(s.usagePath(date), &day); err != nil {
+		return fmt.Errorf("failed to persist daily usage: %w", err)
+	}
+	return s.cache.AddDailyUsage(date, usage)
+}
+
+// ListDailyUsage возвращает расход за дни с from по to включительно
+func (s *FileStorage) ListDailyUsage(from, to string) ([]DailyUsage, error) {
+	return s.cache.ListDailyUsage(from, to)
+}
+
+// backfillUsage заполняет журнал расхода по дням создания задач, сохраненных до его появления
+func (s *FileStorage) backfillUsage() error {
+	jobs, err := s.cache.ListJobs()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Usage == nil {
+			continue
+		}
+		if err := s.AddDailyUsage(UsageDate(job.CreatedAt), *job.Usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveCachedEnrichment сохраняет описание от AI в кэш
+func (s *FileStorage) SaveCachedEnrichment(entry *CachedEnrichment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeJSONAtomic(s.enrichmentPath(entry.Key), entry); err != nil {
+		return fmt.Errorf("failed to persist cached enrichment: %w", err)
+	}
+	return s.cache.SaveCachedEnrichment(entry)
+}
+
+// GetCachedEnrichment возвращает запись кэша по ключу
+func (s *FileStorage) GetCachedEnrichment(key string) (*CachedEnrichment, error) {
+	return s.cache.GetCachedEnrichment(key)
+}
+
+// ListCachedEnrichments возвращает все записи кэша по возрастанию срока жизни
+func (s *FileStorage) ListCachedEnrichments() ([]*CachedEnrichment, error) {
+	return s.cache.ListCachedEnrichments()
+}
+
+// DeleteExpiredEnrichments удаляет просроченные записи кэша вместе с их файлами
+func (s *FileStorage) DeleteExpiredEnrichments(now time.Time) ([]*CachedEnrichment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.cache.ListCachedEnrichments()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if !entry.Expired(now) {
+			break
+		}
+		keys = append(keys, entry.Key)
+	}
+	return s.deleteCachedEnrichments(keys)
+}
+
+// DeleteCachedEnrichments удаляет записи кэша по ключам
+func (s *FileStorage) DeleteCachedEnrichments(keys []string) ([]*CachedEnrichment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteCachedEnrichments(keys)
+}
+
+// deleteCachedEnrichments удаляет файлы записей кэша, затем сами записи; вызывается под s.mu
+func (s *FileStorage) deleteCachedEnrichments(keys []string) ([]*CachedEnrichment, error) {
+	for _, key := range keys {
+		if err := os.Remove(s.enrichmentPath(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to delete cached enrichment: %w", err)
+		}
+	}
+	return s.cache.DeleteCachedEnrichments(keys)
+}
+
+// Sizes возвращает размеры данных, вычисленные при записи в память
+func (s *FileStorage) Sizes() (*StorageSizes, error) {
+	return s.cache.Sizes()
+}
+
+// load читает сохраненные данные с диска в память
+func (s *FileStorage) load() error {
+	jobs, err := readJSONDir[Job](filepath.Join(s.dir, jobsDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load jobs: %w", err)
+	}
+	for _, job := range jobs {
+		s.cache.SaveJob(job)
+	}
+
+	reports, err := readJSONDir[domain.Report](filepath.Join(s.dir, reportsDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load reports: %w", err)
+	}
+	for _, report := range reports {
+		s.cache.SaveReport(report)
+	}
+
+	requests, err := readJSONDir[domain.AnalysisRequest](filepath.Join(s.dir, requestsDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load requests: %w", err)
+	}
+	for jobID, req := range requests {
+		s.cache.SaveRequest(jobID, req)
+	}
+
+	revisionDirs, err := os.ReadDir(filepath.Join(s.dir, revisionsDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load revisions: %w", err)
+	}
+	for _, entry := range revisionDirs {
+		if !entry.IsDir() {
+			continue
+		}
+		revisions, err := readJSONDir[domain.Report](filepath.Join(s.dir, revisionsDirName, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to load revisions: %w", err)
+		}
+		for _, report := range revisions {
+			s.cache.SaveReportRevision(report)
+		}
+	}
+
+	webhookDirs, err := os.ReadDir(filepath.Join(s.dir, webhooksDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+	for _, entry := range webhookDirs {
+		if !entry.IsDir() {
+			continue
+		}
+		deliveries, err := readJSONDir[WebhookDelivery](filepath.Join(s.dir, webhooksDirName, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to load webhook deliveries: %w", err)
+		}
+		for _, delivery := range deliveries {
+			s.cache.SaveWebhookDelivery(delivery)
+		}
+	}
+
+	enrichments, err := readJSONDir[CachedEnrichment](filepath.Join(s.dir, enrichmentsDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load cached enrichments: %w", err)
+	}
+	for _, entry := range enrichments {
+		s.cache.SaveCachedEnrichment(entry)
+	}
+
+	usage, err := readJSONDir[DailyUsage](filepath.Join(s.dir, usageDirName))
+	if err != nil {
+		return fmt.Errorf("failed to load daily usage: %w", err)
+	}
+	for _, day := range usage {
+		s.cache.AddDailyUsage(day.Date, day.TokenUsage)
+	}
+
+	log.Printf("[Storage] Loaded %d jobs and %d reports from %s", len(jobs), len(reports), s.dir)
+	return nil
+}
+
+func (s *FileStorage) jobPath(id string) string {
+	return filepath.Join(s.dir, jobsDirName, safeFileName(id)+".json")
+}
+
+func (s *FileStorage) reportPath(id string) string {
+	return filepath.Join(s.dir, reportsDirName, safeFileName(id)+".json")
+}
+
+func (s *FileStorage) requestPath(id string) string {
+	return filepath.Join(s.dir, requestsDirName, safeFileName(id)+".json")
+}
+
+func (s *FileStorage) revisionsDir(id string) string {
+	return filepath.Join(s.dir, revisionsDirName, safeFileName(id))
+}
+
+func (s *FileStorage) webhooksDir(id string) string {
+	return filepath.Join(s.dir, webhooksDirName, safeFileName(id))
+}
+
+func (s *FileStorage) enrichmentPath(key string) string {
+	return filepath.Join(s.dir, enrichmentsDirName, safeFileName(key)+".json")
+}
+
+func (s *FileStorage) usagePath(date string) string {
+	return filepath.Join(s.dir, usageDirName, safeFileName(date)+".json")
+}
+
+// safeFileName не дает ID выйти за пределы каталога данных
+func safeFileName(id string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(id)
+}
+
+// writeJSONAtomic записывает значение во временный файл и атомарно переименовывает его,
+// чтобы после падения процесса на диске не оставалось наполовину записанных файлов
+func writeJSONAtomic(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// readJSONDir читает все *.json файлы каталога и возвращает их по имени файла без расширения.
+// Поврежденные файлы пропускаются, оставшиеся после сбоя временные файлы удаляются.
+func readJSONDir[T any](dir string) (map[string]*T, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*T)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			os.Remove(path)
+			continue
+		}
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		item := new(T)
+		if err := json.Unmarshal(data, item); err != nil {
+			log.Printf("[Storage] Skipping corrupted file %s: %v", path, err)
+			continue
+		}
+		items[strings.TrimSuffix(entry.Name(), ".json")] = item
+	}
+
+	return items, nil
+}
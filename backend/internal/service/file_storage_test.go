@@ -0,0 +1,141 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// TestFileStorageReload проверяет, что задачи и отчеты переживают перезапуск хранилища
+func TestFileStorageReload(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage returned error: %v", err)
+	}
+
+	job := NewJob("https://example.com")
+	job.UpdateStatus(StatusCompleted)
+	if err := s.SaveJob(job); err != nil {
+		t.Fatalf("SaveJob returned error: %v", err)
+	}
+
+	report := &domain.Report{
+		ID:  job.ID,
+		URL: job.URL,
+		Summary: domain.ReportSummary{
+			TotalIssues: 1,
+			Critical:    1,
+		},
+		IssuesByImpact: map[string][]domain.Issue{
+			"critical": {{ID: "image-alt", Impact: "critical", Title: "alt"}},
+		},
+	}
+	if err := s.SaveReport(report); err != nil {
+		t.Fatalf("SaveReport returned error: %v", err)
+	}
+
+	if err := s.AddDailyUsage("2025-01-10", TokenUsage{Requests: 1, PromptTokens: 10, TotalTokens: 10}); err != nil {
+		t.Fatalf("AddDailyUsage returned error: %v", err)
+	}
+
+	// Имитируем незавершенную запись, оставшуюся после падения процесса
+	leftover := filepath.Join(dir, jobsDirName, "broken.json.123.tmp")
+	if err := os.WriteFile(leftover, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+
+	loadedJob, err := reopened.GetJob(job.ID)
+	if err != nil {
+		t.Fatalf("job was not reloaded: %v", err)
+	}
+	if loadedJob.Status != StatusCompleted || loadedJob.URL != job.URL {
+		t.Errorf("unexpected reloaded job: %+v", loadedJob)
+	}
+
+	loadedReport, err := reopened.GetReport(job.ID)
+	if err != nil {
+		t.Fatalf("report was not reloaded: %v", err)
+	}
+	if loadedReport.Summary.Critical != 1 || len(loadedReport.IssuesByImpact["critical"]) != 1 {
+		t.Errorf("unexpected reloaded report: %+v", loadedReport)
+	}
+
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("expected leftover temp file to be removed")
+	}
+
+	jobs, _ := reopened.ListJobs()
+	if len(jobs) != 1 {
+		t.Errorf("expected 1 job after reload, got %d", len(jobs))
+	}
+
+	if days, _ := reopened.ListDailyUsage("2025-01-10", "2025-01-10"); len(days) != 1 || days[0].PromptTokens != 10 {
+		t.Errorf("daily usage was not reloaded: %+v", days)
+	}
+}
+
+// TestFileStorageBackfillsDailyUsage проверяет заполнение журнала расхода из задач,
+// сохраненных до его появления
+func TestFileStorageBackfillsDailyUsage(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := NewJob("https://example.com")
+	job.CreatedAt = time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	job.AddUsage(TokenUsage{Requests: 2, PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40})
+	s.SaveJob(job)
+	if err := os.RemoveAll(filepath.Join(dir, usageDirName)); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	days, _ := reopened.ListDailyUsage("2025-01-01", "2025-01-31")
+	if len(days) != 1 || days[0].Date != "2025-01-10" || days[0].Requests != 2 || days[0].TotalTokens != 40 {
+		t.Errorf("unexpected backfilled usage: %+v", days)
+	}
+}
+
+// TestFileStorageDelete проверяет, что удаление убирает файлы с диска
+func TestFileStorageDelete(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage returned error: %v", err)
+	}
+
+	job := NewJob("https://example.com")
+	s.SaveJob(job)
+	s.SaveReport(&domain.Report{ID: job.ID, URL: job.URL})
+
+	if err := s.DeleteJob(job.ID); err != nil {
+		t.Fatalf("DeleteJob returned error: %v", err)
+	}
+
+	reopened, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	if _, err := reopened.GetJob(job.ID); err != ErrJobNotFound {
+		t.Errorf("expected ErrJobNotFound after delete, got %v", err)
+	}
+	if _, err := reopened.GetReport(job.ID); err != ErrReportNotFound {
+		t.Errorf("expected ErrReportNotFound after delete, got %v", err)
+	}
+}
@@ -14,8 +14,14 @@ const (
 	StatusProcessing JobStatus = "processing"
 	StatusCompleted  JobStatus = "completed"
 	StatusFailed     JobStatus = "failed"
+	StatusCancelled  JobStatus = "cancelled"
 )
 
+// IsFinal сообщает, что задача больше не будет обрабатываться
+func (s JobStatus) IsFinal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
 // Job представляет задачу анализа
 type Job struct {
 	ID        string    `json:"id"`
@@ -25,6 +31,12 @@ type Job struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Error     string    `json:"error,omitempty"`
 	Progress  int       `json:"progress"`
+	// IdempotencyKey - значение заголовка Idempotency-Key запроса, создавшего задачу
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RequestHash - хеш содержимого запроса (см. RequestHash) для поиска дублей
+	RequestHash string `json:"request_hash,omitempty"`
+	// Usage - расход токенов LLM на задачу, включая повторные обработки и резюме
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
 
 // NewJob создает новую задачу
@@ -40,6 +52,18 @@ func NewJob(url string) *Job {
 	}
 }
 
+// Clone возвращает независимую копию задачи.
+// Хранилища отдают и принимают только копии, поэтому читатели никогда не видят
+// задачу в процессе изменения.
+func (j *Job) Clone() *Job {
+	clone := *j
+	if j.Usage != nil {
+		usage := *j.Usage
+		clone.Usage = &usage
+	}
+	return &clone
+}
+
 // UpdateStatus обновляет статус задачи
 func (j *Job) UpdateStatus(status JobStatus) {
 	j.Status = status
@@ -53,8 +77,30 @@ func (j *Job) SetError(err string) {
 	j.UpdatedAt = time.Now()
 }
 
+// Cancel помечает задачу отмененной
+func (j *Job) Cancel() {
+	j.Status = StatusCancelled
+	j.UpdatedAt = time.Now()
+}
+
+// Requeue возвращает задачу в очередь для повторной обработки
+func (j *Job) Requeue() {
+	j.Status = StatusPending
+	j.Progress = 0
+	j.Error = ""
+	j.UpdatedAt = time.Now()
+}
+
 // SetProgress устанавливает прогресс
 func (j *Job) SetProgress(progress int) {
 	j.Progress = progress
 	j.UpdatedAt = time.Now()
 }
+
+// AddUsage учитывает расход токенов на задачу
+func (j *Job) AddUsage(usage TokenUsage) {
+	if j.Usage == nil {
+		j.Usage = &TokenUsage{}
+	}
+	j.Usage.Add(usage)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// ErrInvalidCursor возвращается, если курсор пагинации поврежден
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Поля сортировки списка задач
+const (
+	SortByCreatedAt   = "created_at"
+	SortByTotalIssues = "total_issues"
+	SortByCritical    = "critical"
+)
+
+// Ограничения размера страницы
+const (
+	DefaultJobPageSize = 20
+	MaxJobPageSize     = 100
+)
+
+// JobQuery описывает фильтры, сортировку и пагинацию списка задач.
+// Нулевые значения полей не ограничивают выборку.
+type JobQuery struct {
+	// Statuses - допустимые статусы задачи
+	Statuses []JobStatus
+	// URLPrefix - начало URL страницы (без учета регистра)
+	URLPrefix string
+	// Domain - домен страницы; поддомены тоже подходят
+	Domain string
+	// CreatedFrom и CreatedTo ограничивают время создания: [CreatedFrom, CreatedTo)
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// MinCritical - минимальное число критических проблем в отчете
+	MinCritical int
+	// IdempotencyKey и RequestHash ищут задачу, созданную тем же запросом
+	IdempotencyKey string
+	RequestHash    string
+
+	// SortBy - поле сортировки: SortByCreatedAt (по умолчанию), SortByTotalIssues или SortByCritical
+	SortBy string
+	// Ascending - сортировать по возрастанию (по умолчанию - по убыванию)
+	Ascending bool
+	// Limit - размер страницы (по умолчанию DefaultJobPageSize, не больше MaxJobPageSize)
+	Limit int
+	// Cursor - значение NextCursor предыдущей страницы
+	Cursor string
+}
+
+// JobListEntry - задача в списке вместе со статистикой ее отчета
+type JobListEntry struct {
+	Job *Job
+	// Summary - статистика отчета, если отчет уже сохранен
+	Summary *domain.ReportSummary
+}
+
+// JobPage - страница списка задач
+type JobPage struct {
+	Entries []*JobListEntry
+	// NextCursor - курсор следующей страницы (пустой, если страница последняя)
+	NextCursor string
+}
+
+// normalized возвращает запрос с примененными значениями по умолчанию
+func (q JobQuery) normalized() JobQuery {
+	if q.SortBy == "" {
+		q.SortBy = SortByCreatedAt
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultJobPageSize
+	}
+	if q.Limit > MaxJobPageSize {
+		q.Limit = MaxJobPageSize
+	}
+	q.Domain = strings.ToLower(q.Domain)
+	return q
+}
+
+// matches проверяет, подходит ли задача под фильтры запроса
+func (q JobQuery) matches(entry *JobListEntry) bool {
+	job := entry.Job
+
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			if job.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.URLPrefix != "" && !strings.HasPrefix(strings.ToLower(job.URL), strings.ToLower(q.URLPrefix)) {
+		return false
+	}
+	if q.Domain != "" {
+		host := jobHost(job.URL)
+		if host != q.Domain && !strings.HasSuffix(host, "."+q.Domain) {
+			return false
+		}
+	}
+	if !q.CreatedFrom.IsZero() && job.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !job.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
+	if q.MinCritical > 0 && (entry.Summary == nil || entry.Summary.Critical < q.MinCritical) {
+		return false
+	}
+	if q.IdempotencyKey != "" && job.IdempotencyKey != q.IdempotencyKey {
+		return false
+	}
+	if q.RequestHash != "" && job.RequestHash != q.RequestHash {
+		return false
+	}
+	return true
+}
+
+// jobCursor - позиция в отсортированном списке: значение поля сортировки,
+// затем время создания и ID для однозначного порядка
+type jobCursor struct {
+	Key       int64  `json:"k"`
+	CreatedAt int64  `json:"c"`
+	ID        string `json:"id"`
+}
+
+// cursorOf возвращает позицию задачи при сортировке по полю sortBy
+func cursorOf(entry *JobListEntry, sortBy string) jobCursor {
+	cursor := jobCursor{CreatedAt: entry.Job.CreatedAt.UnixNano(), ID: entry.Job.ID}
+	switch sortBy {
+	case SortByTotalIssues:
+		if entry.Summary != nil {
+			cursor.Key = int64(entry.Summary.TotalIssues)
+		}
+	case SortByCritical:
+		if entry.Summary != nil {
+			cursor.Key = int64(entry.Summary.Critical)
+		}
+	default:
+		cursor.Key = cursor.CreatedAt
+	}
+	return cursor
+}
+
+// less сравнивает позиции по возрастанию
+func (c jobCursor) less(other jobCursor) bool {
+	if c.Key != other.Key {
+		return c.Key < other.Key
+	}
+	if c.CreatedAt != other.CreatedAt {
+		return c.CreatedAt < other.CreatedAt
+	}
+	return c.ID < other.ID
+}
+
+func encodeCursor(c jobCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор. Для пустой строки возвращает nil.
+func decodeCursor(value string) (*jobCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c jobCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// queryJobEntries выполняет JobQuery над списком задач в памяти
+func queryJobEntries(entries []*JobListEntry, q JobQuery) (*JobPage, error) {
+	q = q.normalized()
+	after, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// before сообщает, что a идет в выдаче раньше b
+	before := func(a, b jobCursor) bool {
+		if q.Ascending {
+			return a.less(b)
+		}
+		return b.less(a)
+	}
+
+	matched := make([]*JobListEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !q.matches(entry) {
+			continue
+		}
+		if after != nil && !before(*after, cursorOf(entry, q.SortBy)) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return before(cursorOf(matched[i], q.SortBy), cursorOf(matched[j], q.SortBy))
+	})
+
+	page := &JobPage{Entries: matched}
+	if len(matched) > q.Limit {
+		page.Entries = matched[:q.Limit]
+		page.NextCursor = encodeCursor(cursorOf(page.Entries[q.Limit-1], q.SortBy))
+	}
+	return page, nil
+}
+
+// jobHost возвращает домен страницы в нижнем регистре
+func jobHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// MemoryStorage представляет хранилище для задач и отчетов в памяти процесса
+type MemoryStorage struct {
+	jobs        map[string]*Job
+	reports     map[string]*domain.Report
+	requests    map[string]*domain.AnalysisRequest
+	revisions   map[string][]*domain.Report
+	webhooks    map[string][]*WebhookDelivery
+	enrichments map[string]*CachedEnrichment
+	usage       map[string]TokenUsage
+	// sizes и enrichmentSizes - размеры данных, вычисленные при записи (см. Sizes)
+	sizes           map[string]*jobSizes
+	enrichmentSizes map[string]int64
+	mu              sync.RWMutex
+}
+
+// jobSizes - размеры данных задачи в байтах сериализованного JSON
+type jobSizes struct {
+	job, report, request, webhooks int64
+	revisions                      map[int]int64
+}
+
+func (z *jobSizes) total() int64 {
+	total := z.job + z.report + z.request + z.webhooks
+	for _, size := range z.revisions {
+		total += size
+	}
+	return total
+}
+
+// NewMemoryStorage создает новое хранилище в памяти
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		jobs:        make(map[string]*Job),
+		reports:     make(map[string]*domain.Report),
+		requests:    make(map[string]*domain.AnalysisRequest),
+		revisions:   make(map[string][]*domain.Report),
+		webhooks:    make(map[string][]*WebhookDelivery),
+		enrichments: make(map[string]*CachedEnrichment),
+		usage:       make(map[string]TokenUsage),
+
+		sizes:           make(map[string]*jobSizes),
+		enrichmentSizes: make(map[string]int64),
+	}
+}
+
+// SaveJob сохраняет задачу
+func (s *MemoryStorage) SaveJob(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.Clone()
+	s.sizesOf(job.ID).job = jsonSize(job)
+	return nil
+}
+
+// GetJob получает задачу по ID
+func (s *MemoryStorage) GetJob(id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	return job.Clone(), nil
+}
+
+// UpdateJob атомарно изменяет задачу
+func (s *MemoryStorage) UpdateJob(id string, update JobUpdate) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	updated := job.Clone()
+	if err := update(updated); err != nil {
+		return nil, err
+	}
+	s.jobs[id] = updated
+	s.sizesOf(id).job = jsonSize(updated)
+	return updated.Clone(), nil
+}
+
+// DeleteJob удаляет задачу
+func (s *MemoryStorage) DeleteJob(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteJob(id)
+	return nil
+}
+
+// DeleteJobIf удаляет задачу, если ее текущее состояние удовлетворяет cond
+func (s *MemoryStorage) DeleteJobIf(id string, cond func(job *Job) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists || !cond(job.Clone()) {
+		return false, nil
+	}
+	s.deleteJob(id)
+	return true, nil
+}
+
+// deleteJob удаляет задачу и связанные с ней данные; вызывается под s.mu
+func (s *MemoryStorage) deleteJob(id string) {
+	delete(s.jobs, id)
+	delete(s.reports, id)
+	delete(s.requests, id)
+	delete(s.revisions, id)
+	delete(s.webhooks, id)
+	delete(s.sizes, id)
+}
+
+// sizesOf возвращает размеры данных задачи, создавая запись при необходимости; вызывается под s.mu
+func (s *MemoryStorage) sizesOf(id string) *jobSizes {
+	sizes, ok := s.sizes[id]
+	if !ok {
+		sizes = &jobSizes{revisions: make(map[int]int64)}
+		s.sizes[id] = sizes
+	}
+	return sizes
+}
+
+// ListJobs возвращает все задачи, отсортированные по времени создания
+func (s *MemoryStorage) ListJobs() ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.Clone())
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].ID < jobs[j].ID
+		}
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	return jobs, nil
+}
+
+// QueryJobs возвращает страницу задач согласно запросу
+func (s *MemoryStorage) QueryJobs(query JobQuery) (*JobPage, error) {
+	s.mu.RLock()
+	entries := make([]*JobListEntry, 0, len(s.jobs))
+	for id, job := range s.jobs {
+		entry := &JobListEntry{Job: job.Clone()}
+		if report, exists := s.reports[id]; exists {
+			summary := report.Summary
+			entry.Summary = &summary
+		}
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+
+	return queryJobEntries(entries, query)
+}
+
+// SaveReport сохраняет отчет
+func (s *MemoryStorage) SaveReport(report *domain.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[report.ID] = report
+	s.sizesOf(report.ID).report = jsonSize(report)
+	return nil
+}
+
+// GetReport получает отчет по ID
+func (s *MemoryStorage) GetReport(id string) (*domain.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report, exists := s.reports[id]
+	if !exists {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// SaveReportSummary сохраняет резюме от AI в отчете
+func (s *MemoryStorage) SaveReportSummary(id, summary string, generatedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, exists := s.reports[id]
+	if !exists {
+		return ErrReportNotFound
+	}
+	// Полученные ранее копии указателя не меняем: читатели могут использовать их без блокировки
+	updated := *report
+	updated.ExecutiveSummary = summary
+	updated.ExecutiveSummaryAt = &generatedAt
+	s.reports[id] = &updated
+	s.sizesOf(id).report = jsonSize(&updated)
+	return nil
+}
+
+// ListReports возвращает все отчеты, отсортированные по времени создания
+func (s *MemoryStorage) ListReports() ([]*domain.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := make([]*domain.Report, 0, len(s.reports))
+	for _, report := range s.reports {
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].CreatedAt.Equal(reports[j].CreatedAt) {
+			return reports[i].ID < reports[j].ID
+		}
+		return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+	})
+
+	return reports, nil
+}
+
+// SaveRequest сохраняет исходный запрос задачи
+func (s *MemoryStorage) SaveRequest(jobID string, req *domain.AnalysisRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[jobID] = req
+	s.sizesOf(jobID).request = jsonSize(req)
+	return nil
+}
+
+// GetRequest получает исходный запрос задачи
+func (s *MemoryStorage) GetRequest(jobID string) (*domain.AnalysisRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	req, exists := s.requests[jobID]
+	if !exists {
+		return nil, ErrRequestNotFound
+	}
+	return req, nil
+}
+
+// SaveReportRevision архивирует версию отчета
+func (s *MemoryStorage) SaveReportRevision(report *domain.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sizesOf(report.ID).revisions[report.Revision] = jsonSize(report)
+
+	revisions := s.revisions[report.ID]
+	for i, existing := range revisions {
+		if existing.Revision == report.Revision {
+			revisions[i] = report
+			return nil
+		}
+	}
+
+	revisions = append(revisions, report)
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	s.revisions[report.ID] = revisions
+	return nil
+}
+
+// ListReportRevisions возвращает архивные версии отчета
+func (s *MemoryStorage) ListReportRevisions(id string) ([]*domain.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]*domain.Report{}, s.revisions[id]...), nil
+}
+
+// SaveWebhookDelivery записывает попытку доставки вебхука
+func (s *MemoryStorage) SaveWebhookDelivery(delivery *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *delivery
+	deliveries := append(s.webhooks[delivery.JobID], &saved)
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return lessWebhookDelivery(deliveries[i], deliveries[j])
+	})
+	s.webhooks[delivery.JobID] = deliveries
+	s.sizesOf(delivery.JobID).webhooks += jsonSize(&saved)
+	return nil
+}
+
+// ListWebhookDeliveries возвращает попытки доставки вебхуков задачи
+func (s *MemoryStorage) ListWebhookDeliveries(jobID string) ([]*WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := make([]*WebhookDelivery, 0, len(s.webhooks[jobID]))
+	for _, delivery := range s.webhooks[jobID] {
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+// AddDailyUsage прибавляет расход токенов к дню
+func (s *MemoryStorage) AddDailyUsage(date string, usage TokenUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := s.usage[date]
+	day.Add(usage)
+	s.usage[date] = day
+	return nil
+}
+
+// ListDailyUsage возвращает расход за дни с from по to включительно
+func (s *MemoryStorage) ListDailyUsage(from, to string) ([]DailyUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	days := []DailyUsage{}
+	for date, usage := range s.usage {
+		if date >= from && date <= to {
+			days = append(days, DailyUsage{Date: date, TokenUsage: usage})
+		}
+	}
+	sortDailyUsage(days)
+	return days, nil
+}
+
+// SaveCachedEnrichment сохраняет описание от AI в кэш
+func (s *MemoryStorage) SaveCachedEnrichment(entry *CachedEnrichment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *entry
+	s.enrichments[entry.Key] = &saved
+	s.enrichmentSizes[entry.Key] = jsonSize(&saved)
+	return nil
+}
+
+// GetCachedEnrichment возвращает запись кэша по ключу
+func (s *MemoryStorage) GetCachedEnrichment(key string) (*CachedEnrichment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.enrichments[key]
+	if !ok {
+		return nil, ErrEnrichmentNotCached
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// ListCachedEnrichments возвращает все записи кэша по возрастанию срока жизни
+func (s *MemoryStorage) ListCachedEnrichments() ([]*CachedEnrichment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]*CachedEnrichment, 0, len(s.enrichments))
+	for _, entry := range s.enrichments {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	sortCachedEnrichments(entries)
+	return entries, nil
+}
+
+// DeleteExpiredEnrichments удаляет просроченные записи кэша
+func (s *MemoryStorage) DeleteExpiredEnrichments(now time.Time) ([]*CachedEnrichment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := []*CachedEnrichment{}
+	for key, entry := range s.enrichments {
+		if entry.Expired(now) {
+			delete(s.enrichments, key)
+			delete(s.enrichmentSizes, key)
+			deleted = append(deleted, entry)
+		}
+	}
+	sortCachedEnrichments(deleted)
+	return deleted, nil
+}
+
+// DeleteCachedEnrichments удаляет записи кэша по ключам
+func (s *MemoryStorage) DeleteCachedEnrichments(keys []string) ([]*CachedEnrichment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := []*CachedEnrichment{}
+	for _, key := range keys {
+		if entry, ok := s.enrichments[key]; ok {
+			delete(s.enrichments, key)
+			delete(s.enrichmentSizes, key)
+			deleted = append(deleted, entry)
+		}
+	}
+	sortCachedEnrichments(deleted)
+	return deleted, nil
+}
+
+// Sizes возвращает размеры данных, вычисленные при записи
+func (s *MemoryStorage) Sizes() (*StorageSizes, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sizes := &StorageSizes{
+		Jobs:        make(map[string]int64, len(s.jobs)),
+		Enrichments: make(map[string]int64, len(s.enrichmentSizes)),
+	}
+	for id := range s.jobs {
+		if job, ok := s.sizes[id]; ok {
+			sizes.Jobs[id] = job.total()
+		}
+	}
+	for key, size := range s.enrichmentSizes {
+		sizes.Enrichments[key] = size
+	}
+	return sizes, nil
+}
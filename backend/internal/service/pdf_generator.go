@@ -2,15 +2,19 @@ package service
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
 	"github.com/jung-kurt/gofpdf"
 )
 
 // PDFGenerator генерирует PDF-отчёты
 type PDFGenerator struct {
 	tr func(string) string
+	// locale - язык подписей текущего отчёта
+	locale string
 }
 
 // NewPDFGenerator создаёт новый генератор PDF
@@ -18,8 +22,10 @@ func NewPDFGenerator() *PDFGenerator {
 	return &PDFGenerator{}
 }
 
-// GenerateReport создаёт PDF-отчёт из данных Report
+// GenerateReport создаёт PDF-отчёт из данных Report. Подписи выводятся на языке отчёта.
 func (g *PDFGenerator) GenerateReport(report *domain.Report) ([]byte, error) {
+	g.locale = i18n.Resolve(report.Locale)
+
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.SetMargins(15, 15, 15)
 	pdf.SetAutoPageBreak(true, 20)
@@ -64,6 +70,11 @@ func (g *PDFGenerator) GenerateReport(report *domain.Report) ([]byte, error) {
 	return buf, nil
 }
 
+// t возвращает подпись на языке отчёта
+func (g *PDFGenerator) t(key string, args ...interface{}) string {
+	return g.tr(i18n.T(g.locale, key, args...))
+}
+
 // bytesBuffer - обёртка для записи PDF в []byte
 type bytesBuffer struct {
 	buf *[]byte
@@ -79,7 +90,7 @@ func (g *PDFGenerator) addHeader(pdf *gofpdf.Fpdf, report *domain.Report) {
 	// Заголовок
 	pdf.SetFont("DejaVu", "B", 24)
 	pdf.SetTextColor(31, 115, 232) // Синий цвет
-	pdf.CellFormat(0, 15, g.tr("Отчёт о доступности"), "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 15, g.t("pdf.title"), "", 1, "C", false, 0, "")
 
 	pdf.Ln(5)
 
@@ -89,8 +100,8 @@ func (g *PDFGenerator) addHeader(pdf *gofpdf.Fpdf, report *domain.Report) {
 	pdf.CellFormat(0, 8, g.tr(fmt.Sprintf("URL: %s", report.URL)), "", 1, "C", false, 0, "")
 
 	// Дата создания
-	createdAt := report.CreatedAt.Format("02.01.2006 15:04")
-	pdf.CellFormat(0, 8, g.tr(fmt.Sprintf("Дата анализа: %s", createdAt)), "", 1, "C", false, 0, "")
+	createdAt := report.CreatedAt.Format(g.t("pdf.date_format"))
+	pdf.CellFormat(0, 8, g.t("pdf.analyzed_at", createdAt), "", 1, "C", false, 0, "")
 
 	pdf.Ln(10)
 }
@@ -99,7 +110,7 @@ func (g *PDFGenerator) addHeader(pdf *gofpdf.Fpdf, report *domain.Report) {
 func (g *PDFGenerator) addSummary(pdf *gofpdf.Fpdf, report *domain.Report) {
 	pdf.SetFont("DejaVu", "B", 16)
 	pdf.SetTextColor(0, 0, 0)
-	pdf.CellFormat(0, 10, g.tr("Сводка"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 10, g.t("pdf.summary"), "", 1, "L", false, 0, "")
 	pdf.Ln(3)
 
 	// Рисуем карточки со статистикой
@@ -112,10 +123,10 @@ func (g *PDFGenerator) addSummary(pdf *gofpdf.Fpdf, report *domain.Report) {
 		count int
 		color []int
 	}{
-		{"Критических", report.Summary.Critical, []int{220, 53, 69}}, // Красный
-		{"Серьёзных", report.Summary.Serious, []int{255, 152, 0}},    // Оранжевый
-		{"Умеренных", report.Summary.Moderate, []int{255, 193, 7}},   // Жёлтый
-		{"Незначительных", report.Summary.Minor, []int{76, 175, 80}}, // Зелёный
+		{g.t("pdf.count.critical"), report.Summary.Critical, []int{220, 53, 69}}, // Красный
+		{g.t("pdf.count.serious"), report.Summary.Serious, []int{255, 152, 0}},   // Оранжевый
+		{g.t("pdf.count.moderate"), report.Summary.Moderate, []int{255, 193, 7}}, // Жёлтый
+		{g.t("pdf.count.minor"), report.Summary.Minor, []int{76, 175, 80}},       // Зелёный
 	}
 
 	x := pdf.GetX()
@@ -153,7 +164,7 @@ func (g *PDFGenerator) addSummary(pdf *gofpdf.Fpdf, report *domain.Report) {
 
 	// Общее количество проблем
 	pdf.SetFont("DejaVu", "B", 14)
-	pdf.CellFormat(0, 8, g.tr(fmt.Sprintf("Всего проблем: %d", report.Summary.TotalIssues)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 8, g.t("pdf.total_issues", report.Summary.TotalIssues), "", 1, "L", false, 0, "")
 	pdf.Ln(5)
 }
 
@@ -165,7 +176,7 @@ func (g *PDFGenerator) addRecommendations(pdf *gofpdf.Fpdf, report *domain.Repor
 
 	pdf.SetFont("DejaVu", "B", 16)
 	pdf.SetTextColor(0, 0, 0)
-	pdf.CellFormat(0, 10, g.tr("Рекомендации"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 10, g.t("pdf.recommendations"), "", 1, "L", false, 0, "")
 	pdf.Ln(3)
 
 	pdf.SetFont("DejaVu", "", 11)
@@ -195,10 +206,10 @@ func (g *PDFGenerator) addIssuesByImpact(pdf *gofpdf.Fpdf, report *domain.Report
 		emoji string
 		color []int
 	}{
-		{"critical", "Критические проблемы", "[!]", []int{220, 53, 69}},
-		{"serious", "Серьёзные проблемы", "[*]", []int{255, 152, 0}},
-		{"moderate", "Умеренные проблемы", "[~]", []int{255, 193, 7}},
-		{"minor", "Незначительные проблемы", "[+]", []int{76, 175, 80}},
+		{"critical", g.t("pdf.impact.critical"), "[!]", []int{220, 53, 69}},
+		{"serious", g.t("pdf.impact.serious"), "[*]", []int{255, 152, 0}},
+		{"moderate", g.t("pdf.impact.moderate"), "[~]", []int{255, 193, 7}},
+		{"minor", g.t("pdf.impact.minor"), "[+]", []int{76, 175, 80}},
 	}
 
 	for _, impact := range impacts {
@@ -256,13 +267,13 @@ func (g *PDFGenerator) addIssueCard(pdf *gofpdf.Fpdf, issue domain.Issue, number
 	// Описание
 	pdf.SetFont("DejaVu", "", 10)
 	pdf.SetTextColor(60, 60, 60)
-	pdf.MultiCell(0, 5, g.tr(fmt.Sprintf("Описание: %s", issue.Description)), "", "L", false)
+	pdf.MultiCell(0, 5, g.t("pdf.description", issue.Description), "", "L", false)
 	pdf.Ln(1)
 
 	// Как исправить
 	pdf.SetFont("DejaVu", "B", 10)
 	pdf.SetTextColor(0, 0, 0)
-	pdf.Cell(0, 5, g.tr("Как исправить:"))
+	pdf.Cell(0, 5, g.t("pdf.how_to_fix"))
 	pdf.Ln(5)
 
 	pdf.SetFont("DejaVu", "", 10)
@@ -270,17 +281,25 @@ func (g *PDFGenerator) addIssueCard(pdf *gofpdf.Fpdf, issue domain.Issue, number
 	pdf.MultiCell(0, 5, g.tr(issue.HowToFix), "", "L", false)
 	pdf.Ln(1)
 
+	// Пример исправленного кода от AI
+	if issue.CodeExample != "" {
+		pdf.SetFont("DejaVuMono", "", 8)
+		pdf.SetTextColor(80, 80, 80)
+		pdf.MultiCell(0, 4, g.tr(issue.CodeExample), "", "L", false)
+		pdf.Ln(1)
+	}
+
 	// Затронуто элементов
 	pdf.SetFont("DejaVu", "", 9)
 	pdf.SetTextColor(100, 100, 100)
-	pdf.CellFormat(0, 5, g.tr(fmt.Sprintf("Затронуто элементов: %d", issue.AffectedElements)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, g.t("pdf.affected_elements", issue.AffectedElements), "", 1, "L", false, 0, "")
 
 	// Примеры (если есть)
 	if len(issue.Examples) > 0 {
 		pdf.Ln(2)
 		pdf.SetFont("DejaVu", "B", 9)
 		pdf.SetTextColor(0, 0, 0)
-		pdf.Cell(0, 5, g.tr("Примеры HTML:"))
+		pdf.Cell(0, 5, g.t("pdf.html_examples"))
 		pdf.Ln(5)
 
 		pdf.SetFont("DejaVuMono", "", 8)
@@ -295,6 +314,30 @@ func (g *PDFGenerator) addIssueCard(pdf *gofpdf.Fpdf, issue domain.Issue, number
 		}
 	}
 
+	// Исправления отдельных элементов от AI
+	if len(issue.NodeFixes) > 0 {
+		pdf.Ln(2)
+		pdf.SetFont("DejaVu", "B", 9)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Cell(0, 5, g.t("pdf.node_fixes"))
+		pdf.Ln(5)
+
+		for _, fix := range issue.NodeFixes {
+			pdf.SetFont("DejaVu", "I", 8)
+			pdf.SetTextColor(100, 100, 100)
+			pdf.MultiCell(0, 4, g.tr(strings.Join(fix.Target, " ")), "", "L", false)
+
+			pdf.SetFont("DejaVuMono", "", 8)
+			pdf.SetTextColor(80, 80, 80)
+			pdf.MultiCell(0, 4, g.tr(fix.FixedHTML), "", "L", false)
+
+			pdf.SetFont("DejaVu", "", 9)
+			pdf.SetTextColor(60, 60, 60)
+			pdf.MultiCell(0, 4, g.tr(fix.Explanation), "", "L", false)
+			pdf.Ln(1)
+		}
+	}
+
 	pdf.Ln(3)
 }
 
@@ -303,5 +346,5 @@ func (g *PDFGenerator) addFooter(pdf *gofpdf.Fpdf) {
 	pdf.SetY(-15)
 	pdf.SetFont("DejaVu", "I", 8)
 	pdf.SetTextColor(150, 150, 150)
-	pdf.CellFormat(0, 10, g.tr(fmt.Sprintf("Сгенерировано %s | Accessibility Analyzer", time.Now().Format("02.01.2006 15:04"))), "", 0, "C", false, 0, "")
+	pdf.CellFormat(0, 10, g.t("pdf.footer", time.Now().Format(g.t("pdf.date_format"))), "", 0, "C", false, 0, "")
 }
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+)
+
+// hashedViolation - значимая для анализа часть нарушения
+type hashedViolation struct {
+	ID     string       `json:"id"`
+	Impact string       `json:"impact"`
+	Nodes  []hashedNode `json:"nodes"`
+}
+
+type hashedNode struct {
+	Target []string `json:"target"`
+	HTML   string   `json:"html"`
+}
+
+// RequestHash возвращает хеш содержимого запроса на анализ: URL без фрагмента, язык отчета
+// и нарушения, упорядоченные по правилу и элементу. Одинаковые страницы, отправленные повторно,
+// дают одинаковый хеш независимо от порядка нарушений в выдаче axe-core.
+func RequestHash(req *domain.AnalysisRequest) string {
+	pageURL := req.URL
+	if u, err := url.Parse(req.URL); err == nil {
+		u.Fragment = ""
+		u.Host = strings.ToLower(u.Host)
+		pageURL = u.String()
+	}
+
+	violations := make([]hashedViolation, 0, len(req.Violations))
+	for _, v := range req.Violations {
+		hv := hashedViolation{ID: v.ID, Impact: v.Impact, Nodes: make([]hashedNode, 0, len(v.Nodes))}
+		for _, node := range v.Nodes {
+			hv.Nodes = append(hv.Nodes, hashedNode{Target: node.Target, HTML: node.HTML})
+		}
+		sort.Slice(hv.Nodes, func(i, j int) bool {
+			a, b := strings.Join(hv.Nodes[i].Target, " "), strings.Join(hv.Nodes[j].Target, " ")
+			if a != b {
+				return a < b
+			}
+			return hv.Nodes[i].HTML < hv.Nodes[j].HTML
+		})
+		violations = append(violations, hv)
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].ID < violations[j].ID
+	})
+
+	// Язык по умолчанию не входит в хеш, чтобы хеши запросов до появления языков не изменились
+	locale := req.Locale
+	if locale == i18n.Default {
+		locale = ""
+	}
+
+	data, _ := json.Marshal(struct {
+		URL        string            `json:"url"`
+		Locale     string            `json:"locale,omitempty"`
+		Violations []hashedViolation `json:"violations"`
+	}{pageURL, locale, violations})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// RetentionPolicy задает ограничения на хранение завершенных задач.
+// Нулевое значение поля отключает соответствующее ограничение.
+type RetentionPolicy struct {
+	// MaxAge - сколько хранить задачу после последнего обновления
+	MaxAge time.Duration
+	// MaxCount - максимальное количество задач в хранилище
+	MaxCount int
+	// MaxBytes - максимальный суммарный размер задач, их запросов, отчетов, ревизий, истории
+	// вебхуков и кэша описаний от AI в байтах (размер оценивает хранилище, см. Storage.Sizes)
+	MaxBytes int64
+}
+
+// Enabled сообщает, задано ли хотя бы одно ограничение
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxCount > 0 || p.MaxBytes > 0
+}
+
+// RetentionStats содержит счетчики работы Janitor
+type RetentionStats struct {
+	Runs           int64 `json:"runs"`
+	EvictedJobs    int64 `json:"evicted_jobs"`
+	EvictedReports int64 `json:"evicted_reports"`
+	// EvictedBytes - размер удаленных данных. Размер задач известен, только если задан MaxBytes.
+	EvictedBytes int64 `json:"evicted_bytes"`
+	// EvictedEnrichments - сколько записей кэша описаний от AI удалено (просроченных и вытесненных по размеру)
+	EvictedEnrichments int64            `json:"evicted_enrichments"`
+	ByReason           map[string]int64 `json:"by_reason"`
+	LastRunAt          *time.Time       `json:"last_run_at,omitempty"`
+	LastError          string           `json:"last_error,omitempty"`
+}
+
+// Причины вытеснения задач
+const (
+	evictReasonAge   = "max_age"
+	evictReasonCount = "max_count"
+	evictReasonBytes = "max_bytes"
+)
+
+// Janitor периодически удаляет старые завершенные задачи и их отчеты согласно RetentionPolicy,
+// а также просроченные записи кэша описаний от AI. Задачи, которые еще ожидают или выполняются,
+// никогда не удаляются.
+type Janitor struct {
+	storage  Storage
+	policy   RetentionPolicy
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	stats RetentionStats
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewJanitor создает новый Janitor
+func NewJanitor(storage Storage, policy RetentionPolicy, interval time.Duration) *Janitor {
+	return &Janitor{
+		storage:  storage,
+		policy:   policy,
+		interval: interval,
+		now:      time.Now,
+		stats:    RetentionStats{ByReason: make(map[string]int64)},
+	}
+}
+
+// Start запускает фоновую очистку. Без политики хранения удаляются только просроченные записи кэша.
+func (j *Janitor) Start() {
+	if j.interval <= 0 || j.stop != nil {
+		return
+	}
+
+	j.stop = make(chan struct{})
+	j.done = make(chan struct{})
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.RunOnce()
+		for {
+			select {
+			case <-ticker.C:
+				j.RunOnce()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает фоновую очистку и дожидается завершения текущего прохода
+func (j *Janitor) Stop() {
+	if j.stop == nil {
+		return
+	}
+	close(j.stop)
+	<-j.done
+	j.stop = nil
+}
+
+// Stats возвращает копию счетчиков вытеснения
+func (j *Janitor) Stats() RetentionStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	stats := j.stats
+	stats.ByReason = make(map[string]int64, len(j.stats.ByReason))
+	for reason, count := range j.stats.ByReason {
+		stats.ByReason[reason] = count
+	}
+	return stats
+}
+
+// retentionEntry - задача с оценкой занимаемого ей места (размер вычисляется только для MaxBytes)
+type retentionEntry struct {
+	job  *Job
+	size int64
+}
+
+// RunOnce выполняет один проход очистки и возвращает число удаленных задач
+func (j *Janitor) RunOnce() (int, error) {
+	evicted, err := j.evict()
+
+	j.mu.Lock()
+	now := j.now()
+	j.stats.Runs++
+	j.stats.LastRunAt = &now
+	j.stats.LastError = ""
+	if err != nil {
+		j.stats.LastError = err.Error()
+	}
+	j.mu.Unlock()
+
+	if err != nil {
+		log.Printf("[Retention] Cleanup failed: %v", err)
+	} else if evicted > 0 {
+		log.Printf("[Retention] Evicted %d jobs", evicted)
+	}
+	return evicted, err
+}
+
+func (j *Janitor) evict() (int, error) {
+	expired, err := j.storage.DeleteExpiredEnrichments(j.now())
+	if err != nil {
+		return 0, err
+	}
+	var expiredSize int64
+	for _, entry := range expired {
+		expiredSize += jsonSize(entry)
+	}
+	j.countEnrichments(len(expired), expiredSize)
+
+	if !j.policy.Enabled() {
+		return 0, nil
+	}
+
+	jobs, err := j.storage.ListJobs()
+	if err != nil {
+		return 0, err
+	}
+
+	// ListJobs отдает задачи от старых к новым, поэтому вытесняем с начала списка
+	entries := make([]*retentionEntry, len(jobs))
+	for i, job := range jobs {
+		entries[i] = &retentionEntry{job: job}
+	}
+
+	// Размеры нужны только для MaxBytes; хранилище отдает их без чтения самих данных
+	var (
+		cache      []*CachedEnrichment
+		cacheSizes []int64
+		totalBytes int64
+	)
+	if j.policy.MaxBytes > 0 {
+		sizes, err := j.storage.Sizes()
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			entry.size = sizes.Jobs[entry.job.ID]
+			totalBytes += entry.size
+		}
+
+		// Кэш отсортирован по сроку жизни: при вытеснении по размеру первыми идут записи,
+		// которые скоро истекут
+		cache, err = j.storage.ListCachedEnrichments()
+		if err != nil {
+			return 0, err
+		}
+		cacheSizes = make([]int64, len(cache))
+		for i, entry := range cache {
+			cacheSizes[i] = sizes.Enrichments[entry.Key]
+			totalBytes += cacheSizes[i]
+		}
+	}
+
+	remaining := len(entries)
+	evicted := 0
+	removed := make(map[string]bool)
+
+	remove := func(entry *retentionEntry, reason string) error {
+		if removed[entry.job.ID] {
+			return nil
+		}
+		ok, err := j.remove(entry, reason)
+		if err != nil || !ok {
+			return err
+		}
+		removed[entry.job.ID] = true
+		remaining--
+		totalBytes -= entry.size
+		evicted++
+		return nil
+	}
+
+	if j.policy.MaxAge > 0 {
+		cutoff := j.now().Add(-j.policy.MaxAge)
+		for _, entry := range entries {
+			if entry.job.UpdatedAt.Before(cutoff) {
+				if err := remove(entry, evictReasonAge); err != nil {
+					return evicted, err
+				}
+			}
+		}
+	}
+
+	if j.policy.MaxCount > 0 {
+		for _, entry := range entries {
+			if remaining <= j.policy.MaxCount {
+				break
+			}
+			if err := remove(entry, evictReasonCount); err != nil {
+				return evicted, err
+			}
+		}
+	}
+
+	if j.policy.MaxBytes > 0 {
+		// Описания из кэша можно снова получить от AI, а отчеты - нет, поэтому сначала
+		// вытесняются записи кэша с ближайшим сроком жизни
+		evictCache := 0
+		for evictCache < len(cache) && totalBytes > j.policy.MaxBytes {
+			totalBytes -= cacheSizes[evictCache]
+			evictCache++
+		}
+		if evictCache > 0 {
+			keys := make([]string, evictCache)
+			selected := make(map[string]int64, evictCache)
+			for i, entry := range cache[:evictCache] {
+				keys[i] = entry.Key
+				selected[entry.Key] = cacheSizes[i]
+			}
+			deleted, err := j.storage.DeleteCachedEnrichments(keys)
+			if err != nil {
+				return evicted, err
+			}
+			var size int64
+			for _, entry := range deleted {
+				size += selected[entry.Key]
+			}
+			j.countEnrichments(len(deleted), size)
+		}
+
+		for _, entry := range entries {
+			if totalBytes <= j.policy.MaxBytes {
+				break
+			}
+			if err := remove(entry, evictReasonBytes); err != nil {
+				return evicted, err
+			}
+		}
+	}
+
+	return evicted, nil
+}
+
+// remove удаляет задачу, если она завершена и не менялась после ListJobs. Проверка и удаление
+// атомарны (Storage.DeleteJobIf), поэтому задачу, которую успели снова взять в работу
+// повтором или переобработкой, janitor не удалит.
+func (j *Janitor) remove(entry *retentionEntry, reason string) (bool, error) {
+	// Наличие отчета проверяется только у удаляемых задач: он нужен лишь для статистики
+	_, err := j.storage.GetReport(entry.job.ID)
+	if err != nil && !errors.Is(err, ErrReportNotFound) {
+		return false, err
+	}
+	hasReport := err == nil
+
+	deleted, err := j.storage.DeleteJobIf(entry.job.ID, func(current *Job) bool {
+		return current.Status.IsFinal() && current.UpdatedAt.Equal(entry.job.UpdatedAt)
+	})
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	j.mu.Lock()
+	j.stats.EvictedJobs++
+	if hasReport {
+		j.stats.EvictedReports++
+	}
+	j.stats.EvictedBytes += entry.size
+	j.stats.ByReason[reason]++
+	j.mu.Unlock()
+
+	return true, nil
+}
+
+// countEnrichments учитывает в статистике count удаленных записей кэша общим размером size
+func (j *Janitor) countEnrichments(count int, size int64) {
+	if count == 0 {
+		return
+	}
+	log.Printf("[Retention] Removed %d cached enrichments", count)
+
+	j.mu.Lock()
+	j.stats.EvictedEnrichments += int64(count)
+	j.stats.EvictedBytes += size
+	j.mu.Unlock()
+}
+
+// jsonSize возвращает размер значения в сериализованном виде
+func jsonSize(v interface{}) int64 {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
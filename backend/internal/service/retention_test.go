@@ -0,0 +1,314 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// newRetentionJob создает задачу с заданным статусом и временем последнего обновления
+func newRetentionJob(t *testing.T, s Storage, status JobStatus, age time.Duration) *Job {
+	t.Helper()
+
+	job := NewJob("https://example.com")
+	job.Status = status
+	job.CreatedAt = time.Now().Add(-age)
+	job.UpdatedAt = job.CreatedAt
+	if err := s.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// TestJanitorNeverEvictsActiveJobs проверяет, что выполняющиеся задачи не удаляются даже при превышении лимитов
+func TestJanitorNeverEvictsActiveJobs(t *testing.T) {
+	s := NewMemoryStorage()
+
+	processing := newRetentionJob(t, s, StatusProcessing, 48*time.Hour)
+	pending := newRetentionJob(t, s, StatusPending, 48*time.Hour)
+	completed := newRetentionJob(t, s, StatusCompleted, 48*time.Hour)
+	failed := newRetentionJob(t, s, StatusFailed, 48*time.Hour)
+	s.SaveReport(&domain.Report{ID: completed.ID, URL: completed.URL})
+
+	janitor := NewJanitor(s, RetentionPolicy{MaxAge: time.Hour, MaxCount: 1, MaxBytes: 1}, time.Minute)
+	evicted, err := janitor.RunOnce()
+	if err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+	if evicted != 2 {
+		t.Errorf("expected 2 evicted jobs, got %d", evicted)
+	}
+
+	for _, job := range []*Job{processing, pending} {
+		if _, err := s.GetJob(job.ID); err != nil {
+			t.Errorf("active job %s (%s) was evicted", job.ID, job.Status)
+		}
+	}
+	for _, job := range []*Job{completed, failed} {
+		if _, err := s.GetJob(job.ID); err != ErrJobNotFound {
+			t.Errorf("finished job %s (%s) was not evicted", job.ID, job.Status)
+		}
+	}
+
+	stats := janitor.Stats()
+	if stats.EvictedJobs != 2 || stats.EvictedReports != 1 || stats.ByReason[evictReasonAge] != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// restartingStorage перезапускает задачу restart перед проверкой в DeleteJobIf,
+// как если бы повтор задачи пришел между ListJobs и удалением
+type restartingStorage struct {
+	Storage
+	restart string
+}
+
+func (s *restartingStorage) DeleteJobIf(id string, cond func(job *Job) bool) (bool, error) {
+	if id == s.restart {
+		s.UpdateJob(id, func(job *Job) error {
+			job.UpdateStatus(StatusPending)
+			return nil
+		})
+	}
+	return s.Storage.DeleteJobIf(id, cond)
+}
+
+// TestJanitorSkipsRestartedJobs проверяет, что задача, перезапущенная после выбора кандидатов
+// на удаление, остается в хранилище
+func TestJanitorSkipsRestartedJobs(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		restarted := newRetentionJob(t, s, StatusFailed, 48*time.Hour)
+		finished := newRetentionJob(t, s, StatusCompleted, 48*time.Hour)
+
+		janitor := NewJanitor(&restartingStorage{Storage: s, restart: restarted.ID}, RetentionPolicy{MaxAge: time.Hour}, time.Minute)
+		evicted, err := janitor.RunOnce()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evicted != 1 {
+			t.Errorf("expected 1 evicted job, got %d", evicted)
+		}
+
+		job, err := s.GetJob(restarted.ID)
+		if err != nil || job.Status != StatusPending {
+			t.Errorf("restarted job must be kept, got %+v, %v", job, err)
+		}
+		if _, err := s.GetJob(finished.ID); err != ErrJobNotFound {
+			t.Errorf("finished job was not evicted")
+		}
+	})
+}
+
+// TestJanitorMaxCount проверяет, что при превышении количества удаляются самые старые задачи
+func TestJanitorMaxCount(t *testing.T) {
+	s := NewMemoryStorage()
+
+	oldest := newRetentionJob(t, s, StatusCompleted, 3*time.Hour)
+	middle := newRetentionJob(t, s, StatusCompleted, 2*time.Hour)
+	newest := newRetentionJob(t, s, StatusCompleted, time.Hour)
+
+	janitor := NewJanitor(s, RetentionPolicy{MaxCount: 2}, time.Minute)
+	if _, err := janitor.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.GetJob(oldest.ID); err != ErrJobNotFound {
+		t.Errorf("expected oldest job to be evicted")
+	}
+	for _, job := range []*Job{middle, newest} {
+		if _, err := s.GetJob(job.ID); err != nil {
+			t.Errorf("job %s should be kept", job.ID)
+		}
+	}
+	if got := janitor.Stats().ByReason[evictReasonCount]; got != 1 {
+		t.Errorf("expected 1 eviction by count, got %d", got)
+	}
+}
+
+// TestJanitorMaxBytes проверяет вытеснение по суммарному размеру отчетов
+func TestJanitorMaxBytes(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		big := newRetentionJob(t, s, StatusCompleted, 2*time.Hour)
+		s.SaveReport(&domain.Report{ID: big.ID, Recommendations: []string{strings.Repeat("x", 10000)}})
+		small := newRetentionJob(t, s, StatusCompleted, time.Hour)
+
+		janitor := NewJanitor(s, RetentionPolicy{MaxBytes: 2000}, time.Minute)
+		if _, err := janitor.RunOnce(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.GetJob(big.ID); err != ErrJobNotFound {
+			t.Errorf("expected large job to be evicted")
+		}
+		if _, err := s.GetJob(small.ID); err != nil {
+			t.Errorf("small job should be kept")
+		}
+		if stats := janitor.Stats(); stats.EvictedBytes < 10000 || stats.EvictedReports != 1 {
+			t.Errorf("expected evicted bytes to include report size, got %+v", stats)
+		}
+	})
+}
+
+// sizelessStorage не отдает размеры данных, как если бы их подсчет был дорогим
+type sizelessStorage struct {
+	Storage
+}
+
+func (s *sizelessStorage) Sizes() (*StorageSizes, error) {
+	return nil, errors.New("sizes must not be requested")
+}
+
+// TestJanitorComputesSizesOnlyForMaxBytes проверяет, что без MaxBytes размеры данных не запрашиваются
+func TestJanitorComputesSizesOnlyForMaxBytes(t *testing.T) {
+	s := NewMemoryStorage()
+	old := newRetentionJob(t, s, StatusCompleted, 3*time.Hour)
+	s.SaveReport(&domain.Report{ID: old.ID})
+	newRetentionJob(t, s, StatusCompleted, 2*time.Hour)
+	newRetentionJob(t, s, StatusCompleted, time.Minute)
+
+	janitor := NewJanitor(&sizelessStorage{Storage: s}, RetentionPolicy{MaxAge: 150 * time.Minute, MaxCount: 1}, time.Minute)
+	evicted, err := janitor.RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evicted != 2 {
+		t.Errorf("expected 2 evicted jobs, got %d", evicted)
+	}
+	if stats := janitor.Stats(); stats.EvictedReports != 1 || stats.ByReason[evictReasonAge] != 1 || stats.ByReason[evictReasonCount] != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestJanitorMaxBytesCountsRequestsAndDeliveries проверяет, что в размер задачи входят
+// исходный запрос с нарушениями и история доставки вебхуков
+func TestJanitorMaxBytesCountsRequestsAndDeliveries(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		withRequest := newRetentionJob(t, s, StatusCompleted, 3*time.Hour)
+		s.SaveRequest(withRequest.ID, &domain.AnalysisRequest{
+			URL:        withRequest.URL,
+			Violations: []domain.AxeViolation{{ID: "color-contrast", Description: strings.Repeat("x", 10000)}},
+		})
+		withDeliveries := newRetentionJob(t, s, StatusCompleted, 2*time.Hour)
+		for attempt := 1; attempt <= 20; attempt++ {
+			s.SaveWebhookDelivery(&WebhookDelivery{
+				ID:      "delivery",
+				JobID:   withDeliveries.ID,
+				URL:     "https://ci.example.com/hook",
+				Attempt: attempt,
+				Error:   strings.Repeat("y", 500),
+			})
+		}
+		small := newRetentionJob(t, s, StatusCompleted, time.Hour)
+
+		janitor := NewJanitor(s, RetentionPolicy{MaxBytes: 2000}, time.Minute)
+		if _, err := janitor.RunOnce(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, job := range []*Job{withRequest, withDeliveries} {
+			if _, err := s.GetJob(job.ID); err != ErrJobNotFound {
+				t.Errorf("expected job %s to be evicted", job.ID)
+			}
+		}
+		if _, err := s.GetJob(small.ID); err != nil {
+			t.Errorf("small job should be kept")
+		}
+		if stats := janitor.Stats(); stats.EvictedBytes < 20000 {
+			t.Errorf("expected evicted bytes to include request and deliveries, got %d", stats.EvictedBytes)
+		}
+	})
+}
+
+// saveCachedEnrichment сохраняет запись кэша описаний с заданным сроком жизни
+func saveCachedEnrichment(t *testing.T, s Storage, key string, expiresAt time.Time, size int) {
+	t.Helper()
+
+	err := s.SaveCachedEnrichment(&CachedEnrichment{
+		Key: key, RuleID: "image-alt", Locale: "ru", Model: "m", PromptVersion: "1",
+		Description: strings.Repeat("x", size), CreatedAt: expiresAt.Add(-time.Hour), ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestJanitorPurgesExpiredEnrichments проверяет удаление просроченного кэша без политики хранения
+func TestJanitorPurgesExpiredEnrichments(t *testing.T) {
+	s := NewMemoryStorage()
+	now := time.Now()
+	saveCachedEnrichment(t, s, "expired", now.Add(-time.Minute), 10)
+	saveCachedEnrichment(t, s, "fresh", now.Add(time.Hour), 10)
+
+	janitor := NewJanitor(s, RetentionPolicy{}, time.Minute)
+	if _, err := janitor.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.GetCachedEnrichment("expired"); err != ErrEnrichmentNotCached {
+		t.Errorf("expected expired entry to be purged, got %v", err)
+	}
+	if _, err := s.GetCachedEnrichment("fresh"); err != nil {
+		t.Errorf("fresh entry must be kept: %v", err)
+	}
+	if stats := janitor.Stats(); stats.EvictedEnrichments != 1 || stats.EvictedBytes == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestJanitorMaxBytesEvictsCacheFirst проверяет, что кэш описаний входит в размер хранилища
+// и при его превышении вытесняется раньше задач
+func TestJanitorMaxBytesEvictsCacheFirst(t *testing.T) {
+	s := NewMemoryStorage()
+	now := time.Now()
+	job := newRetentionJob(t, s, StatusCompleted, time.Hour)
+	saveCachedEnrichment(t, s, "soon", now.Add(time.Hour), 5000)
+	saveCachedEnrichment(t, s, "later", now.Add(2*time.Hour), 500)
+
+	janitor := NewJanitor(s, RetentionPolicy{MaxBytes: 2000}, time.Minute)
+	if _, err := janitor.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.GetCachedEnrichment("soon"); err != ErrEnrichmentNotCached {
+		t.Errorf("expected the entry expiring first to be evicted, got %v", err)
+	}
+	if _, err := s.GetCachedEnrichment("later"); err != nil {
+		t.Errorf("entry must be kept once the size fits: %v", err)
+	}
+	if _, err := s.GetJob(job.ID); err != nil {
+		t.Errorf("job must be kept when evicting the cache is enough: %v", err)
+	}
+	if stats := janitor.Stats(); stats.EvictedEnrichments != 1 || stats.EvictedJobs != 0 || stats.EvictedBytes < 5000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestJanitorMaxBytesEvictsOnlySelectedEntries проверяет, что вытесняются только выбранные записи кэша,
+// а не все записи с тем же сроком жизни, и статистика совпадает с удаленным
+func TestJanitorMaxBytesEvictsOnlySelectedEntries(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		expiresAt := time.Now().Add(time.Hour)
+		saveCachedEnrichment(t, s, "a", expiresAt, 3000)
+		saveCachedEnrichment(t, s, "b", expiresAt, 500)
+
+		janitor := NewJanitor(s, RetentionPolicy{MaxBytes: 2000}, time.Minute)
+		if _, err := janitor.RunOnce(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.GetCachedEnrichment("a"); err != ErrEnrichmentNotCached {
+			t.Errorf("expected a to be evicted, got %v", err)
+		}
+		b, err := s.GetCachedEnrichment("b")
+		if err != nil {
+			t.Fatalf("entry with the same expiration must be kept: %v", err)
+		}
+		stats := janitor.Stats()
+		if stats.EvictedEnrichments != 1 || stats.EvictedBytes >= 3000+jsonSize(b) || stats.EvictedBytes < 3000 {
+			t.Errorf("stats must count only the evicted entry, got %+v", stats)
+		}
+	})
+}
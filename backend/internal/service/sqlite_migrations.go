@@ -0,0 +1,348 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration описывает одну версию схемы SQLite.
+// Миграции применяются строго по возрастанию версии и никогда не изменяются после релиза:
+// для изменения схемы добавляется новая миграция в конец списка.
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+	// Migrate выполняется после SQL в той же транзакции, например для заполнения новых колонок
+	Migrate func(tx *sql.Tx) error
+}
+
+var sqliteMigrations = []migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		SQL: `
+CREATE TABLE jobs (
+	id         TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	progress   INTEGER NOT NULL DEFAULT 0,
+	error      TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX idx_jobs_created_at ON jobs(created_at);
+CREATE INDEX idx_jobs_status ON jobs(status);
+
+CREATE TABLE reports (
+	id              TEXT PRIMARY KEY,
+	url             TEXT NOT NULL,
+	created_at      INTEGER NOT NULL,
+	total_issues    INTEGER NOT NULL DEFAULT 0,
+	critical        INTEGER NOT NULL DEFAULT 0,
+	serious         INTEGER NOT NULL DEFAULT 0,
+	moderate        INTEGER NOT NULL DEFAULT 0,
+	minor           INTEGER NOT NULL DEFAULT 0,
+	recommendations TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX idx_reports_url ON reports(url);
+
+CREATE TABLE issues (
+	id                INTEGER PRIMARY KEY AUTOINCREMENT,
+	report_id         TEXT NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+	position          INTEGER NOT NULL,
+	rule_id           TEXT NOT NULL,
+	impact            TEXT NOT NULL,
+	title             TEXT NOT NULL,
+	description       TEXT NOT NULL,
+	how_to_fix        TEXT NOT NULL,
+	affected_elements INTEGER NOT NULL DEFAULT 0,
+	tags              TEXT NOT NULL DEFAULT '[]',
+	help_url          TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_issues_report ON issues(report_id, position);
+CREATE INDEX idx_issues_rule ON issues(rule_id);
+
+CREATE TABLE nodes (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	issue_id INTEGER NOT NULL REFERENCES issues(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	html     TEXT NOT NULL
+);
+CREATE INDEX idx_nodes_issue ON nodes(issue_id, position);
+`,
+	},
+	{
+		Version: 2,
+		Name:    "analysis requests and report revisions",
+		SQL: `
+ALTER TABLE reports ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE analysis_requests (
+	job_id  TEXT PRIMARY KEY,
+	payload TEXT NOT NULL
+);
+
+CREATE TABLE report_revisions (
+	report_id  TEXT NOT NULL,
+	revision   INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	payload    TEXT NOT NULL,
+	PRIMARY KEY (report_id, revision)
+);
+`,
+	},
+	{
+		Version: 3,
+		Name:    "webhook deliveries",
+		SQL: `
+CREATE TABLE webhook_deliveries (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	delivery_id   TEXT NOT NULL,
+	job_id        TEXT NOT NULL,
+	event         TEXT NOT NULL,
+	url           TEXT NOT NULL,
+	attempt       INTEGER NOT NULL,
+	status_code   INTEGER NOT NULL DEFAULT 0,
+	error         TEXT NOT NULL DEFAULT '',
+	success       INTEGER NOT NULL DEFAULT 0,
+	duration_ms   INTEGER NOT NULL DEFAULT 0,
+	next_retry_at INTEGER,
+	created_at    INTEGER NOT NULL
+);
+CREATE INDEX idx_webhook_deliveries_job ON webhook_deliveries(job_id, created_at);
+`,
+	},
+	{
+		Version: 4,
+		Name:    "job host for listing",
+		SQL: `
+ALTER TABLE jobs ADD COLUMN host TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_jobs_host ON jobs(host);
+CREATE INDEX idx_reports_critical ON reports(critical);
+CREATE INDEX idx_reports_total_issues ON reports(total_issues);
+`,
+		Migrate: backfillJobHosts,
+	},
+	{
+		Version: 5,
+		Name:    "job deduplication keys",
+		SQL: `
+ALTER TABLE jobs ADD COLUMN idempotency_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN request_hash TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_jobs_idempotency_key ON jobs(idempotency_key) WHERE idempotency_key != '';
+CREATE INDEX idx_jobs_request_hash ON jobs(request_hash, created_at) WHERE request_hash != '';
+`,
+	},
+	{
+		Version: 6,
+		Name:    "structured AI enrichment",
+		SQL: `
+ALTER TABLE issues ADD COLUMN code_example TEXT NOT NULL DEFAULT '';
+ALTER TABLE issues ADD COLUMN confidence REAL NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		Version: 7,
+		Name:    "AI enrichment cache",
+		SQL: `
+CREATE TABLE enrichment_cache (
+	key            TEXT PRIMARY KEY,
+	rule_id        TEXT NOT NULL,
+	locale         TEXT NOT NULL,
+	model          TEXT NOT NULL,
+	prompt_version TEXT NOT NULL,
+	description    TEXT NOT NULL,
+	how_to_fix     TEXT NOT NULL,
+	code_example   TEXT NOT NULL DEFAULT '',
+	confidence     REAL NOT NULL DEFAULT 0,
+	created_at     INTEGER NOT NULL,
+	expires_at     INTEGER NOT NULL
+);
+`,
+	},
+	{
+		Version: 8,
+		Name:    "job token usage",
+		SQL: `
+ALTER TABLE jobs ADD COLUMN usage_requests INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN usage_prompt_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN usage_completion_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN usage_cost REAL NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		Version: 9,
+		Name:    "report executive summary",
+		SQL: `
+ALTER TABLE reports ADD COLUMN executive_summary TEXT NOT NULL DEFAULT '';
+ALTER TABLE reports ADD COLUMN executive_summary_at INTEGER;
+`,
+	},
+	{
+		Version: 10,
+		Name:    "report locale",
+		// Отчеты до появления языков сформированы на русском
+		SQL: `
+ALTER TABLE reports ADD COLUMN locale TEXT NOT NULL DEFAULT 'ru';
+`,
+	},
+	{
+		Version: 11,
+		Name:    "issue node fixes",
+		// Исправления элементов хранятся в JSON, как и теги: по ним не нужен поиск
+		SQL: `
+ALTER TABLE issues ADD COLUMN node_fixes TEXT NOT NULL DEFAULT '';
+`,
+	},
+	{
+		Version: 12,
+		Name:    "issue AI rejection",
+		SQL: `
+ALTER TABLE issues ADD COLUMN ai_rejected INTEGER NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		Version: 13,
+		Name:    "report prompt version",
+		// У отчетов до появления шаблонов промптов версия неизвестна
+		SQL: `
+ALTER TABLE reports ADD COLUMN prompt_version TEXT NOT NULL DEFAULT '';
+`,
+	},
+	{
+		Version: 14,
+		Name:    "daily usage ledger",
+		// Расход, сохраненный до появления журнала, относим ко дню создания задачи
+		SQL: `
+CREATE TABLE daily_usage (
+	date              TEXT PRIMARY KEY,
+	requests          INTEGER NOT NULL DEFAULT 0,
+	prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	cost              REAL NOT NULL DEFAULT 0
+);
+`,
+		Migrate: backfillDailyUsage,
+	},
+	{
+		Version: 15,
+		Name:    "enrichment cache expiration index",
+		SQL: `
+CREATE INDEX idx_enrichment_cache_expires_at ON enrichment_cache(expires_at);
+`,
+	},
+}
+
+// backfillJobHosts заполняет колонку host для задач, сохраненных до миграции 4
+func backfillJobHosts(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, url FROM jobs`)
+	if err != nil {
+		return err
+	}
+	hosts := map[string]string{}
+	for rows.Next() {
+		var id, url string
+		if err := rows.Scan(&id, &url); err != nil {
+			rows.Close()
+			return err
+		}
+		hosts[id] = jobHost(url)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, host := range hosts {
+		if _, err := tx.Exec(`UPDATE jobs SET host = ? WHERE id = ?`, host, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillDailyUsage заполняет журнал расхода из задач, сохраненных до миграции 14
+func backfillDailyUsage(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT created_at, usage_requests, usage_prompt_tokens, usage_completion_tokens, usage_cost
+FROM jobs WHERE usage_requests > 0`)
+	if err != nil {
+		return err
+	}
+	days := map[string]TokenUsage{}
+	for rows.Next() {
+		var (
+			createdAt int64
+			usage     TokenUsage
+		)
+		if err := rows.Scan(&createdAt, &usage.Requests, &usage.PromptTokens, &usage.CompletionTokens, &usage.CostUSD); err != nil {
+			rows.Close()
+			return err
+		}
+		date := UsageDate(time.Unix(0, createdAt))
+		day := days[date]
+		day.Add(usage)
+		days[date] = day
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for date, usage := range days {
+		if err := addDailyUsage(tx, date, usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate применяет к базе все еще не примененные миграции
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at INTEGER NOT NULL
+)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, m := range sqliteMigrations {
+		if m.Version <= current {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if m.Migrate != nil {
+			if err := m.Migrate(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			m.Version, m.Name, time.Now().UnixNano()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+		}
+
+		log.Printf("[Storage] Applied migration %d: %s", m.Version, m.Name)
+	}
+
+	return nil
+}
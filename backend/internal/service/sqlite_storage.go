@@ -0,0 +1,1009 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	_ "modernc.org/sqlite" // pure-Go драйвер SQLite, не требует cgo
+)
+
+// SQLiteStorage хранит задачи и отчеты во встроенной базе SQLite.
+// Отчеты разложены по нормализованным таблицам (reports, issues, nodes),
+// поэтому прошлые анализы можно исследовать обычными SQL-запросами.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage открывает (или создает) базу по указанному пути и применяет миграции
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite допускает только одного писателя, поэтому держим одно соединение:
+	// это исключает SQLITE_BUSY и сохраняет PRAGMA для всех запросов
+	db.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{
+		"PRAGMA foreign_keys = ON",
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA busy_timeout = 5000",
+	} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to apply %q: %w", pragma, err)
+		}
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Close закрывает соединение с базой
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// SaveJob сохраняет задачу
+func (s *SQLiteStorage) SaveJob(job *Job) error {
+	return saveJob(s.db, job)
+}
+
+// GetJob получает задачу по ID
+func (s *SQLiteStorage) GetJob(id string) (*Job, error) {
+	return getJob(s.db, id)
+}
+
+// UpdateJob атомарно изменяет задачу в рамках одной транзакции
+func (s *SQLiteStorage) UpdateJob(id string, update JobUpdate) (*Job, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	job, err := getJob(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(job); err != nil {
+		return nil, err
+	}
+	if err := saveJob(tx, job); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// sqlExecutor объединяет *sql.DB и *sql.Tx
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func saveJob(db sqlExecutor, job *Job) error {
+	var usage TokenUsage
+	if job.Usage != nil {
+		usage = *job.Usage
+	}
+
+	_, err := db.Exec(`
+INSERT INTO jobs (id, url, host, status, progress, error, created_at, updated_at, idempotency_key, request_hash,
+	usage_requests, usage_prompt_tokens, usage_completion_tokens, usage_cost)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+	url = excluded.url,
+	host = excluded.host,
+	idempotency_key = excluded.idempotency_key,
+	request_hash = excluded.request_hash,
+	status = excluded.status,
+	progress = excluded.progress,
+	error = excluded.error,
+	updated_at = excluded.updated_at,
+	usage_requests = excluded.usage_requests,
+	usage_prompt_tokens = excluded.usage_prompt_tokens,
+	usage_completion_tokens = excluded.usage_completion_tokens,
+	usage_cost = excluded.usage_cost`,
+		job.ID, job.URL, jobHost(job.URL), string(job.Status), job.Progress, job.Error,
+		job.CreatedAt.UnixNano(), job.UpdatedAt.UnixNano(), job.IdempotencyKey, job.RequestHash,
+		usage.Requests, usage.PromptTokens, usage.CompletionTokens, usage.CostUSD)
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+func getJob(db sqlExecutor, id string) (*Job, error) {
+	row := db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// DeleteJob удаляет задачу вместе с отчетом
+func (s *SQLiteStorage) DeleteJob(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteJob(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteJobIf удаляет задачу, если ее текущее состояние удовлетворяет cond.
+// Проверка и удаление выполняются в одной транзакции.
+func (s *SQLiteStorage) DeleteJobIf(id string, cond func(job *Job) bool) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	job, err := getJob(tx, id)
+	if errors.Is(err, ErrJobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !cond(job) {
+		return false, nil
+	}
+	if err := deleteJob(tx, id); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteJob удаляет задачу и все связанные с ней данные
+func deleteJob(db sqlExecutor, id string) error {
+	if _, err := db.Exec(`DELETE FROM reports WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete report: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM report_revisions WHERE report_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete report revisions: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM analysis_requests WHERE job_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete request: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM webhook_deliveries WHERE job_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM jobs WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
+
+// ListJobs возвращает все задачи, отсортированные по времени создания
+func (s *SQLiteStorage) ListJobs() ([]*Job, error) {
+	rows, err := s.db.Query(`SELECT ` + jobColumns + ` FROM jobs ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// jobSortExpressions - SQL-выражения для полей сортировки списка задач
+var jobSortExpressions = map[string]string{
+	SortByCreatedAt:   "j.created_at",
+	SortByTotalIssues: "COALESCE(r.total_issues, 0)",
+	SortByCritical:    "COALESCE(r.critical, 0)",
+}
+
+// QueryJobs возвращает страницу задач, выполняя фильтрацию и keyset-пагинацию в SQL
+func (s *SQLiteStorage) QueryJobs(query JobQuery) (*JobPage, error) {
+	query = query.normalized()
+	after, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	sortExpr, ok := jobSortExpressions[query.SortBy]
+	if !ok {
+		sortExpr = jobSortExpressions[SortByCreatedAt]
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	if len(query.Statuses) > 0 {
+		placeholders := make([]string, len(query.Statuses))
+		for i, status := range query.Statuses {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		where = append(where, "j.status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if query.URLPrefix != "" {
+		where = append(where, `j.url LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(query.URLPrefix)+"%")
+	}
+	if query.Domain != "" {
+		where = append(where, `(j.host = ? OR j.host LIKE ? ESCAPE '\')`)
+		args = append(args, query.Domain, "%."+escapeLike(query.Domain))
+	}
+	if !query.CreatedFrom.IsZero() {
+		where = append(where, "j.created_at >= ?")
+		args = append(args, query.CreatedFrom.UnixNano())
+	}
+	if !query.CreatedTo.IsZero() {
+		where = append(where, "j.created_at < ?")
+		args = append(args, query.CreatedTo.UnixNano())
+	}
+	if query.MinCritical > 0 {
+		where = append(where, "COALESCE(r.critical, 0) >= ?")
+		args = append(args, query.MinCritical)
+	}
+	if query.IdempotencyKey != "" {
+		where = append(where, "j.idempotency_key = ?")
+		args = append(args, query.IdempotencyKey)
+	}
+	if query.RequestHash != "" {
+		where = append(where, "j.request_hash = ?")
+		args = append(args, query.RequestHash)
+	}
+
+	order, compare := "DESC", "<"
+	if query.Ascending {
+		order, compare = "ASC", ">"
+	}
+	if after != nil {
+		where = append(where, fmt.Sprintf("(%s, j.created_at, j.id) %s (?, ?, ?)", sortExpr, compare))
+		args = append(args, after.Key, after.CreatedAt, after.ID)
+	}
+
+	sqlQuery := `
+SELECT j.id, j.url, j.status, j.progress, j.error, j.created_at, j.updated_at,
+	j.idempotency_key, j.request_hash, j.usage_requests, j.usage_prompt_tokens, j.usage_completion_tokens,
+	j.usage_cost, r.id IS NOT NULL, COALESCE(r.total_issues, 0), COALESCE(r.critical, 0), COALESCE(r.serious, 0),
+	COALESCE(r.moderate, 0), COALESCE(r.minor, 0)
+FROM jobs j
+LEFT JOIN reports r ON r.id = j.id`
+	if len(where) > 0 {
+		sqlQuery += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	sqlQuery += fmt.Sprintf("\nORDER BY %s %s, j.created_at %s, j.id %s\nLIMIT ?", sortExpr, order, order, order)
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	args = append(args, query.Limit+1)
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	page := &JobPage{Entries: []*JobListEntry{}}
+	for rows.Next() {
+		var (
+			job                  Job
+			status               string
+			createdAt, updatedAt int64
+			usage                TokenUsage
+			hasReport            bool
+			summary              domain.ReportSummary
+		)
+		if err := rows.Scan(&job.ID, &job.URL, &status, &job.Progress, &job.Error, &createdAt, &updatedAt,
+			&job.IdempotencyKey, &job.RequestHash, &usage.Requests, &usage.PromptTokens, &usage.CompletionTokens,
+			&usage.CostUSD, &hasReport, &summary.TotalIssues, &summary.Critical, &summary.Serious,
+			&summary.Moderate, &summary.Minor); err != nil {
+			return nil, err
+		}
+		job.Status = JobStatus(status)
+		job.CreatedAt = time.Unix(0, createdAt)
+		job.UpdatedAt = time.Unix(0, updatedAt)
+		job.Usage = scannedUsage(usage)
+
+		entry := &JobListEntry{Job: &job}
+		if hasReport {
+			entry.Summary = &summary
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Entries) > query.Limit {
+		page.Entries = page.Entries[:query.Limit]
+		page.NextCursor = encodeCursor(cursorOf(page.Entries[query.Limit-1], query.SortBy))
+	}
+	return page, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// SaveReport сохраняет отчет, полностью заменяя ранее сохраненные проблемы
+func (s *SQLiteStorage) SaveReport(report *domain.Report) error {
+	recommendations, err := json.Marshal(report.Recommendations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recommendations: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var summaryAt sql.NullInt64
+	if report.ExecutiveSummaryAt != nil {
+		summaryAt = sql.NullInt64{Int64: report.ExecutiveSummaryAt.UnixNano(), Valid: true}
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO reports (id, url, revision, created_at, total_issues, critical, serious, moderate, minor, recommendations,
+	locale, prompt_version, executive_summary, executive_summary_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+	url = excluded.url,
+	revision = excluded.revision,
+	created_at = excluded.created_at,
+	total_issues = excluded.total_issues,
+	critical = excluded.critical,
+	serious = excluded.serious,
+	moderate = excluded.moderate,
+	minor = excluded.minor,
+	recommendations = excluded.recommendations,
+	locale = excluded.locale,
+	prompt_version = excluded.prompt_version,
+	executive_summary = excluded.executive_summary,
+	executive_summary_at = excluded.executive_summary_at`,
+		report.ID, report.URL, report.Revision, report.CreatedAt.UnixNano(),
+		report.Summary.TotalIssues, report.Summary.Critical, report.Summary.Serious,
+		report.Summary.Moderate, report.Summary.Minor, string(recommendations),
+		report.Locale, report.PromptVersion, report.ExecutiveSummary, summaryAt)
+	if err != nil {
+		return fmt.Errorf("failed to save report: %w", err)
+	}
+
+	// Узлы удаляются каскадно вместе с проблемами
+	if _, err := tx.Exec(`DELETE FROM issues WHERE report_id = ?`, report.ID); err != nil {
+		return fmt.Errorf("failed to replace issues: %w", err)
+	}
+
+	position := 0
+	for _, impact := range orderedImpacts(report.IssuesByImpact) {
+		for _, issue := range report.IssuesByImpact[impact] {
+			tags, err := json.Marshal(issue.Tags)
+			if err != nil {
+				return fmt.Errorf("failed to marshal tags: %w", err)
+			}
+			var nodeFixes []byte
+			if len(issue.NodeFixes) > 0 {
+				if nodeFixes, err = json.Marshal(issue.NodeFixes); err != nil {
+					return fmt.Errorf("failed to marshal node fixes: %w", err)
+				}
+			}
+
+			res, err := tx.Exec(`
+INSERT INTO issues (report_id, position, rule_id, impact, title, description, how_to_fix, affected_elements, tags, help_url,
+                    code_example, confidence, node_fixes, ai_rejected)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				report.ID, position, issue.ID, impact, issue.Title, issue.Description,
+				issue.HowToFix, issue.AffectedElements, string(tags), issue.HelpURL,
+				issue.CodeExample, issue.Confidence, string(nodeFixes), issue.AIRejected)
+			if err != nil {
+				return fmt.Errorf("failed to save issue: %w", err)
+			}
+			position++
+
+			issueID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			for i, html := range issue.Examples {
+				if _, err := tx.Exec(`INSERT INTO nodes (issue_id, position, html) VALUES (?, ?, ?)`,
+					issueID, i, html); err != nil {
+					return fmt.Errorf("failed to save node: %w", err)
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReport получает отчет по ID
+func (s *SQLiteStorage) GetReport(id string) (*domain.Report, error) {
+	row := s.db.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = ?`, id)
+	report, err := scanReport(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadIssues(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// SaveReportSummary сохраняет резюме от AI в отчете
+func (s *SQLiteStorage) SaveReportSummary(id, summary string, generatedAt time.Time) error {
+	result, err := s.db.Exec(`UPDATE reports SET executive_summary = ?, executive_summary_at = ? WHERE id = ?`,
+		summary, generatedAt.UnixNano(), id)
+	if err != nil {
+		return fmt.Errorf("failed to save report summary: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrReportNotFound
+	}
+	return nil
+}
+
+// ListReports возвращает все отчеты, отсортированные по времени создания
+func (s *SQLiteStorage) ListReports() ([]*domain.Report, error) {
+	rows, err := s.db.Query(`SELECT ` + reportColumns + ` FROM reports ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	reports := []*domain.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Проблемы догружаем после закрытия курсора: соединение с базой одно
+	for _, report := range reports {
+		if err := s.loadIssues(report); err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
+
+// SaveRequest сохраняет исходный запрос задачи
+func (s *SQLiteStorage) SaveRequest(jobID string, req *domain.AnalysisRequest) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+INSERT INTO analysis_requests (job_id, payload) VALUES (?, ?)
+ON CONFLICT(job_id) DO UPDATE SET payload = excluded.payload`, jobID, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to save request: %w", err)
+	}
+	return nil
+}
+
+// GetRequest получает исходный запрос задачи
+func (s *SQLiteStorage) GetRequest(jobID string) (*domain.AnalysisRequest, error) {
+	var payload string
+	err := s.db.QueryRow(`SELECT payload FROM analysis_requests WHERE job_id = ?`, jobID).Scan(&payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var req domain.AnalysisRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+	return &req, nil
+}
+
+// SaveReportRevision архивирует версию отчета целиком в JSON:
+// нормализованные таблицы описывают только актуальную версию
+func (s *SQLiteStorage) SaveReportRevision(report *domain.Report) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report revision: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+INSERT INTO report_revisions (report_id, revision, created_at, payload) VALUES (?, ?, ?, ?)
+ON CONFLICT(report_id, revision) DO UPDATE SET
+	created_at = excluded.created_at,
+	payload = excluded.payload`,
+		report.ID, report.Revision, report.CreatedAt.UnixNano(), string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to save report revision: %w", err)
+	}
+	return nil
+}
+
+// ListReportRevisions возвращает архивные версии отчета
+func (s *SQLiteStorage) ListReportRevisions(id string) ([]*domain.Report, error) {
+	rows, err := s.db.Query(`SELECT payload FROM report_revisions WHERE report_id = ? ORDER BY revision`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*domain.Report{}
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var report domain.Report
+		if err := json.Unmarshal([]byte(payload), &report); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal report revision: %w", err)
+		}
+		revisions = append(revisions, &report)
+	}
+	return revisions, rows.Err()
+}
+
+// SaveWebhookDelivery записывает попытку доставки вебхука
+func (s *SQLiteStorage) SaveWebhookDelivery(delivery *WebhookDelivery) error {
+	var nextRetryAt sql.NullInt64
+	if delivery.NextRetryAt != nil {
+		nextRetryAt = sql.NullInt64{Int64: delivery.NextRetryAt.UnixNano(), Valid: true}
+	}
+
+	_, err := s.db.Exec(`
+INSERT INTO webhook_deliveries (delivery_id, job_id, event, url, attempt, status_code, error,
+	success, duration_ms, next_retry_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.ID, delivery.JobID, delivery.Event, delivery.URL, delivery.Attempt, delivery.StatusCode,
+		delivery.Error, delivery.Success, delivery.DurationMs, nextRetryAt, delivery.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries возвращает попытки доставки вебхуков задачи
+func (s *SQLiteStorage) ListWebhookDeliveries(jobID string) ([]*WebhookDelivery, error) {
+	rows, err := s.db.Query(`
+SELECT delivery_id, job_id, event, url, attempt, status_code, error, success, duration_ms,
+	next_retry_at, created_at
+FROM webhook_deliveries WHERE job_id = ? ORDER BY created_at, attempt, id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		var (
+			delivery    WebhookDelivery
+			nextRetryAt sql.NullInt64
+			createdAt   int64
+		)
+		if err := rows.Scan(&delivery.ID, &delivery.JobID, &delivery.Event, &delivery.URL, &delivery.Attempt,
+			&delivery.StatusCode, &delivery.Error, &delivery.Success, &delivery.DurationMs,
+			&nextRetryAt, &createdAt); err != nil {
+			return nil, err
+		}
+		if nextRetryAt.Valid {
+			t := time.Unix(0, nextRetryAt.Int64)
+			delivery.NextRetryAt = &t
+		}
+		delivery.CreatedAt = time.Unix(0, createdAt)
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// AddDailyUsage прибавляет расход токенов к дню
+func (s *SQLiteStorage) AddDailyUsage(date string, usage TokenUsage) error {
+	return addDailyUsage(s.db, date, usage)
+}
+
+func addDailyUsage(db sqlExecutor, date string, usage TokenUsage) error {
+	_, err := db.Exec(`
+INSERT INTO daily_usage (date, requests, prompt_tokens, completion_tokens, cost)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(date) DO UPDATE SET
+	requests = requests + excluded.requests,
+	prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+	completion_tokens = completion_tokens + excluded.completion_tokens,
+	cost = cost + excluded.cost`,
+		date, usage.Requests, usage.PromptTokens, usage.CompletionTokens, usage.CostUSD)
+	if err != nil {
+		return fmt.Errorf("failed to add daily usage: %w", err)
+	}
+	return nil
+}
+
+// ListDailyUsage возвращает расход за дни с from по to включительно
+func (s *SQLiteStorage) ListDailyUsage(from, to string) ([]DailyUsage, error) {
+	rows, err := s.db.Query(`
+SELECT date, requests, prompt_tokens, completion_tokens, cost
+FROM daily_usage WHERE date >= ? AND date <= ? ORDER BY date`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily usage: %w", err)
+	}
+	defer rows.Close()
+
+	days := []DailyUsage{}
+	for rows.Next() {
+		var day DailyUsage
+		if err := rows.Scan(&day.Date, &day.Requests, &day.PromptTokens, &day.CompletionTokens, &day.CostUSD); err != nil {
+			return nil, err
+		}
+		day.TotalTokens = day.PromptTokens + day.CompletionTokens
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// SaveCachedEnrichment сохраняет описание от AI в кэш
+func (s *SQLiteStorage) SaveCachedEnrichment(entry *CachedEnrichment) error {
+	_, err := s.db.Exec(`
+INSERT INTO enrichment_cache (key, rule_id, locale, model, prompt_version, description, how_to_fix,
+	code_example, confidence, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET
+	description = excluded.description,
+	how_to_fix = excluded.how_to_fix,
+	code_example = excluded.code_example,
+	confidence = excluded.confidence,
+	created_at = excluded.created_at,
+	expires_at = excluded.expires_at`,
+		entry.Key, entry.RuleID, entry.Locale, entry.Model, entry.PromptVersion, entry.Description,
+		entry.HowToFix, entry.CodeExample, entry.Confidence, entry.CreatedAt.UnixNano(), entry.ExpiresAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to save cached enrichment: %w", err)
+	}
+	return nil
+}
+
+// GetCachedEnrichment возвращает запись кэша по ключу
+func (s *SQLiteStorage) GetCachedEnrichment(key string) (*CachedEnrichment, error) {
+	var (
+		entry                CachedEnrichment
+		createdAt, expiresAt int64
+	)
+	err := s.db.QueryRow(`
+SELECT key, rule_id, locale, model, prompt_version, description, how_to_fix, code_example,
+	confidence, created_at, expires_at
+FROM enrichment_cache WHERE key = ?`, key).Scan(&entry.Key, &entry.RuleID, &entry.Locale, &entry.Model,
+		&entry.PromptVersion, &entry.Description, &entry.HowToFix, &entry.CodeExample, &entry.Confidence,
+		&createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEnrichmentNotCached
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached enrichment: %w", err)
+	}
+	entry.CreatedAt = time.Unix(0, createdAt)
+	entry.ExpiresAt = time.Unix(0, expiresAt)
+	return &entry, nil
+}
+
+// enrichmentColumns - колонки записи кэша в порядке scanCachedEnrichments
+const enrichmentColumns = `key, rule_id, locale, model, prompt_version, description, how_to_fix, code_example,
+	confidence, created_at, expires_at`
+
+// ListCachedEnrichments возвращает все записи кэша по возрастанию срока жизни
+func (s *SQLiteStorage) ListCachedEnrichments() ([]*CachedEnrichment, error) {
+	rows, err := s.db.Query(`SELECT ` + enrichmentColumns + ` FROM enrichment_cache ORDER BY expires_at, key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached enrichments: %w", err)
+	}
+	return scanCachedEnrichments(rows)
+}
+
+// DeleteExpiredEnrichments удаляет просроченные записи кэша
+func (s *SQLiteStorage) DeleteExpiredEnrichments(now time.Time) ([]*CachedEnrichment, error) {
+	rows, err := s.db.Query(`DELETE FROM enrichment_cache WHERE expires_at <= ? RETURNING `+enrichmentColumns, now.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired enrichments: %w", err)
+	}
+	deleted, err := scanCachedEnrichments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired enrichments: %w", err)
+	}
+	sortCachedEnrichments(deleted)
+	return deleted, nil
+}
+
+// DeleteCachedEnrichments удаляет записи кэша по ключам
+func (s *SQLiteStorage) DeleteCachedEnrichments(keys []string) ([]*CachedEnrichment, error) {
+	deleted := []*CachedEnrichment{}
+	for _, key := range keys {
+		rows, err := s.db.Query(`DELETE FROM enrichment_cache WHERE key = ? RETURNING `+enrichmentColumns, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete cached enrichment: %w", err)
+		}
+		entries, err := scanCachedEnrichments(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete cached enrichment: %w", err)
+		}
+		deleted = append(deleted, entries...)
+	}
+	sortCachedEnrichments(deleted)
+	return deleted, nil
+}
+
+// Sizes возвращает суммарную длину значений задач и записей кэша в байтах. Размер считается
+// в самой базе, поэтому отчеты и запросы не читаются.
+func (s *SQLiteStorage) Sizes() (*StorageSizes, error) {
+	sizes := &StorageSizes{Jobs: make(map[string]int64), Enrichments: make(map[string]int64)}
+
+	// Отчет задачи хранится под ее ID, а элементы - через проблемы отчета
+	err := s.querySizes(sizes.Jobs, `
+SELECT job_id, SUM(size) FROM (
+	SELECT id AS job_id, `+valuesLength(jobColumns)+` AS size FROM jobs
+	UNION ALL SELECT id, `+valuesLength(reportColumns)+` FROM reports
+	UNION ALL SELECT report_id, `+valuesLength(issueSizeColumns)+` FROM issues
+	UNION ALL SELECT i.report_id, length(CAST(n.html AS BLOB)) FROM nodes n JOIN issues i ON i.id = n.issue_id
+	UNION ALL SELECT job_id, length(CAST(payload AS BLOB)) FROM analysis_requests
+	UNION ALL SELECT report_id, length(CAST(payload AS BLOB)) FROM report_revisions
+	UNION ALL SELECT job_id, `+valuesLength(deliverySizeColumns)+` FROM webhook_deliveries
+)
+WHERE job_id IN (SELECT id FROM jobs)
+GROUP BY job_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute job sizes: %w", err)
+	}
+
+	err = s.querySizes(sizes.Enrichments, `SELECT key, `+valuesLength(enrichmentColumns)+` FROM enrichment_cache`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute cached enrichment sizes: %w", err)
+	}
+	return sizes, nil
+}
+
+// Колонки проблем и доставок вебхуков, которые учитываются в размере задачи
+const (
+	issueSizeColumns = `rule_id, impact, title, description, how_to_fix, affected_elements, tags, help_url,
+	code_example, confidence, node_fixes, ai_rejected`
+	deliverySizeColumns = `delivery_id, event, url, attempt, status_code, error, success, duration_ms,
+	next_retry_at, created_at`
+)
+
+// valuesLength возвращает SQL-выражение с суммарной длиной значений колонок в байтах
+func valuesLength(columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, column := range parts {
+		parts[i] = fmt.Sprintf("IFNULL(length(CAST(%s AS BLOB)), 0)", strings.TrimSpace(column))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// querySizes читает пары "ключ - размер" из результата запроса в sizes
+func (s *SQLiteStorage) querySizes(sizes map[string]int64, query string) error {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			key  string
+			size int64
+		)
+		if err := rows.Scan(&key, &size); err != nil {
+			return err
+		}
+		sizes[key] = size
+	}
+	return rows.Err()
+}
+
+// scanCachedEnrichments читает записи кэша из rows и закрывает их
+func scanCachedEnrichments(rows *sql.Rows) ([]*CachedEnrichment, error) {
+	defer rows.Close()
+
+	entries := []*CachedEnrichment{}
+	for rows.Next() {
+		var (
+			entry                CachedEnrichment
+			createdAt, expiresAt int64
+		)
+		if err := rows.Scan(&entry.Key, &entry.RuleID, &entry.Locale, &entry.Model, &entry.PromptVersion,
+			&entry.Description, &entry.HowToFix, &entry.CodeExample, &entry.Confidence,
+			&createdAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = time.Unix(0, createdAt)
+		entry.ExpiresAt = time.Unix(0, expiresAt)
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// loadIssues заполняет IssuesByImpact и ImpactScores отчета из таблиц issues и nodes
+func (s *SQLiteStorage) loadIssues(report *domain.Report) error {
+	rows, err := s.db.Query(`
+SELECT i.id, i.rule_id, i.impact, i.title, i.description, i.how_to_fix,
+       i.affected_elements, i.tags, i.help_url, i.code_example, i.confidence, i.node_fixes, i.ai_rejected, n.html
+FROM issues i
+LEFT JOIN nodes n ON n.issue_id = i.id
+WHERE i.report_id = ?
+ORDER BY i.position, n.position`, report.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load issues: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		issues  []domain.Issue
+		lastRow int64 = -1
+	)
+	for rows.Next() {
+		var (
+			rowID     int64
+			issue     domain.Issue
+			tags      string
+			nodeFixes string
+			html      sql.NullString
+		)
+		if err := rows.Scan(&rowID, &issue.ID, &issue.Impact, &issue.Title, &issue.Description,
+			&issue.HowToFix, &issue.AffectedElements, &tags, &issue.HelpURL,
+			&issue.CodeExample, &issue.Confidence, &nodeFixes, &issue.AIRejected, &html); err != nil {
+			return err
+		}
+
+		if rowID != lastRow {
+			if err := json.Unmarshal([]byte(tags), &issue.Tags); err != nil {
+				return fmt.Errorf("failed to unmarshal tags: %w", err)
+			}
+			if nodeFixes != "" {
+				if err := json.Unmarshal([]byte(nodeFixes), &issue.NodeFixes); err != nil {
+					return fmt.Errorf("failed to unmarshal node fixes: %w", err)
+				}
+			}
+			issue.Examples = []string{}
+			issues = append(issues, issue)
+			lastRow = rowID
+		}
+		if html.Valid {
+			last := &issues[len(issues)-1]
+			last.Examples = append(last.Examples, html.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		report.IssuesByImpact[issue.Impact] = append(report.IssuesByImpact[issue.Impact], issue)
+		report.Summary.ImpactScores[issue.Impact]++
+	}
+	return nil
+}
+
+const jobColumns = `id, url, status, progress, error, created_at, updated_at, idempotency_key, request_hash,
+	usage_requests, usage_prompt_tokens, usage_completion_tokens, usage_cost`
+
+// rowScanner объединяет *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	var (
+		job                  Job
+		status               string
+		createdAt, updatedAt int64
+		usage                TokenUsage
+	)
+	if err := row.Scan(&job.ID, &job.URL, &status, &job.Progress, &job.Error, &createdAt, &updatedAt,
+		&job.IdempotencyKey, &job.RequestHash, &usage.Requests, &usage.PromptTokens, &usage.CompletionTokens,
+		&usage.CostUSD); err != nil {
+		return nil, err
+	}
+	job.Usage = scannedUsage(usage)
+	job.Status = JobStatus(status)
+	job.CreatedAt = time.Unix(0, createdAt)
+	job.UpdatedAt = time.Unix(0, updatedAt)
+	return &job, nil
+}
+
+// scannedUsage восстанавливает расход токенов задачи из колонок usage_*
+func scannedUsage(usage TokenUsage) *TokenUsage {
+	if usage.Requests == 0 {
+		return nil
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return &usage
+}
+
+const reportColumns = `id, url, revision, created_at, total_issues, critical, serious, moderate, minor, recommendations,
+	locale, prompt_version, executive_summary, executive_summary_at`
+
+func scanReport(row rowScanner) (*domain.Report, error) {
+	var (
+		report          domain.Report
+		createdAt       int64
+		recommendations string
+		summaryAt       sql.NullInt64
+	)
+	if err := row.Scan(&report.ID, &report.URL, &report.Revision, &createdAt,
+		&report.Summary.TotalIssues, &report.Summary.Critical, &report.Summary.Serious,
+		&report.Summary.Moderate, &report.Summary.Minor, &recommendations,
+		&report.Locale, &report.PromptVersion, &report.ExecutiveSummary, &summaryAt); err != nil {
+		return nil, err
+	}
+	report.CreatedAt = time.Unix(0, createdAt)
+	if summaryAt.Valid {
+		t := time.Unix(0, summaryAt.Int64)
+		report.ExecutiveSummaryAt = &t
+	}
+	if err := json.Unmarshal([]byte(recommendations), &report.Recommendations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recommendations: %w", err)
+	}
+
+	report.Summary.ImpactScores = make(map[string]int)
+	report.IssuesByImpact = make(map[string][]domain.Issue)
+	for _, level := range domain.ImpactLevels {
+		report.IssuesByImpact[level] = []domain.Issue{}
+	}
+	return &report, nil
+}
+
+// orderedImpacts возвращает ключи IssuesByImpact: сначала известные уровни, затем прочие
+func orderedImpacts(issuesByImpact map[string][]domain.Issue) []string {
+	keys := append([]string{}, domain.ImpactLevels...)
+	known := map[string]bool{}
+	for _, level := range domain.ImpactLevels {
+		known[level] = true
+	}
+
+	var extra []string
+	for impact := range issuesByImpact {
+		if !known[impact] {
+			extra = append(extra, impact)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
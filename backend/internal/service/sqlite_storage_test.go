@@ -0,0 +1,110 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// TestSQLiteStorageRoundTrip проверяет сохранение задачи и отчета в нормализованные таблицы
+func TestSQLiteStorageRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analyzer.db")
+
+	s, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage returned error: %v", err)
+	}
+
+	job := NewJob("https://example.com")
+	if err := s.SaveJob(job); err != nil {
+		t.Fatalf("SaveJob returned error: %v", err)
+	}
+	job.UpdateStatus(StatusCompleted)
+	job.SetProgress(100)
+	if err := s.SaveJob(job); err != nil {
+		t.Fatalf("SaveJob (update) returned error: %v", err)
+	}
+
+	report := &domain.Report{
+		ID:            job.ID,
+		URL:           job.URL,
+		CreatedAt:     time.Now(),
+		PromptVersion: "v3",
+		Summary: domain.ReportSummary{
+			TotalIssues:  3,
+			Critical:     2,
+			Minor:        1,
+			ImpactScores: map[string]int{"critical": 2, "minor": 1},
+		},
+		IssuesByImpact: map[string][]domain.Issue{
+			"critical": {
+				{ID: "image-alt", Impact: "critical", Title: "alt", Tags: []string{"wcag2a"}, Examples: []string{"<img src=a>", "<img src=b>"},
+					CodeExample: `<img src=a alt="Logo">`, Confidence: 0.9,
+					NodeFixes: []domain.NodeFix{{Target: []string{"img"}, HTML: "<img src=a>", FixedHTML: `<img src=a alt="Logo">`, Explanation: "alt"}}},
+				{ID: "button-name", Impact: "critical", Title: "button", Tags: []string{}, Examples: []string{}, AIRejected: true},
+			},
+			"serious":  {},
+			"moderate": {},
+			"minor": {
+				{ID: "region", Impact: "minor", Title: "region", Tags: []string{"best-practice"}, Examples: []string{"<div>"}},
+			},
+		},
+		Recommendations: []string{"first", "second"},
+	}
+	if err := s.SaveReport(report); err != nil {
+		t.Fatalf("SaveReport returned error: %v", err)
+	}
+	// Повторное сохранение не должно дублировать проблемы
+	if err := s.SaveReport(report); err != nil {
+		t.Fatalf("SaveReport (overwrite) returned error: %v", err)
+	}
+	s.Close()
+
+	// Повторное открытие не должно заново применять миграции
+	reopened, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	defer reopened.Close()
+
+	loadedJob, err := reopened.GetJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetJob returned error: %v", err)
+	}
+	if loadedJob.Status != StatusCompleted || loadedJob.Progress != 100 || !loadedJob.CreatedAt.Equal(job.CreatedAt) {
+		t.Errorf("unexpected job: %+v", loadedJob)
+	}
+
+	loaded, err := reopened.GetReport(job.ID)
+	if err != nil {
+		t.Fatalf("GetReport returned error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.IssuesByImpact, report.IssuesByImpact) {
+		t.Errorf("issues mismatch:\n got %+v\nwant %+v", loaded.IssuesByImpact, report.IssuesByImpact)
+	}
+	if !reflect.DeepEqual(loaded.Summary, report.Summary) {
+		t.Errorf("summary mismatch: got %+v want %+v", loaded.Summary, report.Summary)
+	}
+	if !reflect.DeepEqual(loaded.Recommendations, report.Recommendations) {
+		t.Errorf("recommendations mismatch: %v", loaded.Recommendations)
+	}
+	if loaded.PromptVersion != "v3" {
+		t.Errorf("prompt version mismatch: %q", loaded.PromptVersion)
+	}
+
+	if err := reopened.DeleteJob(job.ID); err != nil {
+		t.Fatalf("DeleteJob returned error: %v", err)
+	}
+	if _, err := reopened.GetReport(job.ID); err != ErrReportNotFound {
+		t.Errorf("expected ErrReportNotFound, got %v", err)
+	}
+
+	var orphans int
+	reopened.db.QueryRow(`SELECT COUNT(*) FROM nodes`).Scan(&orphans)
+	if orphans != 0 {
+		t.Errorf("expected nodes to be deleted with the report, found %d", orphans)
+	}
+}
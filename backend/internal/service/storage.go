@@ -1,73 +1,102 @@
 package service
 
 import (
-	"fmt"
-	"sync"
+	"errors"
+	"time"
 
 	"github.com/danil/accessibility-analyzer/internal/domain"
 )
 
-// Storage представляет хранилище для задач и отчетов
-type Storage struct {
-	jobs    map[string]*Job
-	reports map[string]*domain.Report
-	mu      sync.RWMutex
-}
+var (
+	// ErrJobNotFound возвращается, если задача отсутствует в хранилище
+	ErrJobNotFound = errors.New("job not found")
+	// ErrReportNotFound возвращается, если отчет отсутствует в хранилище
+	ErrReportNotFound = errors.New("report not found")
+	// ErrRequestNotFound возвращается, если исходный запрос задачи не сохранен
+	ErrRequestNotFound = errors.New("analysis request not found")
+)
 
-// NewStorage создает новое хранилище
-func NewStorage() *Storage {
-	return &Storage{
-		jobs:    make(map[string]*Job),
-		reports: make(map[string]*domain.Report),
-	}
+// StorageSizes - место, которое занимают данные в хранилище, в байтах
+type StorageSizes struct {
+	// Jobs - размер задачи вместе с запросом, отчетом, ревизиями и историей вебхуков по ID задачи
+	Jobs map[string]int64
+	// Enrichments - размер записи кэша описаний от AI по ключу
+	Enrichments map[string]int64
 }
 
-// SaveJob сохраняет задачу
-func (s *Storage) SaveJob(job *Job) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.jobs[job.ID] = job
-	return nil
-}
+// JobUpdate изменяет задачу внутри Storage.UpdateJob.
+// Возврат ошибки отменяет изменение.
+type JobUpdate func(job *Job) error
 
-// GetJob получает задачу по ID
-func (s *Storage) GetJob(id string) (*Job, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// Storage описывает хранилище задач и отчетов.
+// Реализации должны быть безопасны для конкурентного использования: задачи
+// сохраняются и возвращаются копиями, а изменения выполняются через UpdateJob.
+type Storage interface {
+	// SaveJob сохраняет копию задачи (создает или перезаписывает)
+	SaveJob(job *Job) error
+	// GetJob получает копию задачи по ID
+	GetJob(id string) (*Job, error)
+	// UpdateJob атомарно применяет update к текущему состоянию задачи и возвращает
+	// копию результата. Если задачи нет, возвращает ErrJobNotFound.
+	UpdateJob(id string, update JobUpdate) (*Job, error)
+	// DeleteJob удаляет задачу вместе с её запросом, отчетом, ревизиями отчета и историей вебхуков
+	DeleteJob(id string) error
+	// DeleteJobIf атомарно удаляет задачу, как DeleteJob, если для ее текущего состояния cond
+	// возвращает true. Возвращает, была ли задача удалена; отсутствующая задача не считается ошибкой.
+	DeleteJobIf(id string, cond func(job *Job) bool) (bool, error)
+	// ListJobs возвращает все задачи, отсортированные по времени создания
+	ListJobs() ([]*Job, error)
+	// QueryJobs возвращает страницу задач с фильтрами и сортировкой из JobQuery.
+	// Для поврежденного курсора возвращает ErrInvalidCursor.
+	QueryJobs(query JobQuery) (*JobPage, error)
 
-	job, exists := s.jobs[id]
-	if !exists {
-		return nil, fmt.Errorf("job not found")
-	}
-	return job, nil
-}
+	// SaveReport сохраняет отчет (ID отчета совпадает с ID задачи)
+	SaveReport(report *domain.Report) error
+	// GetReport получает отчет по ID
+	GetReport(id string) (*domain.Report, error)
+	// ListReports возвращает все отчеты, отсортированные по времени создания
+	ListReports() ([]*domain.Report, error)
+	// SaveReportSummary сохраняет резюме от AI в отчете, не изменяя остальные поля.
+	// Если отчета нет, возвращает ErrReportNotFound.
+	SaveReportSummary(id, summary string, generatedAt time.Time) error
 
-// DeleteJob удаляет задачу
-func (s *Storage) DeleteJob(id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// SaveRequest сохраняет исходный запрос на анализ, чтобы задачу можно было перезапустить
+	SaveRequest(jobID string, req *domain.AnalysisRequest) error
+	// GetRequest получает исходный запрос задачи
+	GetRequest(jobID string) (*domain.AnalysisRequest, error)
 
-	delete(s.jobs, id)
-	delete(s.reports, id)
-	return nil
-}
+	// SaveReportRevision архивирует предыдущую версию отчета перед повторной обработкой
+	SaveReportRevision(report *domain.Report) error
+	// ListReportRevisions возвращает архивные версии отчета по возрастанию номера ревизии
+	ListReportRevisions(id string) ([]*domain.Report, error)
 
-// SaveReport сохраняет отчет
-func (s *Storage) SaveReport(report *domain.Report) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.reports[report.ID] = report
-	return nil
-}
+	// SaveWebhookDelivery записывает попытку доставки вебхука
+	SaveWebhookDelivery(delivery *WebhookDelivery) error
+	// ListWebhookDeliveries возвращает попытки доставки вебхуков задачи в хронологическом порядке
+	ListWebhookDeliveries(jobID string) ([]*WebhookDelivery, error)
+
+	// AddDailyUsage прибавляет расход токенов к дню date (YYYY-MM-DD, UTC).
+	// Журнал расхода не связан с задачами и не очищается при их удалении.
+	AddDailyUsage(date string, usage TokenUsage) error
+	// ListDailyUsage возвращает расход за дни с from по to включительно (YYYY-MM-DD) по возрастанию даты
+	ListDailyUsage(from, to string) ([]DailyUsage, error)
 
-// GetReport получает отчет по ID
-func (s *Storage) GetReport(id string) (*domain.Report, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	// SaveCachedEnrichment сохраняет описание от AI в кэш (перезаписывает запись с тем же ключом)
+	SaveCachedEnrichment(entry *CachedEnrichment) error
+	// GetCachedEnrichment возвращает запись кэша по ключу, в том числе просроченную.
+	// Если записи нет, возвращает ErrEnrichmentNotCached.
+	GetCachedEnrichment(key string) (*CachedEnrichment, error)
+	// ListCachedEnrichments возвращает все записи кэша по возрастанию срока жизни
+	ListCachedEnrichments() ([]*CachedEnrichment, error)
+	// DeleteExpiredEnrichments удаляет записи кэша, просроченные к моменту now
+	// (см. CachedEnrichment.Expired), и возвращает удаленные записи
+	DeleteExpiredEnrichments(now time.Time) ([]*CachedEnrichment, error)
+	// DeleteCachedEnrichments удаляет записи кэша с ключами keys и возвращает удаленные записи
+	// (отсутствующие ключи пропускаются)
+	DeleteCachedEnrichments(keys []string) ([]*CachedEnrichment, error)
 
-	report, exists := s.reports[id]
-	if !exists {
-		return nil, fmt.Errorf("report not found")
-	}
-	return report, nil
+	// Sizes возвращает размер задач и записей кэша без чтения самих данных.
+	// Размер оценивается по-разному в зависимости от хранилища: MemoryStorage и FileStorage
+	// запоминают размер сериализованного JSON при записи, SQLiteStorage суммирует длину значений.
+	Sizes() (*StorageSizes, error)
 }
@@ -0,0 +1,678 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// forEachStorage запускает тест для каждой реализации Storage
+func forEachStorage(t *testing.T, test func(t *testing.T, s Storage)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStorage())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		s, err := NewFileStorage(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(t, s)
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "analyzer.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		test(t, s)
+	})
+}
+
+// TestStorageReturnsCopies проверяет, что изменение полученной задачи не меняет хранилище
+func TestStorageReturnsCopies(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		if err := s.SaveJob(job); err != nil {
+			t.Fatal(err)
+		}
+
+		// Изменения исходного объекта после сохранения не должны просачиваться в хранилище
+		job.SetProgress(42)
+
+		got, err := s.GetJob(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.UpdateStatus(StatusFailed)
+
+		again, _ := s.GetJob(job.ID)
+		if again.Status != StatusPending || again.Progress != 0 {
+			t.Errorf("storage state leaked through returned pointer: %+v", again)
+		}
+	})
+}
+
+// TestStorageUpdateJobConcurrent проверяет атомарность UpdateJob под параллельной нагрузкой
+func TestStorageUpdateJobConcurrent(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		if err := s.SaveJob(job); err != nil {
+			t.Fatal(err)
+		}
+
+		const writers, increments = 4, 25
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+
+		// Читатели непрерывно опрашивают задачу, пока писатели ее изменяют
+		for r := 0; r < 4; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					got, err := s.GetJob(job.ID)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					// Статус и прогресс меняются вместе, поэтому не могут разойтись
+					if got.Status == StatusCompleted && got.Progress != writers*increments {
+						t.Errorf("inconsistent snapshot: %+v", got)
+						return
+					}
+				}
+			}()
+		}
+
+		var writersWG sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			writersWG.Add(1)
+			go func() {
+				defer writersWG.Done()
+				for i := 0; i < increments; i++ {
+					_, err := s.UpdateJob(job.ID, func(j *Job) error {
+						j.SetProgress(j.Progress + 1)
+						if j.Progress == writers*increments {
+							j.UpdateStatus(StatusCompleted)
+						}
+						return nil
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+
+		writersWG.Wait()
+		close(stop)
+		wg.Wait()
+
+		got, _ := s.GetJob(job.ID)
+		if got.Progress != writers*increments || got.Status != StatusCompleted {
+			t.Errorf("lost updates: %+v", got)
+		}
+
+		if _, err := s.UpdateJob("missing", func(j *Job) error { return nil }); err != ErrJobNotFound {
+			t.Errorf("expected ErrJobNotFound for missing job, got %v", err)
+		}
+	})
+}
+
+// TestStorageRequestsAndRevisions проверяет хранение исходных запросов и ревизий отчета
+func TestStorageRequestsAndRevisions(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		s.SaveJob(job)
+
+		req := &domain.AnalysisRequest{
+			URL:        job.URL,
+			Violations: []domain.AxeViolation{{ID: "image-alt", Impact: "critical"}},
+		}
+		if err := s.SaveRequest(job.ID, req); err != nil {
+			t.Fatalf("SaveRequest returned error: %v", err)
+		}
+
+		got, err := s.GetRequest(job.ID)
+		if err != nil {
+			t.Fatalf("GetRequest returned error: %v", err)
+		}
+		if len(got.Violations) != 1 || got.Violations[0].ID != "image-alt" {
+			t.Errorf("unexpected request: %+v", got)
+		}
+
+		for _, revision := range []int{2, 1} {
+			if err := s.SaveReportRevision(&domain.Report{ID: job.ID, Revision: revision}); err != nil {
+				t.Fatalf("SaveReportRevision returned error: %v", err)
+			}
+		}
+		revisions, err := s.ListReportRevisions(job.ID)
+		if err != nil {
+			t.Fatalf("ListReportRevisions returned error: %v", err)
+		}
+		if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 2 {
+			t.Errorf("unexpected revisions: %+v", revisions)
+		}
+
+		s.DeleteJob(job.ID)
+		if _, err := s.GetRequest(job.ID); err != ErrRequestNotFound {
+			t.Errorf("expected ErrRequestNotFound after delete, got %v", err)
+		}
+		if revisions, _ := s.ListReportRevisions(job.ID); len(revisions) != 0 {
+			t.Errorf("expected revisions to be deleted, got %d", len(revisions))
+		}
+	})
+}
+
+// TestStorageWebhookDeliveries проверяет запись и удаление истории доставки вебхуков
+func TestStorageWebhookDeliveries(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		s.SaveJob(job)
+
+		now := time.Now()
+		next := now.Add(time.Second)
+		deliveries := []*WebhookDelivery{
+			{ID: "d1", JobID: job.ID, Event: "job.completed", URL: "http://hook", Attempt: 2,
+				Success: true, StatusCode: 200, CreatedAt: now.Add(time.Second)},
+			{ID: "d1", JobID: job.ID, Event: "job.completed", URL: "http://hook", Attempt: 1,
+				StatusCode: 500, Error: "unexpected response status 500", NextRetryAt: &next, CreatedAt: now},
+		}
+		for _, d := range deliveries {
+			if err := s.SaveWebhookDelivery(d); err != nil {
+				t.Fatalf("SaveWebhookDelivery returned error: %v", err)
+			}
+		}
+
+		got, err := s.ListWebhookDeliveries(job.ID)
+		if err != nil {
+			t.Fatalf("ListWebhookDeliveries returned error: %v", err)
+		}
+		if len(got) != 2 || got[0].Attempt != 1 || got[1].Attempt != 2 {
+			t.Fatalf("unexpected deliveries: %+v", got)
+		}
+		if got[0].NextRetryAt == nil || !got[0].NextRetryAt.Equal(next) || got[0].Success {
+			t.Errorf("unexpected first attempt: %+v", got[0])
+		}
+		if !got[1].Success || got[1].StatusCode != 200 {
+			t.Errorf("unexpected second attempt: %+v", got[1])
+		}
+
+		s.DeleteJob(job.ID)
+		if got, _ := s.ListWebhookDeliveries(job.ID); len(got) != 0 {
+			t.Errorf("expected deliveries to be deleted, got %d", len(got))
+		}
+	})
+}
+
+// TestStorageEnrichmentCache проверяет сохранение, перезапись и чтение кэша описаний
+func TestStorageEnrichmentCache(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		if _, err := s.GetCachedEnrichment("missing"); err != ErrEnrichmentNotCached {
+			t.Fatalf("expected ErrEnrichmentNotCached, got %v", err)
+		}
+
+		now := time.Now()
+		entry := &CachedEnrichment{
+			Key: "k1", RuleID: "image-alt", Locale: "ru", Model: "openai (m)", PromptVersion: "1",
+			Description: "Нет alt", HowToFix: "Добавьте alt", Confidence: 0.5,
+			CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+		}
+		if err := s.SaveCachedEnrichment(entry); err != nil {
+			t.Fatalf("SaveCachedEnrichment returned error: %v", err)
+		}
+
+		updated := *entry
+		updated.HowToFix = "Добавьте атрибут alt"
+		updated.CodeExample = `<img alt="Логотип">`
+		updated.Confidence = 0.9
+		if err := s.SaveCachedEnrichment(&updated); err != nil {
+			t.Fatalf("SaveCachedEnrichment returned error: %v", err)
+		}
+
+		got, err := s.GetCachedEnrichment("k1")
+		if err != nil {
+			t.Fatalf("GetCachedEnrichment returned error: %v", err)
+		}
+		if got.RuleID != "image-alt" || got.HowToFix != updated.HowToFix || got.CodeExample != updated.CodeExample || got.Confidence != 0.9 {
+			t.Errorf("unexpected cached enrichment: %+v", got)
+		}
+		if !got.ExpiresAt.Equal(entry.ExpiresAt) || got.Expired(now) || !got.Expired(now.Add(time.Hour)) {
+			t.Errorf("unexpected expiration: %v", got.ExpiresAt)
+		}
+	})
+}
+
+// TestStorageDeleteExpiredEnrichments проверяет удаление просроченных записей кэша описаний
+func TestStorageDeleteExpiredEnrichments(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		now := time.Now()
+		for key, ttl := range map[string]time.Duration{"old": -time.Hour, "due": 0, "fresh": time.Hour} {
+			err := s.SaveCachedEnrichment(&CachedEnrichment{
+				Key: key, RuleID: "image-alt", Locale: "ru", Model: "m", PromptVersion: "1",
+				Description: "Нет alt", HowToFix: "Добавьте alt", CreatedAt: now, ExpiresAt: now.Add(ttl),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		entries, err := s.ListCachedEnrichments()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 3 || entries[0].Key != "old" || entries[1].Key != "due" || entries[2].Key != "fresh" {
+			t.Fatalf("expected entries ordered by expiration, got %+v", entries)
+		}
+
+		deleted, err := s.DeleteExpiredEnrichments(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deleted) != 2 || deleted[0].Key != "old" || deleted[1].Key != "due" {
+			t.Errorf("expected old and due entries to be deleted, got %+v", deleted)
+		}
+		for _, key := range []string{"old", "due"} {
+			if _, err := s.GetCachedEnrichment(key); err != ErrEnrichmentNotCached {
+				t.Errorf("expected %q to be deleted, got %v", key, err)
+			}
+		}
+		if _, err := s.GetCachedEnrichment("fresh"); err != nil {
+			t.Errorf("fresh entry must be kept: %v", err)
+		}
+	})
+}
+
+// TestStorageDeleteCachedEnrichments проверяет удаление записей кэша по ключам
+func TestStorageDeleteCachedEnrichments(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		expiresAt := time.Now().Add(time.Hour)
+		for _, key := range []string{"a", "b", "c"} {
+			err := s.SaveCachedEnrichment(&CachedEnrichment{
+				Key: key, RuleID: "image-alt", Locale: "ru", Model: "m", PromptVersion: "1",
+				Description: "Нет alt", HowToFix: "Добавьте alt", CreatedAt: time.Now(), ExpiresAt: expiresAt,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Запись c с тем же сроком жизни не входит в список и должна остаться
+		deleted, err := s.DeleteCachedEnrichments([]string{"a", "b", "missing"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deleted) != 2 || deleted[0].Key != "a" || deleted[1].Key != "b" || deleted[0].Description != "Нет alt" {
+			t.Errorf("expected a and b to be deleted, got %+v", deleted)
+		}
+		for _, key := range []string{"a", "b"} {
+			if _, err := s.GetCachedEnrichment(key); err != ErrEnrichmentNotCached {
+				t.Errorf("expected %q to be deleted, got %v", key, err)
+			}
+		}
+		if _, err := s.GetCachedEnrichment("c"); err != nil {
+			t.Errorf("entry c must be kept: %v", err)
+		}
+	})
+}
+
+// TestStorageSizes проверяет, что в размер задачи входят ее отчет, запрос, ревизии и вебхуки,
+// а удаленные данные перестают учитываться
+func TestStorageSizes(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		if err := s.SaveJob(job); err != nil {
+			t.Fatal(err)
+		}
+		other := NewJob("https://example.org")
+		if err := s.SaveJob(other); err != nil {
+			t.Fatal(err)
+		}
+
+		jobSize := func() int64 {
+			t.Helper()
+			sizes, err := s.Sizes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sizes.Jobs[job.ID]
+		}
+
+		size := jobSize()
+		if size == 0 {
+			t.Fatal("expected job to have a size")
+		}
+		grow := func(name string, save func() error) {
+			t.Helper()
+			if err := save(); err != nil {
+				t.Fatal(err)
+			}
+			if next := jobSize(); next < size+1000 {
+				t.Errorf("%s: expected size to grow by at least 1000 bytes, got %d -> %d", name, size, next)
+			} else {
+				size = next
+			}
+		}
+		big := strings.Repeat("x", 1000)
+		grow("report", func() error {
+			return s.SaveReport(&domain.Report{ID: job.ID, URL: job.URL, IssuesByImpact: map[string][]domain.Issue{
+				"critical": {{ID: "image-alt", Impact: "critical", Title: "alt", AffectedElements: 1, Examples: []string{big}}},
+			}})
+		})
+		grow("request", func() error {
+			return s.SaveRequest(job.ID, &domain.AnalysisRequest{URL: job.URL, Violations: []domain.AxeViolation{{ID: "a", Description: big}}})
+		})
+		grow("revision", func() error {
+			return s.SaveReportRevision(&domain.Report{ID: job.ID, URL: job.URL, Revision: 1, Recommendations: []string{big}})
+		})
+		grow("webhook delivery", func() error {
+			return s.SaveWebhookDelivery(&WebhookDelivery{ID: "d", JobID: job.ID, URL: "https://ci.example.com", Attempt: 1, Error: big})
+		})
+
+		err := s.SaveCachedEnrichment(&CachedEnrichment{
+			Key: "k", RuleID: "image-alt", Locale: "ru", Model: "m", PromptVersion: "1",
+			Description: big, HowToFix: "Добавьте alt", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.DeleteJob(job.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.DeleteCachedEnrichments([]string{"k"}); err != nil {
+			t.Fatal(err)
+		}
+		sizes, err := s.Sizes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := sizes.Jobs[job.ID]; ok {
+			t.Errorf("deleted job must not have a size: %v", sizes.Jobs)
+		}
+		if sizes.Jobs[other.ID] == 0 || len(sizes.Enrichments) != 0 {
+			t.Errorf("unexpected sizes after deletion: %+v", sizes)
+		}
+	})
+}
+
+// TestStorageDeleteJobIf проверяет удаление задачи по условию вместе со связанными данными
+func TestStorageDeleteJobIf(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		job.UpdateStatus(StatusCompleted)
+		if err := s.SaveJob(job); err != nil {
+			t.Fatal(err)
+		}
+		s.SaveRequest(job.ID, &domain.AnalysisRequest{URL: job.URL})
+		s.SaveReport(&domain.Report{ID: job.ID, URL: job.URL})
+
+		isFailed := func(j *Job) bool { return j.Status == StatusFailed }
+		if deleted, err := s.DeleteJobIf(job.ID, isFailed); err != nil || deleted {
+			t.Fatalf("job must be kept when cond is false, got %v, %v", deleted, err)
+		}
+		if _, err := s.GetJob(job.ID); err != nil {
+			t.Fatalf("job was deleted: %v", err)
+		}
+
+		var seen JobStatus
+		deleted, err := s.DeleteJobIf(job.ID, func(j *Job) bool {
+			seen = j.Status
+			return j.Status.IsFinal()
+		})
+		if err != nil || !deleted {
+			t.Fatalf("expected job to be deleted, got %v, %v", deleted, err)
+		}
+		if seen != StatusCompleted {
+			t.Errorf("cond must receive the stored job, got status %q", seen)
+		}
+		if _, err := s.GetJob(job.ID); err != ErrJobNotFound {
+			t.Errorf("expected ErrJobNotFound, got %v", err)
+		}
+		if _, err := s.GetReport(job.ID); err != ErrReportNotFound {
+			t.Errorf("expected report to be deleted, got %v", err)
+		}
+		if _, err := s.GetRequest(job.ID); err != ErrRequestNotFound {
+			t.Errorf("expected request to be deleted, got %v", err)
+		}
+
+		if deleted, err := s.DeleteJobIf(job.ID, func(*Job) bool { return true }); err != nil || deleted {
+			t.Errorf("missing job must not be reported as deleted, got %v, %v", deleted, err)
+		}
+	})
+}
+
+// TestStorageJobUsage проверяет сохранение расхода токенов задачи
+func TestStorageJobUsage(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		day := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+		first := NewJob("https://example.com/a")
+		first.CreatedAt = day
+		first.AddUsage(TokenUsage{Requests: 2, PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CostUSD: 0.5})
+		first.AddUsage(TokenUsage{Requests: 1, PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CostUSD: 0.25})
+		second := NewJob("https://example.com/b")
+		second.CreatedAt = day.Add(24 * time.Hour)
+		second.AddUsage(TokenUsage{Requests: 1, PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2})
+		// Задача без обращений к модели в сводку не попадает
+		idle := NewJob("https://example.com/c")
+		idle.CreatedAt = day
+		for _, job := range []*Job{first, second, idle} {
+			if err := s.SaveJob(job); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := TokenUsage{Requests: 3, PromptTokens: 110, CompletionTokens: 55, TotalTokens: 165, CostUSD: 0.75}
+		got, err := s.GetJob(first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Usage == nil || *got.Usage != want {
+			t.Errorf("unexpected usage %+v, want %+v", got.Usage, want)
+		}
+		if got, _ := s.GetJob(idle.ID); got.Usage != nil {
+			t.Errorf("expected no usage for idle job, got %+v", got.Usage)
+		}
+
+		page, err := s.QueryJobs(JobQuery{URLPrefix: "https://example.com/a"})
+		if err != nil || len(page.Entries) != 1 || page.Entries[0].Job.Usage == nil || *page.Entries[0].Job.Usage != want {
+			t.Errorf("expected usage in job list, got %+v (%v)", page, err)
+		}
+	})
+}
+
+// TestStorageDailyUsage проверяет журнал расхода по дням, который не зависит от задач
+func TestStorageDailyUsage(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		job := NewJob("https://example.com")
+		s.SaveJob(job)
+
+		for _, add := range []struct {
+			date  string
+			usage TokenUsage
+		}{
+			{"2025-01-10", TokenUsage{Requests: 2, PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CostUSD: 0.5}},
+			{"2025-01-10", TokenUsage{Requests: 1, PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CostUSD: 0.25}},
+			{"2025-01-12", TokenUsage{Requests: 1, PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}},
+			{"2025-02-01", TokenUsage{Requests: 1, PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}},
+		} {
+			if err := s.AddDailyUsage(add.date, add.usage); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Удаление задачи не затрагивает журнал
+		s.DeleteJob(job.ID)
+
+		days, err := s.ListDailyUsage("2025-01-01", "2025-01-31")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []DailyUsage{
+			{Date: "2025-01-10", TokenUsage: TokenUsage{Requests: 3, PromptTokens: 110, CompletionTokens: 55, TotalTokens: 165, CostUSD: 0.75}},
+			{Date: "2025-01-12", TokenUsage: TokenUsage{Requests: 1, PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}},
+		}
+		if !reflect.DeepEqual(days, want) {
+			t.Errorf("unexpected daily usage %+v, want %+v", days, want)
+		}
+		if days, _ := s.ListDailyUsage("2025-01-11", "2025-01-12"); len(days) != 1 || days[0].Date != "2025-01-12" {
+			t.Errorf("expected only 2025-01-12, got %+v", days)
+		}
+	})
+}
+
+// TestStorageReportSummary проверяет сохранение резюме в отчёте
+func TestStorageReportSummary(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		generatedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		if err := s.SaveReportSummary("missing", "резюме", generatedAt); err != ErrReportNotFound {
+			t.Fatalf("expected ErrReportNotFound, got %v", err)
+		}
+
+		job := NewJob("https://example.com")
+		if err := s.SaveJob(job); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveReport(&domain.Report{ID: job.ID, URL: job.URL}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveReportSummary(job.ID, "Общая оценка", generatedAt); err != nil {
+			t.Fatalf("SaveReportSummary returned error: %v", err)
+		}
+
+		report, err := s.GetReport(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.ExecutiveSummary != "Общая оценка" || report.ExecutiveSummaryAt == nil || !report.ExecutiveSummaryAt.Equal(generatedAt) {
+			t.Errorf("unexpected summary %q at %v", report.ExecutiveSummary, report.ExecutiveSummaryAt)
+		}
+	})
+}
+
+// TestStorageQueryJobs проверяет фильтры, сортировку и постраничный обход списка задач
+func TestStorageQueryJobs(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		fixtures := []struct {
+			url      string
+			status   JobStatus
+			critical int
+			total    int
+		}{
+			{"https://example.com/", StatusCompleted, 3, 10},
+			{"https://blog.example.com/post", StatusCompleted, 0, 4},
+			{"https://example.org/", StatusCompleted, 5, 7},
+			{"https://example.com/pricing", StatusFailed, 0, 0},
+			{"https://notexample.com/", StatusPending, 0, 0},
+		}
+
+		ids := make([]string, len(fixtures))
+		for i, f := range fixtures {
+			job := NewJob(f.url)
+			job.Status = f.status
+			job.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+			job.UpdatedAt = job.CreatedAt
+			s.SaveJob(job)
+			ids[i] = job.ID
+
+			if f.status == StatusCompleted {
+				s.SaveReport(&domain.Report{ID: job.ID, URL: job.URL, CreatedAt: job.CreatedAt,
+					Summary: domain.ReportSummary{TotalIssues: f.total, Critical: f.critical}})
+			}
+		}
+
+		pageIDs := func(page *JobPage) []string {
+			var result []string
+			for _, entry := range page.Entries {
+				result = append(result, entry.Job.ID)
+			}
+			return result
+		}
+		expect := func(name string, q JobQuery, want ...string) {
+			t.Helper()
+			page, err := s.QueryJobs(q)
+			if err != nil {
+				t.Fatalf("%s: QueryJobs returned error: %v", name, err)
+			}
+			if got := pageIDs(page); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: got %v, want %v", name, got, want)
+			}
+		}
+
+		expect("default newest first", JobQuery{}, ids[4], ids[3], ids[2], ids[1], ids[0])
+		expect("domain with subdomains", JobQuery{Domain: "Example.com", Ascending: true}, ids[0], ids[1], ids[3])
+		expect("url prefix", JobQuery{URLPrefix: "https://example.com/", Ascending: true}, ids[0], ids[3])
+		expect("status", JobQuery{Statuses: []JobStatus{StatusFailed, StatusPending}}, ids[4], ids[3])
+		expect("date range", JobQuery{CreatedFrom: base.Add(time.Hour), CreatedTo: base.Add(3 * time.Hour)}, ids[2], ids[1])
+		expect("min critical", JobQuery{MinCritical: 3, SortBy: SortByCritical}, ids[2], ids[0])
+		expect("sort by total issues", JobQuery{SortBy: SortByTotalIssues, Statuses: []JobStatus{StatusCompleted}}, ids[0], ids[2], ids[1])
+
+		// Постраничный обход возвращает каждую задачу ровно один раз
+		var walked []string
+		query := JobQuery{SortBy: SortByTotalIssues, Limit: 2}
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatal("pagination did not terminate")
+			}
+			page, err := s.QueryJobs(query)
+			if err != nil {
+				t.Fatalf("QueryJobs returned error: %v", err)
+			}
+			walked = append(walked, pageIDs(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		// Задачи без отчета идут последними, от новых к старым
+		if want := []string{ids[0], ids[2], ids[1], ids[4], ids[3]}; !reflect.DeepEqual(walked, want) {
+			t.Errorf("pagination: got %v, want %v", walked, want)
+		}
+
+		if _, err := s.QueryJobs(JobQuery{Cursor: "not-a-cursor"}); err != ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
+
+// TestRequestHashIgnoresOrder проверяет, что хеш запроса не зависит от порядка нарушений и элементов
+func TestRequestHashIgnoresOrder(t *testing.T) {
+	a := &domain.AnalysisRequest{
+		URL: "https://Example.com/page#top",
+		Violations: []domain.AxeViolation{
+			{ID: "image-alt", Impact: "critical", Nodes: []domain.AxeNode{{HTML: "<img a>", Target: []string{"#a"}}, {HTML: "<img b>", Target: []string{"#b"}}}},
+			{ID: "label", Impact: "serious"},
+		},
+	}
+	b := &domain.AnalysisRequest{
+		URL: "https://example.com/page",
+		Violations: []domain.AxeViolation{
+			{ID: "label", Impact: "serious"},
+			{ID: "image-alt", Impact: "critical", Nodes: []domain.AxeNode{{HTML: "<img b>", Target: []string{"#b"}}, {HTML: "<img a>", Target: []string{"#a"}}}},
+		},
+		CallbackURL: "https://ci.example.com/hook",
+	}
+	if RequestHash(a) != RequestHash(b) {
+		t.Error("expected equal hashes for reordered violations")
+	}
+
+	b.Violations[0].Impact = "critical"
+	if RequestHash(a) == RequestHash(b) {
+		t.Error("expected different hashes for different violations")
+	}
+}
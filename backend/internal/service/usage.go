@@ -0,0 +1,51 @@
+package service
+
+import (
+	"sort"
+	"time"
+)
+
+// TokenUsage - расход токенов LLM и его оценочная стоимость
+type TokenUsage struct {
+	// Requests - число успешных запросов к модели
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// CostUSD - стоимость по таблице цен (0, если цена модели не задана)
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// Add прибавляет расход other
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.Requests += other.Requests
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.CostUSD += other.CostUSD
+}
+
+// usageDateLayout - формат дня в журнале расхода
+const usageDateLayout = "2006-01-02"
+
+// DailyUsage - расход токенов за один день
+type DailyUsage struct {
+	// Date - день в формате 2006-01-02 (UTC)
+	Date string `json:"date"`
+	TokenUsage
+}
+
+// UsageDate возвращает день журнала расхода (UTC) для момента t
+func UsageDate(t time.Time) string {
+	return t.UTC().Format(usageDateLayout)
+}
+
+// UsageRange возвращает дни журнала, которые покрывает интервал [from, to)
+func UsageRange(from, to time.Time) (fromDate, toDate string) {
+	return UsageDate(from), UsageDate(to.Add(-time.Nanosecond))
+}
+
+// sortDailyUsage упорядочивает дни по возрастанию даты
+func sortDailyUsage(days []DailyUsage) {
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"time"
+)
+
+// WebhookDelivery - одна попытка доставки уведомления о завершении задачи
+type WebhookDelivery struct {
+	// ID - идентификатор доставки, общий для всех ее попыток
+	ID    string `json:"id"`
+	JobID string `json:"job_id"`
+	// Event - тип уведомления, например job.completed
+	Event   string `json:"event"`
+	URL     string `json:"url"`
+	Attempt int    `json:"attempt"`
+	// StatusCode - HTTP-статус ответа получателя (0, если ответа не было)
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Success    bool   `json:"success"`
+	DurationMs int64  `json:"duration_ms"`
+	// NextRetryAt - когда будет следующая попытка, если она запланирована
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// lessWebhookDelivery упорядочивает попытки по времени, а при равенстве - по номеру попытки
+func lessWebhookDelivery(a, b *WebhookDelivery) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.Attempt < b.Attempt
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
@@ -1,395 +1,378 @@
 package translator
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/redact"
+	"github.com/danil/accessibility-analyzer/internal/service"
 )
 
-// AIClient представляет клиент для работы с AI API (Groq для Qwen)
+// AIClient формирует промпты для анализа доступности и отправляет их в LLMProvider
 type AIClient struct {
-	apiKey     string
-	httpClient *http.Client
-	apiURL     string
-}
-
-// NewAIClient создает новый клиент AI
-func NewAIClient(apiKey string) *AIClient {
-	return &AIClient{
-		apiKey:     apiKey,
-		httpClient: &http.Client{},
-		apiURL:     "https://api.groq.com/openai/v1/chat/completions",
-	}
-}
-
-// OpenAIRequest представляет запрос к OpenAI
-type OpenAIRequest struct {
-	Model     string    `json:"model"`
-	Messages  []Message `json:"messages"`
-	MaxTokens int       `json:"max_tokens,omitempty"`
+	provider LLMProvider
+	// cache хранит ранее полученные описания нарушений (nil - кэш отключен)
+	cache *enrichmentCache
+	// prices используются для оценки стоимости запросов
+	prices PriceTable
+	// redactor убирает персональные данные и секреты из промптов (nil - промпты не меняются)
+	redactor *redact.Redactor
+	// prompts - шаблоны промптов
+	prompts *Prompts
 }
 
-// Message представляет сообщение в чате
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// NewAIClient создает новый клиент AI. Если provider равен nil, клиент работает
+// в демо-режиме и возвращает заглушки без обращения к модели.
+func NewAIClient(provider LLMProvider) *AIClient {
+	return &AIClient{provider: provider, prompts: defaultPrompts}
 }
 
-// OpenAIResponse представляет ответ от OpenAI
-type OpenAIResponse struct {
-	Choices []Choice  `json:"choices"`
-	Error   *APIError `json:"error,omitempty"`
+// chat отправляет запрос провайдеру и убирает из ответа теги <think>
+func (c *AIClient) chat(ctx context.Context, system, prompt string, maxTokens int) (string, error) {
+	return c.send(ctx, ChatRequest{
+		System:    system,
+		Messages:  []Message{{Role: "user", Content: prompt}},
+		MaxTokens: maxTokens,
+	})
 }
 
-// Choice представляет вариант ответа
-type Choice struct {
-	Message Message `json:"message"`
-}
+// send выполняет запрос к провайдеру и убирает из ответа теги <think>
+func (c *AIClient) send(ctx context.Context, req ChatRequest) (string, error) {
+	req = c.redactRequest(req)
 
-// APIError представляет ошибку API
-type APIError struct {
-	Message string `json:"message"`
-	Type    string `json:"type"`
-}
-
-// Translate отправляет запрос к AI для перевода
-func (c *AIClient) Translate(prompt string) (string, error) {
-	// Если API ключ не настроен, возвращаем заглушку
-	if c.apiKey == "" {
-		return c.mockTranslate(prompt), nil
-	}
-
-	// Добавляем строгие инструкции к пользовательскому промпту, чтобы исключить
-	// использование Markdown/выделения через звездочки и получить предсказуемый
-	// plain-text ответ.
-	finalPrompt := "Переведи и объясни проблему доступа. Ответ строго на русском языке. " +
-		"Формат ответа: краткий чистый текст. НЕЛЬЗЯ использовать Markdown, звёздочки (* или **), " +
-		"подчёркивания (_), обратные кавычки, тильды или HTML-теги. Если нужно выделить, " +
-		"используй ПРОПИСНЫЕ БУКВЫ или метки в квадратных скобках, например [ВАЖНО]. " +
-		"Начинай ответ по делу и коротко.\n\n" + prompt
-
-	reqBody := OpenAIRequest{
-		Model: "qwen/qwen3-32b",
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "Ты эксперт по веб-доступности. Переводи технические описания проблем доступности на русский язык и давай практические рекомендации по исправлению.",
-			},
-			{
-				Role:    "user",
-				Content: finalPrompt,
-			},
-		},
-		MaxTokens: 1500,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+	resp, err := c.provider.Chat(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		log.Printf("[AI] %s error: %v", c.provider.Name(), err)
+		return "", err
 	}
 
-	log.Printf("[AI] Sending request to %s with model %s, prompt length: %d chars", c.apiURL, reqBody.Model, len(prompt))
+	c.recordResponse(ctx, resp)
+	return removeThinkTags(resp.Content), nil
+}
 
-	req, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+// redactRequest возвращает копию запроса, из сообщений которой убраны персональные данные и секреты
+func (c *AIClient) redactRequest(req ChatRequest) ChatRequest {
+	if c.redactor == nil {
+		return req
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+	messages := make([]Message, len(req.Messages))
+	for i, message := range req.Messages {
+		message.Content = c.redactor.String(message.Content)
+		messages[i] = message
 	}
-	defer resp.Body.Close()
+	req.Messages = messages
+	return req
+}
 
-	body, err := io.ReadAll(resp.Body)
+// redactJSON убирает данные из строк JSON-документа до того, как он попадет в промпт:
+// в закодированном JSON символы разметки экранированы и детекторы HTML их не находят.
+// Документ, который не разбирается, обрабатывается как обычный текст.
+func (c *AIClient) redactJSON(data string) string {
+	redacted, err := c.redactor.JSON([]byte(data))
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return c.redactor.String(data)
 	}
+	return string(redacted)
+}
 
-	var openAIResp OpenAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+// recordResponse пишет в лог размер ответа и учитывает расход токенов в счетчике из ctx
+func (c *AIClient) recordResponse(ctx context.Context, resp *ChatResponse) {
+	log.Printf("[AI] Received response from %s, length: %d chars, tokens: %d+%d",
+		resp.Model, len(resp.Content), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	if meter := usageMeterFrom(ctx); meter != nil {
+		meter.add(service.TokenUsage{
+			Requests:         1,
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.PromptTokens + resp.Usage.CompletionTokens,
+			CostUSD:          c.prices.Cost(resp.Model, resp.Usage),
+		})
 	}
+}
 
-	if openAIResp.Error != nil {
-		log.Printf("[AI] API error: %s", openAIResp.Error.Message)
-		return "", fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
+// Translate отправляет запрос к AI для перевода на язык locale.
+// Отмена ctx прерывает выполняющийся HTTP-запрос.
+func (c *AIClient) Translate(ctx context.Context, locale, prompt string) (string, error) {
+	// Если провайдер не настроен, возвращаем заглушку
+	if c.provider == nil {
+		return c.prompts.text(locale, promptDemo), nil
 	}
 
-	if len(openAIResp.Choices) == 0 {
-		log.Printf("[AI] No choices in response")
-		return "", fmt.Errorf("no response from OpenAI")
+	user, err := c.prompts.render(locale, promptTranslate, promptData{Data: untrusted(prompt)})
+	if err != nil {
+		return "", err
 	}
 
-	responseText := openAIResp.Choices[0].Message.Content
-	log.Printf("[AI] Received response, length: %d chars", len(responseText))
-	log.Printf("[AI] Response text:\n%s", responseText)
-
-	// Убираем теги <think> из ответа
-	responseText = removeThinkTags(responseText)
+	log.Printf("[AI] Sending request to %s, prompt length: %d chars", c.provider.Name(), len(prompt))
 
-	return responseText, nil
+	return c.chat(ctx, c.prompts.text(locale, promptTranslatorSystem), user, 1500)
 }
 
-// mockTranslate возвращает заглушку для демонстрации без API ключа
-func (c *AIClient) mockTranslate(prompt string) string {
-	return "Это демо-режим. Для полноценной работы с AI установите OPENAI_API_KEY. " +
-		"Проблема требует внимания и исправления согласно стандартам WCAG 2.1."
-}
-
-// TranslateBatch обрабатывает несколько нарушений в одном запросе к AI
-// Это позволяет сократить количество запросов с ~50 до 5 для типичного сайта
-func (c *AIClient) TranslateBatch(violations []string) ([]string, error) {
+// EnrichBatch получает от AI описания нескольких нарушений на языке locale одним запросом.
+// Это позволяет сократить количество запросов с ~50 до 5 для типичного сайта.
+// Описания, найденные в кэше, возвращаются сразу, в AI отправляются только остальные.
+// Результат выровнен по violations: nil означает, что для нарушения следует
+// использовать статическое описание. При ошибке AI вместе с ней возвращаются
+// описания из кэша.
+func (c *AIClient) EnrichBatch(ctx context.Context, locale string, violations []domain.AxeViolation) ([]*Enrichment, error) {
+	results := make([]*Enrichment, len(violations))
 	if len(violations) == 0 {
-		return []string{}, nil
+		return results, nil
 	}
 
-	// Если API ключ не настроен, возвращаем заглушки
-	if c.apiKey == "" {
-		results := make([]string, len(violations))
-		for i := range violations {
-			results[i] = c.mockTranslate(violations[i])
+	// Если провайдер не настроен, возвращаем заглушки
+	if c.provider == nil {
+		for i, v := range violations {
+			results[i] = &Enrichment{RuleID: v.ID, Description: c.prompts.text(locale, promptDemo)}
 		}
 		return results, nil
 	}
 
-	// Формируем промпт с несколькими нарушениями
-	// Строгие инструкции для пакетной обработки: явный запрет на любую разметку/выделение
-	// и требование формата для надёжного парсинга.
-	prompt := fmt.Sprintf(`Переведи и объясни следующие %d проблем доступности веб-сайта.
-Требования к ответу:
-- Язык: русский.
-- Формат: пронумерованный список (1., 2., ...). Для каждой проблемы: сначала краткое описание, затем строка "Решение: <текст>".
-- Ответ должен быть ЧИСТЫМ ТЕКСТОМ. Категорически НЕЛЬЗЯ использовать Markdown или символы/теги разметки: звёздочки (* или **), подчёркивания (_), обратные кавычки, тильды (~) или HTML-теги.
-- Не используйте кавычки или другие символы для выделения. Для акцентов используйте ПРОПИСНЫЕ БУКВЫ или метки в квадратных скобках, например [ВАЖНО].
-Начинайте каждый пункт как "1. <описание>\n   Решение: <текст>".
-Проблемы:
-`, len(violations))
+	if c.cache == nil {
+		return c.enrich(ctx, locale, violations)
+	}
 
+	model := c.provider.Name()
+	var (
+		misses  []domain.AxeViolation
+		indexes []int
+	)
 	for i, v := range violations {
-		prompt += fmt.Sprintf("\n%d. %s", i+1, v)
-	}
-
-	reqBody := OpenAIRequest{
-		Model: "qwen/qwen3-32b",
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "Ты эксперт по веб-доступности. Переводи технические описания проблем доступности на русский язык и давай практические рекомендации по исправлению.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		MaxTokens: 2000,
+		if results[i] = c.cache.get(v, locale, model, c.prompts.Version()); results[i] == nil {
+			misses = append(misses, v)
+			indexes = append(indexes, i)
+		}
 	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	if len(misses) == 0 {
+		log.Printf("[AI] All %d violations served from cache", len(violations))
+		return results, nil
 	}
 
-	log.Printf("[AI] Sending batch request with %d violations, model: %s", len(violations), reqBody.Model)
-
-	req, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(jsonData))
+	enriched, err := c.enrich(ctx, locale, misses)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return results, err
+	}
+	for j, e := range enriched {
+		if e != nil && !e.Rejected {
+			c.cache.put(misses[j], locale, model, c.prompts.Version(), e)
+		}
+		results[indexes[j]] = e
 	}
+	return results, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+// enrich запрашивает описания у AI. Ответ ограничен JSON-схемой и проверяется;
+// если часть описаний некорректна, модель переспрашивается один раз.
+func (c *AIClient) enrich(ctx context.Context, locale string, violations []domain.AxeViolation) ([]*Enrichment, error) {
+	results := make([]*Enrichment, len(violations))
 
-	resp, err := c.httpClient.Do(req)
+	type batchItem struct {
+		RuleID      string `json:"rule_id"`
+		Impact      string `json:"impact"`
+		Help        string `json:"help"`
+		Description string `json:"description"`
+	}
+	items := make([]batchItem, len(violations))
+	expected := make([]string, len(violations))
+	for i, v := range violations {
+		items[i] = batchItem{RuleID: v.ID, Impact: v.Impact, Help: v.Help, Description: v.Description}
+		expected[i] = v.ID
+	}
+	itemsJSON, err := json.MarshalIndent(items, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to marshal violations: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	prompt, err := c.prompts.render(locale, promptEnrich, promptData{Data: untrusted(string(itemsJSON)), Count: len(violations)})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	var openAIResp OpenAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	req := ChatRequest{
+		System:    c.prompts.text(locale, promptTranslatorSystem),
+		Messages:  []Message{{Role: "user", Content: prompt}},
+		MaxTokens: 2000,
+		Schema:    enrichmentSchema,
 	}
 
-	if openAIResp.Error != nil {
-		log.Printf("[AI] Batch API error: %s", openAIResp.Error.Message)
-		return nil, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
-	}
+	log.Printf("[AI] Sending batch request with %d violations to %s", len(violations), c.provider.Name())
 
-	if len(openAIResp.Choices) == 0 {
-		log.Printf("[AI] No choices in batch response")
-		return nil, fmt.Errorf("no response from OpenAI")
+	content, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// Парсим ответ AI и разбиваем на отдельные рекомендации
-	content := openAIResp.Choices[0].Message.Content
-	log.Printf("[AI] Received batch response, content length: %d chars", len(content))
-	log.Printf("[AI] Batch response text:\n%s", content)
+	valid, problems, rejected := parseEnrichments(locale, content, expected)
+	if missing := missingRules(expected, valid); len(missing) > 0 {
+		// Переспрашиваем один раз, перечислив найденные ошибки
+		log.Printf("[AI] Batch response failed validation (%d problems), asking again", len(problems))
 
-	// Убираем теги <think> из ответа
-	content = removeThinkTags(content)
+		retry, err := c.prompts.render(locale, promptEnrichRetry, promptData{Problems: problems, Rules: missing})
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages,
+			Message{Role: "assistant", Content: content},
+			Message{Role: "user", Content: retry},
+		)
+
+		retried, err := c.send(ctx, req)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil {
+			fixed, _, rejectedAgain := parseEnrichments(locale, retried, missing)
+			for id, enrichment := range fixed {
+				valid[id] = enrichment
+			}
+			for id := range rejectedAgain {
+				rejected[id] = true
+			}
+		}
+	}
 
-	results := c.parseBatchResponse(content, len(violations))
-	log.Printf("[AI] Parsed %d results from batch response", len(results))
+	for i, v := range violations {
+		results[i] = valid[v.ID]
+		// Отклоненный ответ отмечается в проблеме, чтобы было видно, почему описание статическое
+		if results[i] == nil && rejected[v.ID] {
+			results[i] = &Enrichment{RuleID: v.ID, Rejected: true}
+		}
+	}
+	if missing := missingRules(expected, valid); len(missing) > 0 {
+		log.Printf("[AI] No valid description for %d of %d violations (%d rejected as unsafe), using static descriptions: %s",
+			len(missing), len(violations), countRejected(results), strings.Join(missing, ", "))
+	}
 
 	return results, nil
 }
 
-// parseBatchResponse парсит ответ AI и извлекает рекомендации для каждого нарушения
-func (c *AIClient) parseBatchResponse(content string, expectedCount int) []string {
-	// Простой парсинг по номерам: "1. ", "2. ", и т.д.
-	results := make([]string, expectedCount)
-	lines := strings.Split(content, "\n")
-
-	currentIndex := -1
-	var currentText strings.Builder
-
-	for _, line := range lines {
-		// Проверяем, начинается ли строка с номера
-		for i := 1; i <= expectedCount; i++ {
-			prefix := fmt.Sprintf("%d.", i)
-			if strings.HasPrefix(strings.TrimSpace(line), prefix) {
-				// Сохраняем предыдущий текст
-				if currentIndex >= 0 && currentIndex < expectedCount {
-					results[currentIndex] = strings.TrimSpace(currentText.String())
-				}
-				// Начинаем новый блок
-				currentIndex = i - 1
-				currentText.Reset()
-				// Добавляем текст после номера
-				text := strings.TrimPrefix(strings.TrimSpace(line), prefix)
-				currentText.WriteString(strings.TrimSpace(text))
-				goto nextLine
-			}
+// countRejected возвращает число описаний, отклоненных проверкой безопасности
+func countRejected(enrichments []*Enrichment) int {
+	count := 0
+	for _, e := range enrichments {
+		if e != nil && e.Rejected {
+			count++
 		}
-		// Если не нашли номер, добавляем к текущему блоку
-		if currentIndex >= 0 {
-			currentText.WriteString(" ")
-			currentText.WriteString(strings.TrimSpace(line))
-		}
-	nextLine:
-	}
-
-	// Сохраняем последний блок
-	if currentIndex >= 0 && currentIndex < expectedCount {
-		results[currentIndex] = strings.TrimSpace(currentText.String())
 	}
+	return count
+}
 
-	// Заполняем пустые значения заглушками
-	for i := range results {
-		if results[i] == "" {
-			results[i] = "Требует внимания и исправления согласно стандартам WCAG 2.1."
+// missingRules возвращает ID правил без корректного описания
+func missingRules(expected []string, valid map[string]*Enrichment) []string {
+	var missing []string
+	for _, id := range expected {
+		if valid[id] == nil {
+			missing = append(missing, id)
 		}
 	}
-
-	return results
+	return missing
 }
 
-// GenerateSummary генерирует общее резюме с комплексными рекомендациями по всему отчёту
-func (c *AIClient) GenerateSummary(reportJSON string) (string, error) {
-	// Если API ключ не настроен, возвращаем заглушку
-	if c.apiKey == "" {
-		return "Это демо-режим. Для получения комплексных рекомендаций установите OPENAI_API_KEY.", nil
-	}
-
-	prompt := fmt.Sprintf(`Проанализируй следующий отчёт о доступности веб-сайта и составь комплексное резюме.
-
-Отчёт:
-%s
-
-Твоя задача:
-1. Проанализировать все проблемы доступности на сайте
-2. Выявить основные категории проблем
-3. Дать рекомендации по дальнейшему поддержанию доступности
-
-Формат ответа:
-- Общая оценка доступности сайта
-- Основные проблемы
-- Рекомендации по поддержке
-
-ТРЕБОВАНИЯ К ФОРМАТУ:
-- Ответ должен быть на русском языке, структурированным и практичным
-- Ответ должен быть ЧИСТЫМ ТЕКСТОМ без разметки
-- КАТЕГОРИЧЕСКИ НЕЛЬЗЯ использовать Markdown или символы разметки: звёздочки (* или **), подчёркивания (_), обратные кавычки, тильды (~) или HTML-теги
-- Не используйте кавычки или другие символы для выделения
-- Для акцентов и заголовков используйте ПРОПИСНЫЕ БУКВЫ или метки в квадратных скобках, например [ВАЖНО], [ПРИОРИТЕТ 1]
-- Используйте простые списки с дефисами или нумерацией (1., 2., 3.)`, reportJSON)
-
-	reqBody := OpenAIRequest{
-		Model: "qwen/qwen3-32b",
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "Ты эксперт по веб-доступности и стандартам WCAG. Ты анализируешь отчёты о доступности и даёшь комплексные практические рекомендации по улучшению сайтов.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		MaxTokens: 2500,
+// FixNodes запрашивает у AI минимально исправленный HTML каждого элемента items
+// с пояснением на языке locale. Возвращает исправления по ID элемента; элементы без
+// корректного исправления в результат не попадают. В демо-режиме исправления не запрашиваются.
+func (c *AIClient) FixNodes(ctx context.Context, locale string, items []nodeFixItem) (map[string]*nodeFix, error) {
+	if c.provider == nil || len(items) == 0 {
+		return nil, nil
 	}
 
-	jsonData, err := json.Marshal(reqBody)
+	// HTML проверяется до кодирования в JSON, где символы разметки экранируются
+	sent := make([]nodeFixItem, len(items))
+	for i, item := range items {
+		item.HTML = c.redactor.String(item.HTML)
+		item.FailureSummary = c.redactor.String(item.FailureSummary)
+		sent[i] = item
+	}
+	itemsJSON, err := json.MarshalIndent(sent, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
 	}
 
-	log.Printf("[AI] Generating summary for report, model: %s", reqBody.Model)
-
-	req, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(jsonData))
+	prompt, err := c.prompts.render(locale, promptNodeFix, promptData{Data: untrusted(string(itemsJSON))})
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, err
+	}
+	req := ChatRequest{
+		System:    c.prompts.text(locale, promptTranslatorSystem),
+		Messages:  []Message{{Role: "user", Content: prompt}},
+		MaxTokens: 3000,
+		Schema:    nodeFixSchema,
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	log.Printf("[AI] Sending node fix request with %d elements to %s", len(items), c.provider.Name())
 
-	resp, err := c.httpClient.Do(req)
+	content, err := c.send(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	fixes := parseNodeFixes(content, items)
+	if len(fixes) < len(items) {
+		log.Printf("[AI] No valid fix for %d of %d elements", len(items)-len(fixes), len(items))
 	}
+	return fixes, nil
+}
 
-	var openAIResp OpenAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+// GenerateSummary генерирует общее резюме с комплексными рекомендациями по всему отчёту на языке locale
+func (c *AIClient) GenerateSummary(ctx context.Context, locale, reportJSON string) (string, error) {
+	// Если провайдер не настроен, возвращаем заглушку
+	if c.provider == nil {
+		return c.prompts.text(locale, promptDemoSummary), nil
 	}
 
-	if openAIResp.Error != nil {
-		log.Printf("[AI] Summary API error: %s", openAIResp.Error.Message)
-		return "", fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
+	prompt, err := c.prompts.render(locale, promptSummary, promptData{Data: untrusted(c.redactJSON(reportJSON))})
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("[AI] Generating summary for report with %s", c.provider.Name())
+
+	return c.chat(ctx, c.prompts.text(locale, promptSummarySystem), prompt, 2500)
+}
+
+// StreamSummary генерирует резюме, передавая текст в onDelta по мере генерации.
+// Блоки <think> отфильтровываются на лету и не попадают ни в onDelta, ни в результат.
+func (c *AIClient) StreamSummary(ctx context.Context, locale, reportJSON string, onDelta func(text string)) (string, error) {
+	// Если провайдер не настроен, возвращаем заглушку одним фрагментом
+	if c.provider == nil {
+		demo := c.prompts.text(locale, promptDemoSummary)
+		onDelta(demo)
+		return demo, nil
 	}
 
-	if len(openAIResp.Choices) == 0 {
-		log.Printf("[AI] No choices in summary response")
-		return "", fmt.Errorf("no response from OpenAI")
+	prompt, err := c.prompts.render(locale, promptSummary, promptData{Data: untrusted(c.redactJSON(reportJSON))})
+	if err != nil {
+		return "", err
+	}
+	req := ChatRequest{
+		System:    c.prompts.text(locale, promptSummarySystem),
+		Messages:  []Message{{Role: "user", Content: prompt}},
+		MaxTokens: 2500,
 	}
+	req = c.redactRequest(req)
 
-	summary := openAIResp.Choices[0].Message.Content
-	log.Printf("[AI] Generated summary, length: %d chars", len(summary))
+	log.Printf("[AI] Streaming summary for report with %s", c.provider.Name())
 
-	// Убираем теги <think> из ответа
-	summary = removeThinkTags(summary)
+	var filter thinkFilter
+	resp, err := chatStream(ctx, c.provider, req, func(text string) {
+		if visible := filter.Write(text); visible != "" {
+			onDelta(visible)
+		}
+	})
+	if err != nil {
+		log.Printf("[AI] %s error: %v", c.provider.Name(), err)
+		return "", err
+	}
+	if rest := filter.Flush(); rest != "" {
+		onDelta(rest)
+	}
 
-	return summary, nil
+	c.recordResponse(ctx, resp)
+	return removeThinkTags(resp.Content), nil
 }
 
 // removeThinkTags удаляет теги <think> и </think> вместе с содержимым из текста
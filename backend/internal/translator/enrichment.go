@@ -0,0 +1,124 @@
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+)
+
+// Enrichment - описание проблемы доступности от AI
+type Enrichment struct {
+	RuleID      string  `json:"rule_id"`
+	Description string  `json:"description"`
+	HowToFix    string  `json:"how_to_fix"`
+	CodeExample string  `json:"code_example"`
+	Confidence  float64 `json:"confidence"`
+	// Rejected означает, что ответ модели не прошел проверку безопасности
+	// и вместо него используется статическое описание
+	Rejected bool `json:"-"`
+}
+
+// enrichmentResponse - ответ модели на запрос описаний батча
+type enrichmentResponse struct {
+	Items []Enrichment `json:"items"`
+}
+
+// enrichmentSchema - JSON-схема ответа на запрос описаний батча.
+// Все поля обязательны, чтобы схема подходила для строгого режима OpenAI.
+var enrichmentSchema = &JSONSchema{
+	Name: "accessibility_issues",
+	Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "items": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "rule_id": {"type": "string"},
+          "description": {"type": "string"},
+          "how_to_fix": {"type": "string"},
+          "code_example": {"type": "string"},
+          "confidence": {"type": "number", "minimum": 0, "maximum": 1}
+        },
+        "required": ["rule_id", "description", "how_to_fix", "code_example", "confidence"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["items"],
+  "additionalProperties": false
+}`),
+}
+
+// parseEnrichments разбирает ответ модели и проверяет каждый элемент.
+// Возвращает прошедшие проверку описания по ID правила, список найденных проблем на языке
+// locale (они перечисляются модели при повторном запросе) и правила, ответ для которых отклонен проверкой безопасности (например, следует
+// инструкциям со страницы). Описания правил, которых нет в expected, отбрасываются.
+func parseEnrichments(locale, content string, expected []string) (map[string]*Enrichment, []string, map[string]bool) {
+	valid := make(map[string]*Enrichment)
+	rejected := make(map[string]bool)
+	var problems []string
+
+	var resp enrichmentResponse
+	if err := json.Unmarshal([]byte(extractJSON(content)), &resp); err != nil {
+		return valid, []string{i18n.T(locale, "validation.invalid_json", err)}, rejected
+	}
+
+	wanted := make(map[string]bool, len(expected))
+	for _, id := range expected {
+		wanted[id] = true
+	}
+
+	for i := range resp.Items {
+		item := resp.Items[i]
+		item.RuleID = strings.TrimSpace(item.RuleID)
+		item.Description = strings.TrimSpace(item.Description)
+		item.HowToFix = strings.TrimSpace(item.HowToFix)
+		item.CodeExample = strings.TrimSpace(item.CodeExample)
+
+		switch {
+		case !wanted[item.RuleID]:
+			problems = append(problems, i18n.T(locale, "validation.unknown_rule", item.RuleID))
+		case valid[item.RuleID] != nil:
+			problems = append(problems, i18n.T(locale, "validation.duplicate_rule", item.RuleID))
+		case item.Description == "" || item.HowToFix == "":
+			problems = append(problems, i18n.T(locale, "validation.empty_fields", item.RuleID))
+		case item.Confidence < 0 || item.Confidence > 1:
+			problems = append(problems, i18n.T(locale, "validation.confidence_range", item.RuleID))
+		case unsafeEnrichment(item) != "":
+			problems = append(problems, i18n.T(locale, "validation.unsafe", item.RuleID, i18n.T(locale, unsafeEnrichment(item))))
+			rejected[item.RuleID] = true
+		default:
+			valid[item.RuleID] = &item
+		}
+	}
+
+	for _, id := range expected {
+		if valid[id] == nil {
+			problems = append(problems, i18n.T(locale, "validation.missing_rule", id))
+		}
+	}
+	return valid, problems, rejected
+}
+
+// unsafeEnrichment возвращает ключ i18n с причиной, по которой описание нельзя показывать
+// в отчете, или пустую строку
+func unsafeEnrichment(e Enrichment) string {
+	if reason := unsafeText(e.Description + "\n" + e.HowToFix); reason != "" {
+		return reason
+	}
+	return unsafeCode(e.CodeExample)
+}
+
+// extractJSON убирает из ответа обертку ```json ... ``` и текст вокруг JSON-объекта
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return content
+	}
+	return content[start : end+1]
+}
@@ -0,0 +1,106 @@
+package translator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// EnrichmentCacheStore хранит описания от AI между запусками (реализуется service.Storage)
+type EnrichmentCacheStore interface {
+	SaveCachedEnrichment(entry *service.CachedEnrichment) error
+	GetCachedEnrichment(key string) (*service.CachedEnrichment, error)
+}
+
+// CacheStats - счетчики кэша описаний для /health
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	TTL     string  `json:"ttl"`
+}
+
+// enrichmentCache отдает ранее полученные описания нарушений, чтобы повторяющиеся
+// правила не отправлялись в AI. Ключ включает текст axe, язык, модель и версию промпта,
+// поэтому смена любого из них приводит к новому запросу.
+type enrichmentCache struct {
+	store EnrichmentCacheStore
+	ttl   time.Duration
+	// now подменяется в тестах
+	now func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newEnrichmentCache(store EnrichmentCacheStore, ttl time.Duration) *enrichmentCache {
+	return &enrichmentCache{store: store, ttl: ttl, now: time.Now}
+}
+
+// enrichmentCacheKey вычисляет ключ кэша для нарушения. Версия промптов promptVersion
+// входит в ключ, чтобы описания, полученные по старым промптам, не брались из кэша.
+func enrichmentCacheKey(v domain.AxeViolation, locale, model, promptVersion string) string {
+	textHash := sha256.Sum256([]byte(v.Help + "\n" + v.Description))
+	key := sha256.Sum256([]byte(strings.Join([]string{
+		v.ID, hex.EncodeToString(textHash[:]), locale, model, promptVersion,
+	}, "\x00")))
+	return hex.EncodeToString(key[:])
+}
+
+// get возвращает непросроченное описание из кэша или nil
+func (c *enrichmentCache) get(v domain.AxeViolation, locale, model, promptVersion string) *Enrichment {
+	entry, err := c.store.GetCachedEnrichment(enrichmentCacheKey(v, locale, model, promptVersion))
+	if err != nil || entry.Expired(c.now()) {
+		if err != nil && !errors.Is(err, service.ErrEnrichmentNotCached) {
+			log.Printf("[AI] Failed to read enrichment cache: %v", err)
+		}
+		c.misses.Add(1)
+		return nil
+	}
+
+	c.hits.Add(1)
+	return &Enrichment{
+		RuleID:      v.ID,
+		Description: entry.Description,
+		HowToFix:    entry.HowToFix,
+		CodeExample: entry.CodeExample,
+		Confidence:  entry.Confidence,
+	}
+}
+
+// put сохраняет прошедшее проверку описание. Ошибка записи не мешает анализу.
+func (c *enrichmentCache) put(v domain.AxeViolation, locale, model, promptVersion string, e *Enrichment) {
+	now := c.now()
+	err := c.store.SaveCachedEnrichment(&service.CachedEnrichment{
+		Key:           enrichmentCacheKey(v, locale, model, promptVersion),
+		RuleID:        v.ID,
+		Locale:        locale,
+		Model:         model,
+		PromptVersion: promptVersion,
+		Description:   e.Description,
+		HowToFix:      e.HowToFix,
+		CodeExample:   e.CodeExample,
+		Confidence:    e.Confidence,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(c.ttl),
+	})
+	if err != nil {
+		log.Printf("[AI] Failed to save enrichment cache: %v", err)
+	}
+}
+
+// Stats возвращает счетчики попаданий и промахов с момента запуска
+func (c *enrichmentCache) Stats() CacheStats {
+	stats := CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), TTL: c.ttl.String()}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,258 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// TestParseEnrichmentsValidatesItems проверяет разбор и проверку каждого элемента ответа
+func TestParseEnrichmentsValidatesItems(t *testing.T) {
+	content := "```json\n" + `{"items": [
+		{"rule_id": "image-alt", "description": "Нет alt", "how_to_fix": "Добавьте alt", "code_example": "<img alt=\"Логотип\">", "confidence": 0.9},
+		{"rule_id": "label", "description": "Нет метки", "how_to_fix": "", "code_example": "", "confidence": 0.5},
+		{"rule_id": "link-name", "description": "Пустая ссылка", "how_to_fix": "Добавьте текст", "code_example": "", "confidence": 7},
+		{"rule_id": "unknown", "description": "x", "how_to_fix": "y", "code_example": "", "confidence": 0.1}
+	]}` + "\n```"
+
+	valid, problems, rejected := parseEnrichments(i18n.Russian, content, []string{"image-alt", "label", "link-name", "region"})
+
+	if len(valid) != 1 || valid["image-alt"] == nil {
+		t.Fatalf("expected only image-alt to be valid, got %v", valid)
+	}
+	if got := valid["image-alt"]; got.CodeExample != `<img alt="Логотип">` || got.Confidence != 0.9 {
+		t.Errorf("unexpected enrichment: %+v", got)
+	}
+	if len(rejected) != 0 {
+		t.Errorf("expected no rejected items, got %v", rejected)
+	}
+
+	joined := strings.Join(problems, "\n")
+	for _, want := range []string{`"label"`, `"link-name"`, `"region"`, `"unknown"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected problem mentioning %s, got:\n%s", want, joined)
+		}
+	}
+
+	if _, problems, _ := parseEnrichments(i18n.Russian, "1. Описание\nРешение: текст", []string{"image-alt"}); len(problems) == 0 {
+		t.Error("expected problem for non-JSON response")
+	}
+}
+
+// TestParseEnrichmentsProblemsFollowLocale проверяет, что ошибки для повторного запроса
+// написаны на языке отчета, включая причины отклонения проверкой безопасности
+func TestParseEnrichmentsProblemsFollowLocale(t *testing.T) {
+	content := `{"items": [
+		{"rule_id": "image-alt", "description": "See https://evil.example", "how_to_fix": "Add alt", "code_example": "", "confidence": 0.9},
+		{"rule_id": "label", "description": "No label", "how_to_fix": "Add a label", "code_example": "<script>x()</script>", "confidence": 0.9}
+	]}`
+	expected := []string{"image-alt", "label", "region"}
+
+	_, problems, rejected := parseEnrichments(i18n.English, content, expected)
+	if !rejected["image-alt"] || !rejected["label"] {
+		t.Fatalf("expected both items to be rejected, got %v", rejected)
+	}
+	want := []string{
+		`rule "image-alt": the answer contains links to unknown sites`,
+		`rule "label": the code example contains a script`,
+		`no valid description for rule "region"`,
+	}
+	joined := strings.Join(problems, "\n")
+	for _, text := range want {
+		if !strings.Contains(joined, text) {
+			t.Errorf("expected English problem %q, got:\n%s", text, joined)
+		}
+	}
+
+	_, problems, _ = parseEnrichments(i18n.Russian, content, expected)
+	if joined := strings.Join(problems, "\n"); !strings.Contains(joined, `правило "image-alt": ответ содержит ссылки на неизвестные сайты`) {
+		t.Errorf("expected Russian problems, got:\n%s", joined)
+	}
+}
+
+// TestEnrichBatchReasksOnceAndFallsBackPerItem проверяет повторный запрос только
+// для некорректных элементов и статические описания для оставшихся
+func TestEnrichBatchReasksOnceAndFallsBackPerItem(t *testing.T) {
+	responses := []string{
+		// Первый ответ: label без решения, region отсутствует
+		`{"items": [
+			{"rule_id": "image-alt", "description": "Нет alt", "how_to_fix": "Добавьте alt", "code_example": "", "confidence": 0.9},
+			{"rule_id": "label", "description": "Нет метки", "how_to_fix": "", "code_example": "", "confidence": 0.5}
+		]}`,
+		// Второй ответ исправляет только label
+		`{"items": [
+			{"rule_id": "label", "description": "Нет метки", "how_to_fix": "Добавьте label", "code_example": "<label for=\"q\">Поиск</label>", "confidence": 0.8}
+		]}`,
+	}
+
+	var (
+		mu       sync.Mutex
+		requests []OpenAIRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		n := len(requests)
+		requests = append(requests, req)
+		mu.Unlock()
+
+		content := `{"items": []}`
+		if n < len(responses) {
+			content = responses[n]
+		}
+		json.NewEncoder(w).Encode(OpenAIResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: content}}}})
+	}))
+	defer server.Close()
+
+	violations := []domain.AxeViolation{
+		{ID: "image-alt", Impact: "critical", Help: "Images must have alternate text"},
+		{ID: "label", Impact: "critical", Help: "Form elements must have labels"},
+		{ID: "region", Impact: "moderate", Help: "All page content should be contained by landmarks"},
+	}
+
+	results, err := NewAIClient(testProvider(server.URL)).EnrichBatch(context.Background(), i18n.Russian, violations)
+	if err != nil {
+		t.Fatalf("EnrichBatch returned error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected exactly one re-ask, got %d requests", len(requests))
+	}
+	if format := requests[0].ResponseFormat; format == nil || format.Type != "json_schema" || !format.JSONSchema.Strict {
+		t.Errorf("expected strict json_schema response format, got %+v", format)
+	}
+	retry := requests[1].Messages
+	if len(retry) != 4 || retry[2].Role != "assistant" || !strings.Contains(retry[3].Content, "label, region") {
+		t.Errorf("re-ask should include the previous answer and list invalid rules, got %+v", retry)
+	}
+
+	if results[0] == nil || results[0].HowToFix != "Добавьте alt" {
+		t.Errorf("expected image-alt from the first answer, got %+v", results[0])
+	}
+	if results[1] == nil || results[1].HowToFix != "Добавьте label" {
+		t.Errorf("expected label from the second answer, got %+v", results[1])
+	}
+	if results[2] != nil {
+		t.Errorf("region should fall back to static description, got %+v", results[2])
+	}
+
+	// Processor использует статическое описание только для region
+	processor := NewProcessor(nil)
+	issue := processor.convertViolationToIssueWithAI(i18n.Russian, violations[1], results[1])
+	if issue.HowToFix != "Добавьте label" || issue.CodeExample != `<label for="q">Поиск</label>` || issue.Confidence != 0.8 {
+		t.Errorf("unexpected enriched issue: %+v", issue)
+	}
+	fallback := processor.convertViolationToIssueWithAI(i18n.Russian, violations[2], results[2])
+	if fallback.Confidence != 0 || fallback.CodeExample != "" || fallback.HowToFix == "" {
+		t.Errorf("unexpected fallback issue: %+v", fallback)
+	}
+}
+
+// TestEnrichBatchUsesCache проверяет, что в AI отправляются только правила, которых нет в кэше
+func TestEnrichBatchUsesCache(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		prompts []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// Отвечаем описанием для каждого правила, упомянутого в промпте
+		prompt := req.Messages[len(req.Messages)-1].Content
+		mu.Lock()
+		prompts = append(prompts, prompt)
+		mu.Unlock()
+
+		var resp enrichmentResponse
+		for _, id := range []string{"image-alt", "label", "region"} {
+			if strings.Contains(prompt, `"`+id+`"`) {
+				resp.Items = append(resp.Items, Enrichment{RuleID: id, Description: "Описание " + id, HowToFix: "Исправление", Confidence: 0.7})
+			}
+		}
+		content, _ := json.Marshal(resp)
+		json.NewEncoder(w).Encode(OpenAIResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: string(content)}}}})
+	}))
+	defer server.Close()
+
+	client := NewAIClient(testProvider(server.URL))
+	client.cache = newEnrichmentCache(service.NewMemoryStorage(), time.Hour)
+	now := time.Now()
+	client.cache.now = func() time.Time { return now }
+
+	imageAlt := domain.AxeViolation{ID: "image-alt", Impact: "critical", Help: "Images must have alternate text"}
+	label := domain.AxeViolation{ID: "label", Impact: "critical", Help: "Form elements must have labels"}
+	region := domain.AxeViolation{ID: "region", Impact: "moderate", Help: "All page content should be contained by landmarks"}
+
+	if _, err := client.EnrichBatch(context.Background(), i18n.Russian, []domain.AxeViolation{imageAlt, label}); err != nil {
+		t.Fatalf("EnrichBatch returned error: %v", err)
+	}
+
+	// Повторные правила берутся из кэша, в AI уходит только region
+	results, err := client.EnrichBatch(context.Background(), i18n.Russian, []domain.AxeViolation{imageAlt, region, label})
+	if err != nil {
+		t.Fatalf("EnrichBatch returned error: %v", err)
+	}
+	if len(prompts) != 2 || strings.Contains(prompts[1], `"image-alt"`) || !strings.Contains(prompts[1], `"region"`) {
+		t.Fatalf("expected second request to contain only region, got %d requests", len(prompts))
+	}
+	for i, want := range []string{"image-alt", "region", "label"} {
+		if results[i] == nil || results[i].RuleID != want || results[i].Description != "Описание "+want || results[i].Confidence != 0.7 {
+			t.Errorf("unexpected result %d: %+v", i, results[i])
+		}
+	}
+	if stats := client.cache.Stats(); stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("expected 2 hits and 3 misses, got %+v", stats)
+	}
+
+	// Измененный текст axe и истекший срок жизни приводят к новому запросу
+	changed := imageAlt
+	changed.Help = "Images must have alt text"
+	client.EnrichBatch(context.Background(), i18n.Russian, []domain.AxeViolation{changed})
+	now = now.Add(2 * time.Hour)
+	client.EnrichBatch(context.Background(), i18n.Russian, []domain.AxeViolation{label})
+	if len(prompts) != 4 {
+		t.Errorf("expected changed and expired rules to be requested again, got %d requests", len(prompts))
+	}
+}
+
+// TestProvidersSendSchema проверяет передачу JSON-схемы в запросах адаптеров
+func TestProvidersSendSchema(t *testing.T) {
+	req := testChatRequest
+	req.Schema = enrichmentSchema
+
+	ollama := newLLMStub(t, http.StatusOK, `{"message":{"role":"assistant","content":"{}"}}`)
+	provider, _ := NewProvider(ProviderConfig{Provider: ProviderOllama, BaseURL: ollama.URL})
+	if _, err := provider.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Ollama Chat returned error: %v", err)
+	}
+	if format, ok := ollama.body["format"].(map[string]interface{}); !ok || format["type"] != "object" {
+		t.Errorf("expected schema in Ollama format field, got %v", ollama.body["format"])
+	}
+
+	// Anthropic возвращает структурированный ответ как аргументы обязательного инструмента
+	anthropic := newLLMStub(t, http.StatusOK, `{"content":[{"type":"tool_use","name":"accessibility_issues","input":{"items":[]}}]}`)
+	provider, _ = NewProvider(ProviderConfig{Provider: ProviderAnthropic, APIKey: "key", BaseURL: anthropic.URL})
+	resp, err := provider.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Anthropic Chat returned error: %v", err)
+	}
+	if resp.Content != `{"items":[]}` {
+		t.Errorf("expected tool input as content, got %q", resp.Content)
+	}
+	choice, _ := anthropic.body["tool_choice"].(map[string]interface{})
+	if choice["type"] != "tool" || choice["name"] != enrichmentSchema.Name {
+		t.Errorf("expected forced tool choice, got %v", anthropic.body["tool_choice"])
+	}
+}
@@ -0,0 +1,223 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Режимы записи и воспроизведения ответов модели (LLM_FIXTURE_MODE)
+const (
+	// FixtureRecord - запросы выполняются провайдером, пары запрос/ответ сохраняются в файлы
+	FixtureRecord = "record"
+	// FixtureReplay - ответы берутся из сохраненных файлов, провайдер не нужен
+	FixtureReplay = "replay"
+)
+
+// ErrFixtureNotFound возвращается в режиме воспроизведения, если запрос не был записан
+var ErrFixtureNotFound = errors.New("no recorded LLM response for request")
+
+// fixture - файл с записанной парой запрос/ответ
+type fixture struct {
+	Request  fixtureRequest  `json:"request"`
+	Response fixtureResponse `json:"response"`
+}
+
+type fixtureRequest struct {
+	System    string         `json:"system"`
+	Messages  []Message      `json:"messages"`
+	MaxTokens int            `json:"max_tokens"`
+	Schema    *fixtureSchema `json:"schema,omitempty"`
+}
+
+type fixtureSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type fixtureResponse struct {
+	Content          string `json:"content"`
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+func newFixtureRequest(req ChatRequest) fixtureRequest {
+	r := fixtureRequest{System: req.System, Messages: req.Messages, MaxTokens: req.MaxTokens}
+	if req.Schema != nil {
+		r.Schema = &fixtureSchema{Name: req.Schema.Name, Schema: req.Schema.Schema}
+	}
+	return r
+}
+
+// fixtureHash вычисляет имя файла записи: хэш всех полей запроса.
+// Любое изменение промпта, данных или схемы дает новый хэш.
+func fixtureHash(req ChatRequest) (string, error) {
+	data, err := json.Marshal(newFixtureRequest(req))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
+
+// FixtureProvider записывает ответы модели в каталог или воспроизводит их оттуда.
+// Запись с одинаковым запросом всегда дает одинаковый ответ, поэтому тесты и отладка
+// на воспроизведенных ответах детерминированы.
+type FixtureProvider struct {
+	// provider выполняет запросы в режиме записи (nil при воспроизведении)
+	provider LLMProvider
+	mode     string
+	dir      string
+}
+
+// NewFixtureProvider оборачивает provider в режиме mode (FixtureRecord или FixtureReplay)
+// с файлами в каталоге dir. Пустой mode возвращает provider без изменений.
+func NewFixtureProvider(provider LLMProvider, mode, dir string) (LLMProvider, error) {
+	switch mode {
+	case "":
+		return provider, nil
+	case FixtureRecord:
+		if provider == nil {
+			return nil, errors.New("fixture record mode requires an LLM provider")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+		}
+	case FixtureReplay:
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("fixture directory %q not found", dir)
+		}
+		provider = nil
+	default:
+		return nil, fmt.Errorf("unknown fixture mode %q (want %q or %q)", mode, FixtureRecord, FixtureReplay)
+	}
+	return &FixtureProvider{provider: provider, mode: mode, dir: dir}, nil
+}
+
+// Name возвращает название обернутого провайдера или "replay"
+func (f *FixtureProvider) Name() string {
+	if f.provider == nil {
+		return "replay"
+	}
+	return f.provider.Name()
+}
+
+// Chat воспроизводит записанный ответ или выполняет запрос и записывает его
+func (f *FixtureProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if f.mode == FixtureReplay {
+		return f.load(req)
+	}
+	resp, err := f.provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return f.save(req, resp)
+}
+
+// ChatStream передает записанный ответ в onDelta целиком. При записи потоковый и обычный
+// запросы с одинаковыми полями сохраняются в один и тот же файл.
+func (f *FixtureProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	if f.mode == FixtureReplay {
+		resp, err := f.load(req)
+		if err != nil {
+			return nil, err
+		}
+		onDelta(resp.Content)
+		return resp, nil
+	}
+	resp, err := chatStream(ctx, f.provider, req, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	return f.save(req, resp)
+}
+
+func (f *FixtureProvider) path(req ChatRequest) (string, error) {
+	hash, err := fixtureHash(req)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(f.dir, hash+".json"), nil
+}
+
+// load читает записанный ответ на запрос
+func (f *FixtureProvider) load(req ChatRequest) (*ChatResponse, error) {
+	path, err := f.path(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrFixtureNotFound, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fx fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &ChatResponse{
+		Content: fx.Response.Content,
+		Model:   fx.Response.Model,
+		Usage:   Usage{PromptTokens: fx.Response.PromptTokens, CompletionTokens: fx.Response.CompletionTokens},
+	}, nil
+}
+
+// save записывает пару запрос/ответ и возвращает ответ. Ответ, который не удалось записать,
+// считается ошибкой: иначе при воспроизведении запрос окажется без записи.
+func (f *FixtureProvider) save(req ChatRequest, resp *ChatResponse) (*ChatResponse, error) {
+	if err := f.write(req, resp); err != nil {
+		return nil, fmt.Errorf("failed to record LLM response: %w", err)
+	}
+	return resp, nil
+}
+
+// write сохраняет файл записи. Файл заменяется атомарно, поэтому одновременная
+// запись одинаковых запросов не оставляет поврежденных файлов.
+func (f *FixtureProvider) write(req ChatRequest, resp *ChatResponse) error {
+	path, err := f.path(req)
+	if err != nil {
+		return err
+	}
+
+	// HTML в промптах и ответах остается читаемым в файле
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(fixture{
+		Request: newFixtureRequest(req),
+		Response: fixtureResponse{
+			Content:          resp.Content,
+			Model:            resp.Model,
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".fixture-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,183 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+)
+
+// recordFixtures перезаписывает ответы модели в testdata/llm_fixtures провайдером из переменных
+// LLM_PROVIDER, LLM_API_KEY, LLM_BASE_URL и LLM_MODEL:
+//
+//	go test ./internal/translator -run Replay -record
+var recordFixtures = flag.Bool("record", false, "record LLM fixtures with the provider from LLM_* environment variables")
+
+// fixtureDir - ответы модели на demo JSON. Файлы в репозитории синтетические: они готовились
+// без доступа к провайдеру, поэтому написаны вручную в формате ответов модели (model "demo-fixture").
+// Флаг -record заменяет их настоящими ответами.
+var fixtureDir = filepath.Join("..", "..", "testdata", "llm_fixtures")
+
+// fixtureProvider воспроизводит ответы из fixtureDir или, с флагом -record, записывает новые
+func fixtureProvider(t *testing.T) LLMProvider {
+	t.Helper()
+
+	var live LLMProvider
+	mode := FixtureReplay
+	if *recordFixtures {
+		var err error
+		live, err = NewProvider(ProviderConfig{
+			Provider: os.Getenv("LLM_PROVIDER"),
+			APIKey:   os.Getenv("LLM_API_KEY"),
+			BaseURL:  os.Getenv("LLM_BASE_URL"),
+			Model:    os.Getenv("LLM_MODEL"),
+		})
+		if err != nil || live == nil {
+			t.Fatalf("recording requires LLM_PROVIDER and LLM_API_KEY (err: %v)", err)
+		}
+		mode = FixtureRecord
+	}
+
+	provider, err := NewFixtureProvider(live, mode, fixtureDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// replayDemoReport строит отчет по demo JSON на ответах из fixtureDir
+func replayDemoReport(ctx context.Context, t *testing.T, provider LLMProvider) *domain.Report {
+	t.Helper()
+
+	violations, err := loadDemoJSON()
+	if err != nil {
+		t.Fatalf("failed to load demo json: %v", err)
+	}
+	processor := NewProcessor(provider)
+	processor.nodeFixesPerRule = 1
+
+	report, err := processor.ProcessViolations(ctx, "https://bank.example.com", violations, "fixture-job",
+		ProcessOptions{Locale: i18n.Russian})
+	if err != nil {
+		t.Fatalf("ProcessViolations returned error: %v", err)
+	}
+	return report
+}
+
+// TestProcessViolationsReplay проверяет разбор ответов из fixtureDir: JSON в обертке ```json
+// с блоком <think>, повторный запрос для некорректного описания и исправления элементов
+func TestProcessViolationsReplay(t *testing.T) {
+	meter := &usageMeter{}
+	report := replayDemoReport(withUsageMeter(context.Background(), meter), t, fixtureProvider(t))
+
+	if report.Summary.TotalIssues != 10 || report.PromptVersion != builtinPromptVersion {
+		t.Errorf("unexpected report: %+v", report.Summary)
+	}
+
+	fixes := 0
+	for _, issues := range report.IssuesByImpact {
+		for _, issue := range issues {
+			if issue.Confidence <= 0 || issue.Confidence > 1 || issue.AIRejected {
+				t.Errorf("%s: expected AI description, got %+v", issue.ID, issue)
+			}
+			if strings.ContainsAny(issue.Description+issue.HowToFix, "*`") || strings.Contains(issue.Description, "<think>") {
+				t.Errorf("%s: description must be plain text: %q", issue.ID, issue.Description)
+			}
+			for _, fix := range issue.NodeFixes {
+				if fix.FixedHTML == fix.HTML || fix.Explanation == "" || len(fix.Target) == 0 {
+					t.Errorf("%s: unexpected node fix %+v", issue.ID, fix)
+				}
+				fixes++
+			}
+		}
+	}
+	if fixes == 0 {
+		t.Error("expected node fixes from the fixture responses")
+	}
+
+	if usage := meter.total(); usage.Requests < 2 || usage.TotalTokens == 0 {
+		t.Errorf("expected usage from fixture responses, got %+v", usage)
+	}
+}
+
+// TestGenerateSummaryReplay проверяет резюме по ответу из fixtureDir: блок <think> убран,
+// потоковый и обычный запросы используют один файл
+func TestGenerateSummaryReplay(t *testing.T) {
+	provider := fixtureProvider(t)
+	report := replayDemoReport(context.Background(), t, provider)
+	client := NewAIClient(provider)
+	reportJSON, err := SummaryReportJSON(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := client.GenerateSummary(context.Background(), i18n.Russian, string(reportJSON))
+	if err != nil {
+		t.Fatalf("GenerateSummary returned error: %v", err)
+	}
+	if strings.TrimSpace(summary) == "" || strings.Contains(summary, "<think>") || strings.Contains(summary, "**") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+
+	var streamed strings.Builder
+	got, err := client.StreamSummary(context.Background(), i18n.Russian, string(reportJSON), func(text string) { streamed.WriteString(text) })
+	if err != nil {
+		t.Fatalf("StreamSummary returned error: %v", err)
+	}
+	if got != summary || streamed.String() != summary {
+		t.Errorf("streamed summary differs from the fixture one:\n%s", got)
+	}
+}
+
+// TestFixtureProviderRecordReplay проверяет запись ответов и поиск по хэшу запроса
+func TestFixtureProviderRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewFixtureProvider(&nodeFixProvider{}, FixtureRecord, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := ChatRequest{System: "system", Messages: []Message{{Role: "user", Content: `<img src="a">`}}, MaxTokens: 100}
+	recorded, err := recorder.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one fixture file, got %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); !strings.Contains(string(data), `<img src=\"a\">`) {
+		t.Errorf("fixture must keep HTML readable:\n%s", data)
+	}
+
+	replay, err := NewFixtureProvider(nil, FixtureReplay, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := replay.Chat(context.Background(), req)
+	if err != nil || resp.Content != recorded.Content || resp.Model != recorded.Model {
+		t.Errorf("expected recorded response, got %+v, %v", resp, err)
+	}
+
+	// Любое изменение запроса дает другой хэш
+	req.MaxTokens = 200
+	if _, err := replay.Chat(context.Background(), req); !errors.Is(err, ErrFixtureNotFound) {
+		t.Errorf("expected ErrFixtureNotFound, got %v", err)
+	}
+
+	if _, err := NewFixtureProvider(nil, FixtureRecord, dir); err == nil {
+		t.Error("record mode without provider must fail")
+	}
+	if _, err := NewFixtureProvider(nil, FixtureReplay, filepath.Join(dir, "missing")); err == nil {
+		t.Error("replay mode without directory must fail")
+	}
+	if _, err := NewFixtureProvider(nil, "playback", dir); err == nil {
+		t.Error("unknown mode must fail")
+	}
+}
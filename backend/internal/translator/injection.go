@@ -0,0 +1,112 @@
+package translator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Разделители данных страницы в промпте. Системная инструкция объявляет содержимое
+// между ними данными, а не инструкциями.
+const (
+	untrustedOpen  = "<untrusted_data>"
+	untrustedClose = "</untrusted_data>"
+)
+
+// removedInstruction заменяет в данных страницы фразы, похожие на инструкции модели
+const removedInstruction = "[removed]"
+
+// instructionPhrases - фразы, которыми страница может попытаться переопределить инструкции.
+// Пробелы допускаются и в виде \n, а угловые скобки - в виде \u003c и \u003e из JSON,
+// в котором данные попадают в промпт.
+var instructionPhrases = []string{
+	`ignore\s+(?:all\s+|any\s+)?(?:the\s+)?(?:previous|prior|above|earlier|preceding|system)\s+(?:instructions?|prompts?|messages?|rules)`,
+	`disregard\s+(?:all\s+|any\s+)?(?:the\s+)?(?:previous|prior|above|earlier|system)\s+(?:instructions?|prompts?|messages?|rules)`,
+	`forget\s+(?:all\s+|everything\s+)?(?:the\s+|your\s+)?(?:previous\s+|prior\s+)?(?:instructions?|prompts?|rules)`,
+	`you\s+are\s+now\b`,
+	`new\s+instructions?\s*:`,
+	`\b(?:system|assistant|developer)\s*(?:prompt\s*)?:`,
+	`<\|(?:im_start|im_end|system|endoftext)\|>`,
+	`\[/?INST\]`,
+	`<</?SYS>>`,
+	`игнорируй\s+(?:все\s+)?(?:предыдущие|прошлые|вышеуказанные|системные)\s+(?:инструкции|указания|правила|сообщения)`,
+	`забудь\s+(?:все\s+)?(?:предыдущие\s+|прошлые\s+)?(?:инструкции|указания|правила)`,
+	`ты\s+теперь`,
+	`новые\s+инструкции\s*:`,
+}
+
+// jsonEscapes допускает в шаблонах варианты символов, экранированные в JSON
+var jsonEscapes = strings.NewReplacer(`\s+`, `(?:\s|\\[nrt])+`, `<`, `(?:<|\\u003c)`, `>`, `(?:>|\\u003e)`)
+
+var (
+	instructionPattern = regexp.MustCompile(`(?i)(?:` + jsonEscapes.Replace(strings.Join(instructionPhrases, "|")) + `)`)
+	delimiterPattern   = regexp.MustCompile(`(?i)` + jsonEscapes.Replace(`<\s*/?\s*untrusted_data\s*>`))
+
+	// Признаки ответа, который следует инструкциям страницы, а не описывает проблему.
+	// linkPattern выделяет из ссылки часть с хостом.
+	linkPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)([^\s/?#<>"'()\[\]]*)`)
+	scriptPattern = regexp.MustCompile(`(?i)<script\b|javascript:`)
+)
+
+// docHosts - сайты документации по доступности, на которые модель может ссылаться в ответе.
+// Поддомены тоже разрешены.
+var docHosts = []string{"w3.org", "dequeuniversity.com", "developer.mozilla.org"}
+
+// untrusted готовит данные страницы для промпта: заменяет фразы, похожие на инструкции,
+// убирает поддельные разделители и помещает данные между untrustedOpen и untrustedClose
+func untrusted(content string) string {
+	content = instructionPattern.ReplaceAllString(content, removedInstruction)
+	content = delimiterPattern.ReplaceAllString(content, "")
+	return untrustedOpen + "\n" + content + "\n" + untrustedClose
+}
+
+// unsafeText возвращает ключ i18n с причиной, по которой текст ответа модели нельзя
+// показывать в отчете, или пустую строку
+func unsafeText(text string) string {
+	switch {
+	case instructionPattern.MatchString(text):
+		return "validation.unsafe.instructions"
+	case unknownLink(text):
+		return "validation.unsafe.links"
+	}
+	return ""
+}
+
+// unknownLink сообщает, что в тексте есть ссылка на сайт не из docHosts
+func unknownLink(text string) bool {
+	for _, match := range linkPattern.FindAllStringSubmatch(text, -1) {
+		if !docHost(match[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// docHost сообщает, что authority (хост с портом) ссылки относится к сайту из docHosts.
+// Ссылки с именем пользователя (https://w3.org@example.com) не принимаются.
+func docHost(authority string) bool {
+	host := strings.ToLower(strings.TrimRight(authority, ".,;:!"))
+	if strings.Contains(host, "@") {
+		return false
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	for _, allowed := range docHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsafeCode возвращает ключ i18n с причиной, по которой пример кода нельзя показывать
+// в отчете, или пустую строку
+func unsafeCode(code string) string {
+	switch {
+	case scriptPattern.MatchString(code):
+		return "validation.unsafe.code_script"
+	case instructionPattern.MatchString(code):
+		return "validation.unsafe.code_instructions"
+	}
+	return ""
+}
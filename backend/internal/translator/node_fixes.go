@@ -0,0 +1,180 @@
+package translator
+
+import (
+	"encoding/json"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+)
+
+// nodeFixBatchSize - сколько элементов отправляется в AI одним запросом
+const nodeFixBatchSize = 10
+
+// nodeFixItem - элемент страницы в запросе исправлений
+type nodeFixItem struct {
+	ID             string `json:"id"`
+	RuleID         string `json:"rule_id"`
+	Help           string `json:"help"`
+	FailureSummary string `json:"failure_summary,omitempty"`
+	HTML           string `json:"html"`
+
+	// violation - индекс нарушения в анализе, target - селектор элемента
+	violation int
+	target    []string
+}
+
+// nodeFix - исправление элемента в ответе модели
+type nodeFix struct {
+	ID          string `json:"id"`
+	FixedHTML   string `json:"fixed_html"`
+	Explanation string `json:"explanation"`
+}
+
+// nodeFixResponse - ответ модели на запрос исправлений элементов
+type nodeFixResponse struct {
+	Items []nodeFix `json:"items"`
+}
+
+// nodeFixSchema - JSON-схема ответа на запрос исправлений элементов
+var nodeFixSchema = &JSONSchema{
+	Name: "element_fixes",
+	Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "items": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "fixed_html": {"type": "string"},
+          "explanation": {"type": "string"}
+        },
+        "required": ["id", "fixed_html", "explanation"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["items"],
+  "additionalProperties": false
+}`),
+}
+
+// parseNodeFixes разбирает ответ модели и возвращает исправления по ID элемента.
+// Исправления неизвестных элементов, повторные, пустые и совпадающие с исходным HTML отбрасываются,
+// как и исправления, которые не содержат исходный элемент или не прошли проверку безопасности.
+func parseNodeFixes(content string, items []nodeFixItem) map[string]*nodeFix {
+	fixes := make(map[string]*nodeFix)
+
+	var resp nodeFixResponse
+	if err := json.Unmarshal([]byte(extractJSON(content)), &resp); err != nil {
+		return fixes
+	}
+
+	original := make(map[string]string, len(items))
+	for _, item := range items {
+		original[item.ID] = item.HTML
+	}
+
+	for i := range resp.Items {
+		fix := resp.Items[i]
+		fix.ID = strings.TrimSpace(fix.ID)
+		fix.FixedHTML = strings.TrimSpace(fix.FixedHTML)
+		fix.Explanation = strings.TrimSpace(fix.Explanation)
+
+		html, known := original[fix.ID]
+		if !known || fixes[fix.ID] != nil || fix.FixedHTML == "" || fix.Explanation == "" || fix.FixedHTML == html {
+			continue
+		}
+		if !keepsElement(html, fix.FixedHTML) {
+			log.Printf("[AI] Fix for element %s rejected: it does not contain the original element", fix.ID)
+			continue
+		}
+		reason := unsafeCode(fix.FixedHTML)
+		if reason == "" {
+			reason = unsafeText(fix.Explanation)
+		}
+		if reason != "" {
+			log.Printf("[AI] Fix for element %s rejected: %s", fix.ID, i18n.T(i18n.English, reason))
+			continue
+		}
+		fixes[fix.ID] = &fix
+	}
+	return fixes
+}
+
+// tagNamePattern находит имя первого тега в HTML элемента
+var tagNamePattern = regexp.MustCompile(`^\s*<([A-Za-z][A-Za-z0-9-]*)`)
+
+// keepsElement проверяет, что исправление относится к исходному элементу:
+// тег элемента должен остаться в исправленном HTML (например, внутри добавленного <label>)
+func keepsElement(html, fixed string) bool {
+	match := tagNamePattern.FindStringSubmatch(html)
+	if match == nil {
+		return true
+	}
+	fixed = strings.ToLower(fixed)
+	open := "<" + strings.ToLower(match[1])
+	for {
+		i := strings.Index(fixed, open)
+		if i < 0 {
+			return false
+		}
+		fixed = fixed[i+len(open):]
+		// Имя тега должно закончиться: <a подходит для <a href>, но не для <abbr>
+		if fixed == "" || !isTagNameChar(fixed[0]) {
+			return true
+		}
+	}
+}
+
+// isTagNameChar сообщает, что символ может продолжать имя тега
+func isTagNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// sampleNodes выбирает элементы для исправления: не больше perRule элементов с различным HTML
+// на правило и не больше limit всего (0 - без общего ограничения). При общем ограничении
+// первыми идут нарушения с более высоким уровнем важности.
+func sampleNodes(violations []domain.AxeViolation, perRule, limit int) []nodeFixItem {
+	order := make([]int, len(violations))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return domain.ImpactRank(violations[order[a]].Impact) < domain.ImpactRank(violations[order[b]].Impact)
+	})
+
+	var items []nodeFixItem
+	for _, index := range order {
+		v := violations[index]
+		seen := make(map[string]bool)
+		for _, node := range v.Nodes {
+			if len(seen) >= perRule || (limit > 0 && len(items) >= limit) {
+				break
+			}
+			// Одинаковые элементы (например, повторяющиеся иконки) исправляются одинаково
+			html := strings.TrimSpace(node.HTML)
+			if html == "" || seen[html] {
+				continue
+			}
+			seen[html] = true
+
+			items = append(items, nodeFixItem{
+				ID:             strconv.Itoa(len(items) + 1),
+				RuleID:         v.ID,
+				Help:           v.Help,
+				FailureSummary: node.FailureSummary,
+				HTML:           html,
+				violation:      index,
+				target:         node.Target,
+			})
+		}
+	}
+	return items
+}
@@ -0,0 +1,174 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// TestSampleNodes проверяет лимиты выборки элементов и приоритет по уровню важности
+func TestSampleNodes(t *testing.T) {
+	violations := []domain.AxeViolation{
+		{ID: "region", Impact: "minor", Nodes: []domain.AxeNode{{HTML: "<div>", Target: []string{"div"}}}},
+		{ID: "image-alt", Impact: "critical", Nodes: []domain.AxeNode{
+			{HTML: `<img src="a">`, Target: []string{"#a"}},
+			{HTML: `<img src="a">`, Target: []string{"#a2"}},
+			{HTML: " ", Target: []string{"#empty"}},
+			{HTML: `<img src="b">`, Target: []string{"#b"}},
+			{HTML: `<img src="c">`, Target: []string{"#c"}},
+		}},
+	}
+
+	items := sampleNodes(violations, 2, 0)
+	var targets []string
+	for _, item := range items {
+		targets = append(targets, item.RuleID+" "+strings.Join(item.target, " "))
+	}
+	if got := strings.Join(targets, ", "); got != "image-alt #a, image-alt #b, region div" {
+		t.Errorf("unexpected sample: %s", got)
+	}
+	if items[0].ID != "1" || items[0].violation != 1 || items[2].violation != 0 {
+		t.Errorf("unexpected item references: %+v", items)
+	}
+
+	if items := sampleNodes(violations, 2, 2); len(items) != 2 || items[1].RuleID != "image-alt" {
+		t.Errorf("expected limit to keep critical nodes, got %+v", items)
+	}
+}
+
+// TestParseNodeFixes проверяет отбрасывание некорректных исправлений
+func TestParseNodeFixes(t *testing.T) {
+	items := []nodeFixItem{{ID: "1", HTML: `<img src="a">`}, {ID: "2", HTML: "<button></button>"}, {ID: "3", HTML: "<div>"}}
+	content := "```json\n" + `{"items": [
+		{"id": "1", "fixed_html": "<img src=\"a\" alt=\"Логотип\">", "explanation": "Добавлен alt"},
+		{"id": "1", "fixed_html": "<img>", "explanation": "Повтор"},
+		{"id": "2", "fixed_html": "<button></button>", "explanation": "Без изменений"},
+		{"id": "3", "fixed_html": "", "explanation": "Пусто"},
+		{"id": "9", "fixed_html": "<p>", "explanation": "Неизвестный"}
+	]}` + "\n```"
+
+	fixes := parseNodeFixes(content, items)
+	if len(fixes) != 1 || fixes["1"] == nil || fixes["1"].FixedHTML != `<img src="a" alt="Логотип">` {
+		t.Errorf("expected only fix for element 1, got %+v", fixes)
+	}
+
+	// Исправления, не содержащие исходный элемент или со скриптами и ссылками, отбрасываются
+	unsafe := `{"items": [
+		{"id": "1", "fixed_html": "<p>Сайт доступен</p>", "explanation": "Заменено"},
+		{"id": "2", "fixed_html": "<button onclick=\"javascript:go()\">OK</button>", "explanation": "Добавлен текст"},
+		{"id": "3", "fixed_html": "<div role=\"main\">", "explanation": "Подробнее на https://evil.example.com"}
+	]}`
+	if fixes := parseNodeFixes(unsafe, items); len(fixes) != 0 {
+		t.Errorf("expected unsafe fixes to be rejected, got %+v", fixes)
+	}
+	if fixes := parseNodeFixes("не JSON", items); len(fixes) != 0 {
+		t.Errorf("expected no fixes for non-JSON response, got %+v", fixes)
+	}
+}
+
+// TestKeepsElement проверяет, что тег исходного элемента ищется без учета регистра и целиком
+func TestKeepsElement(t *testing.T) {
+	cases := []struct {
+		html, fixed string
+		want        bool
+	}{
+		{`<a href="/">`, `<a href="/">Главная</a>`, true},
+		{`<input id="q">`, `<label>Поиск <INPUT id="q"></label>`, true},
+		{`<a href="/">`, `<abbr title="x">x</abbr> <a>`, true},
+		{`<a href="/">`, `<abbr title="x">x</abbr>`, false},
+		{`<my-button>`, `<my-button-group>`, false},
+		{`<img src="a">`, `<img`, true},
+		{`текст без тега`, `<p>`, true},
+	}
+	for _, c := range cases {
+		if got := keepsElement(c.html, c.fixed); got != c.want {
+			t.Errorf("keepsElement(%q, %q) = %v, want %v", c.html, c.fixed, got, c.want)
+		}
+	}
+}
+
+// nodeFixProvider исправляет элементы из промпта, кроме skipID, и не дает описаний правил
+type nodeFixProvider struct {
+	skipID string
+
+	mu       sync.Mutex
+	nodeReqs int
+}
+
+func (p *nodeFixProvider) Name() string { return "node-fix" }
+
+func (p *nodeFixProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if req.Schema != nodeFixSchema {
+		return &ChatResponse{Content: `{"items": []}`, Model: "node-fix"}, nil
+	}
+
+	p.mu.Lock()
+	p.nodeReqs++
+	p.mu.Unlock()
+
+	prompt := req.Messages[0].Content
+	prompt = prompt[strings.LastIndex(prompt, "Элементы:"):]
+	var items []nodeFixItem
+	json.Unmarshal([]byte(prompt[strings.Index(prompt, "["):strings.LastIndex(prompt, "]")+1]), &items)
+
+	var resp nodeFixResponse
+	for _, item := range items {
+		if item.ID != p.skipID {
+			resp.Items = append(resp.Items, nodeFix{ID: item.ID, FixedHTML: item.HTML + " fixed", Explanation: "**Исправлено** " + item.RuleID})
+		}
+	}
+	content, _ := json.Marshal(resp)
+	return &ChatResponse{Content: string(content), Model: "node-fix"}, nil
+}
+
+// TestProcessViolationsNodeFixes проверяет исправления элементов в отчете
+func TestProcessViolationsNodeFixes(t *testing.T) {
+	violations := []domain.AxeViolation{
+		{ID: "image-alt", Impact: "critical", Help: "Images must have alternate text", Nodes: []domain.AxeNode{
+			{HTML: `<img src="a">`, Target: []string{"#a"}},
+			{HTML: `<img src="b">`, Target: []string{"#b"}},
+			{HTML: `<img src="c">`, Target: []string{"#c"}},
+		}},
+		{ID: "label", Impact: "serious", Help: "Form elements must have labels", Nodes: []domain.AxeNode{
+			{HTML: `<input id="q">`, Target: []string{"form", "#q"}},
+		}},
+	}
+
+	// По умолчанию исправления отдельных элементов не запрашиваются
+	provider := &nodeFixProvider{}
+	report, err := NewProcessor(provider).ProcessViolations(context.Background(), "https://example.com", violations, "job", ProcessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.nodeReqs != 0 || report.IssuesByImpact["critical"][0].NodeFixes != nil {
+		t.Fatalf("node fixes should be disabled by default")
+	}
+
+	provider = &nodeFixProvider{skipID: "2"}
+	processor := NewProcessor(provider)
+	processor.nodeFixesPerRule = 2
+	report, err = processor.ProcessViolations(context.Background(), "https://example.com", violations, "job", ProcessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imageFixes := report.IssuesByImpact["critical"][0].NodeFixes
+	if len(imageFixes) != 1 {
+		t.Fatalf("expected one fix for image-alt (second node skipped by model, third over limit), got %+v", imageFixes)
+	}
+	if fix := imageFixes[0]; fix.Target[0] != "#a" || fix.HTML != `<img src="a">` || fix.FixedHTML != `<img src="a"> fixed` || fix.Explanation != "Исправлено image-alt" {
+		t.Errorf("unexpected fix: %+v", fix)
+	}
+
+	labelFixes := report.IssuesByImpact["serious"][0].NodeFixes
+	if len(labelFixes) != 1 || strings.Join(labelFixes[0].Target, " ") != "form #q" {
+		t.Errorf("unexpected label fixes: %+v", labelFixes)
+	}
+	if examples := report.IssuesByImpact["critical"][0].Examples; len(examples) != 3 {
+		t.Errorf("examples should be kept, got %v", examples)
+	}
+}
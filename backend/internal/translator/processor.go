@@ -1,31 +1,73 @@
 package translator
 
 import (
+	"context"
 	"fmt"
-
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
 )
 
 // Processor обрабатывает результаты axe-core
 type Processor struct {
 	aiClient *AIClient
+	// concurrency - сколько батчей одного анализа отправляется в AI одновременно
+	concurrency int
+	// nodeFixesPerRule - для скольких элементов каждого правила запрашивать исправленный HTML
+	// (0 - исправления отдельных элементов отключены)
+	nodeFixesPerRule int
+	// nodeFixesMax - общий лимит таких элементов на анализ (0 - без ограничения)
+	nodeFixesMax int
 }
 
-// NewProcessor создает новый процессор
-func NewProcessor(aiClient *AIClient) *Processor {
+// NewProcessor создает новый процессор. Если provider равен nil, используются демо-описания.
+func NewProcessor(provider LLMProvider) *Processor {
 	return &Processor{
-		aiClient: aiClient,
+		aiClient:    NewAIClient(provider),
+		concurrency: 1,
 	}
 }
 
-// ProcessViolations обрабатывает нарушения и создает отчет
-func (p *Processor) ProcessViolations(url string, violations []domain.AxeViolation, jobID string) (*domain.Report, error) {
+// BatchProgress описывает ход обработки нарушений после очередного батча
+type BatchProgress struct {
+	// Batch - сколько батчей уже обработано
+	Batch int `json:"batch"`
+	// TotalBatches - общее число батчей
+	TotalBatches int `json:"total_batches"`
+	// ProcessedIssues - сколько нарушений уже обработано
+	ProcessedIssues int `json:"processed_issues"`
+	// EnrichedIssues - сколько из них получили описание от AI
+	EnrichedIssues int `json:"enriched_issues"`
+	// TotalIssues - общее число нарушений
+	TotalIssues int `json:"total_issues"`
+}
+
+// ProgressFunc вызывается после обработки каждого батча. Вызовы не пересекаются,
+// но при параллельной обработке батчи завершаются в произвольном порядке.
+type ProgressFunc func(progress BatchProgress)
+
+// ProcessOptions содержит необязательные параметры обработки
+type ProcessOptions struct {
+	// OnProgress получает ход обработки по батчам
+	OnProgress ProgressFunc
+	// Locale - язык отчета и описаний от AI (пустой - i18n.Default)
+	Locale string
+}
+
+// ProcessViolations обрабатывает нарушения и создает отчет.
+// При отмене ctx обработка прерывается и возвращается ctx.Err().
+func (p *Processor) ProcessViolations(ctx context.Context, url string, violations []domain.AxeViolation, jobID string, opts ProcessOptions) (*domain.Report, error) {
 	report := &domain.Report{
 		ID:             jobID,
 		URL:            url,
+		Revision:       1,
+		Locale:         i18n.Resolve(opts.Locale),
+		PromptVersion:  p.aiClient.prompts.Version(),
+		CreatedAt:      time.Now(),
 		IssuesByImpact: make(map[string][]domain.Issue),
 		Summary: domain.ReportSummary{
 			ImpactScores: make(map[string]int),
@@ -33,36 +75,32 @@ func (p *Processor) ProcessViolations(url string, violations []domain.AxeViolati
 	}
 
 	// Группируем проблемы по уровню важности
-	impactLevels := []string{"critical", "serious", "moderate", "minor"}
-	for _, level := range impactLevels {
+	for _, level := range domain.ImpactLevels {
 		report.IssuesByImpact[level] = []domain.Issue{}
 	}
 
 	// Батчинг: группируем нарушения по 10 штук для обработки AI
 	batchSize := 10
 	batches := p.createBatches(violations, batchSize)
+	// Батчи отправляются в AI параллельно (не больше p.concurrency одновременно),
+	// а проблемы добавляются в отчет в исходном порядке
+	enrichments, err := p.enrichBatches(ctx, report.Locale, batches, len(violations), opts.OnProgress)
+	if err != nil {
+		return nil, err
+	}
+	nodeFixes, err := p.fixNodes(ctx, report.Locale, violations)
+	if err != nil {
+		return nil, err
+	}
 
-	// Обрабатываем каждый батч (максимум 5 запросов к AI для типичного сайта до 50 нарушений)
-	for _, batch := range batches {
-		// Готовим промпты для батчевой обработки в AI
-		prompts := make([]string, len(batch))
+	index := 0
+	for batchIndex, batch := range batches {
 		for i, violation := range batch {
-			prompts[i] = fmt.Sprintf("Проблема: %s - %s", violation.ID, violation.Help)
-		}
-
-		// Отправляем батч в AI для получения улучшенных описаний (1 запрос вместо 10)
-		aiDescriptions, err := p.aiClient.TranslateBatch(prompts)
-		if err != nil {
-			// Если AI недоступен, используем базовые переводы
-			aiDescriptions = make([]string, len(batch))
-			for i := range aiDescriptions {
-				aiDescriptions[i] = ""
+			issue := p.convertViolationToIssueWithAI(report.Locale, violation, enrichments[batchIndex][i])
+			if nodeFixes != nil {
+				issue.NodeFixes = nodeFixes[index]
 			}
-		}
-
-		// Обрабатываем нарушения из батча
-		for i, violation := range batch {
-			issue := p.convertViolationToIssueWithAI(violation, aiDescriptions[i])
+			index++
 
 			// Добавляем в соответствующую группу
 			if _, exists := report.IssuesByImpact[violation.Impact]; !exists {
@@ -93,6 +131,133 @@ func (p *Processor) ProcessViolations(url string, violations []domain.AxeViolati
 	return report, nil
 }
 
+// enrichBatches получает AI-описания для всех батчей. Результат выровнен по batches.
+// onProgress вызывается последовательно по мере завершения батчей.
+func (p *Processor) enrichBatches(ctx context.Context, locale string, batches [][]domain.AxeViolation, total int, onProgress ProgressFunc) ([][]*Enrichment, error) {
+	results := make([][]*Enrichment, len(batches))
+	progress := BatchProgress{TotalBatches: len(batches), TotalIssues: total}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, p.concurrency)
+	)
+	for batchIndex, batch := range batches {
+		// Новые батчи не запускаем после отмены задачи
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(batchIndex int, batch []domain.AxeViolation) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Отправляем батч в AI для получения улучшенных описаний (1 запрос вместо 10)
+			enrichments, err := p.aiClient.EnrichBatch(ctx, locale, batch)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && enrichments == nil {
+				// Если AI недоступен и кэш не помог, используем базовые переводы для всего батча
+				enrichments = make([]*Enrichment, len(batch))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[batchIndex] = enrichments
+			progress.Batch++
+			progress.ProcessedIssues += len(batch)
+			for _, enrichment := range enrichments {
+				if enrichment != nil && !enrichment.Rejected {
+					progress.EnrichedIssues++
+				}
+			}
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		}(batchIndex, batch)
+	}
+	wg.Wait()
+
+	// Задачу отменили: не подменяем результат базовыми переводами
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// fixNodes запрашивает исправленный HTML для выборки элементов, если исправления включены.
+// Результат выровнен по violations. Ошибка AI не прерывает анализ: элементы батча
+// остаются без исправлений.
+func (p *Processor) fixNodes(ctx context.Context, locale string, violations []domain.AxeViolation) ([][]domain.NodeFix, error) {
+	if p.nodeFixesPerRule <= 0 || p.aiClient.provider == nil {
+		return nil, nil
+	}
+	items := sampleNodes(violations, p.nodeFixesPerRule, p.nodeFixesMax)
+
+	var (
+		fixes = make(map[string]*nodeFix, len(items))
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, p.concurrency)
+	)
+	for start := 0; start < len(items); start += nodeFixBatchSize {
+		end := start + nodeFixBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(batch []nodeFixItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			batchFixes, err := p.aiClient.FixNodes(ctx, locale, batch)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for id, fix := range batchFixes {
+				fixes[id] = fix
+			}
+		}(items[start:end])
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([][]domain.NodeFix, len(violations))
+	for _, item := range items {
+		fix := fixes[item.ID]
+		if fix == nil {
+			continue
+		}
+		results[item.violation] = append(results[item.violation], domain.NodeFix{
+			Target:      item.target,
+			HTML:        item.HTML,
+			FixedHTML:   fix.FixedHTML,
+			Explanation: p.cleanFormatting(fix.Explanation),
+		})
+	}
+	return results, nil
+}
+
 // createBatches разбивает нарушения на батчи для оптимизации запросов к AI
 func (p *Processor) createBatches(violations []domain.AxeViolation, batchSize int) [][]domain.AxeViolation {
 	var batches [][]domain.AxeViolation
@@ -109,7 +274,7 @@ func (p *Processor) createBatches(violations []domain.AxeViolation, batchSize in
 }
 
 // convertViolationToIssue конвертирует нарушение в проблему
-func (p *Processor) convertViolationToIssue(violation domain.AxeViolation) domain.Issue {
+func (p *Processor) convertViolationToIssue(locale string, violation domain.AxeViolation) domain.Issue {
 	// Собираем примеры HTML
 	examples := []string{}
 	for i, node := range violation.Nodes {
@@ -119,12 +284,12 @@ func (p *Processor) convertViolationToIssue(violation domain.AxeViolation) domai
 		examples = append(examples, node.HTML)
 	}
 
-	// Генерируем описание на русском
-	description := p.translateDescription(violation)
-	howToFix := p.generateHowToFix(violation)
+	// Генерируем описание на языке отчета
+	description := p.translateDescription(locale, violation)
+	howToFix := p.generateHowToFix(locale, violation)
 
-	// Переводим заголовок на русский
-	title := p.translateTitle(violation.Help, violation.ID)
+	// Переводим заголовок на язык отчета
+	title := p.translateTitle(locale, violation.Help, violation.ID)
 
 	return domain.Issue{
 		ID:               violation.ID,
@@ -139,8 +304,9 @@ func (p *Processor) convertViolationToIssue(violation domain.AxeViolation) domai
 	}
 }
 
-// convertViolationToIssueWithAI конвертирует нарушение в проблему с использованием AI-описания
-func (p *Processor) convertViolationToIssueWithAI(violation domain.AxeViolation, aiDescription string) domain.Issue {
+// convertViolationToIssueWithAI конвертирует нарушение в проблему с использованием AI-описания.
+// Если enrichment равен nil, отклонен проверкой безопасности или его поля пустые, используется базовый перевод.
+func (p *Processor) convertViolationToIssueWithAI(locale string, violation domain.AxeViolation, enrichment *Enrichment) domain.Issue {
 	// Собираем примеры HTML
 	examples := []string{}
 	for i, node := range violation.Nodes {
@@ -151,23 +317,26 @@ func (p *Processor) convertViolationToIssueWithAI(violation domain.AxeViolation,
 	}
 
 	// Используем AI-описание, если доступно, иначе базовый перевод
-	description := p.translateDescription(violation)
-	howToFix := p.generateHowToFix(violation)
-
-	if aiDescription != "" {
-		// AI вернул описание, парсим его на description и howToFix
-		parts := strings.Split(aiDescription, "Решение:")
-		if len(parts) == 2 {
-			description = p.cleanFormatting(strings.TrimSpace(parts[0]))
-			howToFix = p.cleanFormatting(strings.TrimSpace(parts[1]))
-		} else {
-			// Если AI вернул просто текст, используем его как описание
-			description = p.cleanFormatting(aiDescription)
+	description := p.translateDescription(locale, violation)
+	howToFix := p.generateHowToFix(locale, violation)
+
+	var codeExample string
+	var confidence float64
+	rejected := enrichment != nil && enrichment.Rejected
+	if enrichment != nil && !rejected {
+		if enrichment.Description != "" {
+			description = p.cleanFormatting(enrichment.Description)
+		}
+		if enrichment.HowToFix != "" {
+			howToFix = p.cleanFormatting(enrichment.HowToFix)
 		}
+		// Код не очищаем: в нем допустимы символы разметки
+		codeExample = enrichment.CodeExample
+		confidence = enrichment.Confidence
 	}
 
-	// Переводим заголовок на русский
-	title := p.translateTitle(violation.Help, violation.ID)
+	// Переводим заголовок на язык отчета
+	title := p.translateTitle(locale, violation.Help, violation.ID)
 
 	return domain.Issue{
 		ID:               violation.ID,
@@ -179,56 +348,15 @@ func (p *Processor) convertViolationToIssueWithAI(violation domain.AxeViolation,
 		Tags:             violation.Tags,
 		HelpURL:          violation.HelpURL,
 		Examples:         examples,
+		CodeExample:      codeExample,
+		Confidence:       confidence,
+		AIRejected:       rejected,
 	}
 }
 
-// translateTitle переводит заголовок проблемы на русский язык
-func (p *Processor) translateTitle(title string, violationID string) string {
-	// Прямой перевод по ID нарушения
-	titleTranslations := map[string]string{
-		"aria-hidden-focus":           "ARIA-скрытые элементы не должны получать фокус",
-		"button-name":                 "Кнопки должны иметь понятный текст",
-		"color-contrast":              "Недостаточный контраст цвета",
-		"image-alt":                   "Изображения должны иметь альтернативный текст",
-		"label":                       "Элементы формы должны иметь метки",
-		"link-name":                   "Ссылки должны иметь понятный текст",
-		"html-has-lang":               "HTML-элемент должен иметь атрибут lang",
-		"valid-lang":                  "Атрибут lang должен содержать корректное значение",
-		"document-title":              "Документ должен иметь заголовок",
-		"landmark-one-main":           "Страница должна содержать один главный landmark",
-		"region":                      "Контент должен быть в landmark-регионах",
-		"page-has-heading-one":        "Страница должна содержать заголовок первого уровня",
-		"bypass":                      "Страница должна иметь возможность пропуска повторяющегося контента",
-		"heading-order":               "Заголовки должны следовать в правильном порядке",
-		"list":                        "Списки должны содержать только элементы li",
-		"listitem":                    "Элементы списка должны быть внутри ul или ol",
-		"definition-list":             "Списки определений должны быть правильно структурированы",
-		"dlitem":                      "Элементы списка определений должны быть внутри dl",
-		"duplicate-id":                "ID элементов должны быть уникальными",
-		"duplicate-id-active":         "ID активных элементов должны быть уникальными",
-		"duplicate-id-aria":           "ID элементов в ARIA должны быть уникальными",
-		"form-field-multiple-labels":  "Поля формы не должны иметь несколько меток",
-		"frame-title":                 "Фреймы должны иметь заголовок",
-		"input-image-alt":             "Кнопки-изображения должны иметь альтернативный текст",
-		"meta-refresh":                "Не используйте meta refresh",
-		"meta-viewport":               "Meta viewport не должен запрещать масштабирование",
-		"object-alt":                  "Object-элементы должны иметь альтернативный текст",
-		"role-img-alt":                "Элементы с role=img должны иметь альтернативный текст",
-		"scrollable-region-focusable": "Прокручиваемые области должны быть фокусируемыми",
-		"select-name":                 "Select-элементы должны иметь доступное имя",
-		"server-side-image-map":       "Серверные карты изображений не рекомендуются",
-		"svg-img-alt":                 "SVG-элементы с role=img должны иметь альтернативный текст",
-		"td-headers-attr":             "Ячейки таблицы с атрибутом headers должны ссылаться на существующие ячейки",
-		"th-has-data-cells":           "Заголовки таблицы должны иметь связанные ячейки данных",
-		"valid-aria-role":             "ARIA role должен быть корректным",
-		"video-caption":               "Видео должно иметь субтитры",
-		"aria-allowed-attr":           "ARIA-атрибуты должны быть разрешены для данной роли",
-		"aria-required-attr":          "Обязательные ARIA-атрибуты должны присутствовать",
-		"aria-valid-attr":             "ARIA-атрибуты должны быть корректными",
-		"aria-valid-attr-value":       "Значения ARIA-атрибутов должны быть корректными",
-	}
-
-	if translated, exists := titleTranslations[violationID]; exists {
+// translateTitle переводит заголовок проблемы на язык отчета
+func (p *Processor) translateTitle(locale, title, violationID string) string {
+	if translated, exists := i18n.RuleTitle(locale, violationID); exists {
 		return translated
 	}
 
@@ -255,18 +383,8 @@ func (p *Processor) cleanFormatting(text string) string {
 }
 
 // translateDescription переводит описание проблемы
-func (p *Processor) translateDescription(violation domain.AxeViolation) string {
-	// Базовые переводы для популярных проблем
-	translations := map[string]string{
-		"aria-hidden-focus": "Элементы со скрытым ARIA не должны получать фокус или содержать элементы с фокусом",
-		"button-name":       "Кнопки должны иметь понятный текст",
-		"color-contrast":    "Текст должен иметь достаточный контраст с фоном",
-		"image-alt":         "Изображения должны иметь альтернативный текст",
-		"label":             "Поля форм должны иметь метки",
-		"link-name":         "Ссылки должны иметь понятный текст",
-	}
-
-	if translated, exists := translations[violation.ID]; exists {
+func (p *Processor) translateDescription(locale string, violation domain.AxeViolation) string {
+	if translated, exists := i18n.RuleDescription(locale, violation.ID); exists {
 		return p.cleanFormatting(translated)
 	}
 
@@ -274,17 +392,8 @@ func (p *Processor) translateDescription(violation domain.AxeViolation) string {
 }
 
 // generateHowToFix генерирует рекомендации по исправлению
-func (p *Processor) generateHowToFix(violation domain.AxeViolation) string {
-	fixes := map[string]string{
-		"aria-hidden-focus": "Добавьте tabindex=\"-1\" к элементам с aria-hidden=\"true\" или удалите их из DOM.",
-		"button-name":       "Добавьте текст внутрь кнопки или используйте aria-label для описания действия.",
-		"color-contrast":    "Увеличьте контраст между текстом и фоном до соотношения минимум 4.5:1 для обычного текста.",
-		"image-alt":         "Добавьте атрибут alt с описанием содержимого изображения.",
-		"label":             "Добавьте элемент <label> с атрибутом for или оберните поле в <label>.",
-		"link-name":         "Добавьте понятный текст в ссылку или используйте aria-label.",
-	}
-
-	if fix, exists := fixes[violation.ID]; exists {
+func (p *Processor) generateHowToFix(locale string, violation domain.AxeViolation) string {
+	if fix, exists := i18n.RuleFix(locale, violation.ID); exists {
 		return fix
 	}
 
@@ -293,7 +402,7 @@ func (p *Processor) generateHowToFix(violation domain.AxeViolation) string {
 		return violation.Nodes[0].All[0].Message
 	}
 
-	return "Изучите документацию по ссылке для получения рекомендаций по исправлению."
+	return i18n.T(locale, "issue.default_fix")
 }
 
 // generateRecommendations генерирует общие рекомендации
@@ -302,28 +411,27 @@ func (p *Processor) generateRecommendations(report *domain.Report) []string {
 
 	if report.Summary.Critical > 0 {
 		recommendations = append(recommendations,
-			fmt.Sprintf("[!] Обнаружено %d критических проблем. Рекомендуется исправить их в первую очередь.", report.Summary.Critical))
+			i18n.T(report.Locale, "recommendation.critical", report.Summary.Critical))
 	}
 
 	if report.Summary.Serious > 0 {
 		recommendations = append(recommendations,
-			fmt.Sprintf("[!!] Найдено %d серьезных проблем, которые могут значительно затруднить использование сайта.", report.Summary.Serious))
+			i18n.T(report.Locale, "recommendation.serious", report.Summary.Serious))
 	}
 
 	if report.Summary.TotalIssues > 10 {
-		recommendations = append(recommendations,
-			"[i] Рекомендуется провести комплексный аудит доступности с участием экспертов.")
+		recommendations = append(recommendations, i18n.T(report.Locale, "recommendation.audit"))
 	}
 
 	// Анализируем частые типы проблем
 	commonIssues := p.findCommonIssues(report)
 	if len(commonIssues) > 0 {
 		recommendations = append(recommendations,
-			"[*] Частые проблемы: "+strings.Join(commonIssues, ", ")+". Рассмотрите возможность автоматизации проверок.")
+			i18n.T(report.Locale, "recommendation.common_issues", strings.Join(commonIssues, ", ")))
 	}
 
 	if len(recommendations) == 0 {
-		recommendations = append(recommendations, "[OK] Отличная работа! Серьезных проблем с доступностью не обнаружено.")
+		recommendations = append(recommendations, i18n.T(report.Locale, "recommendation.no_issues"))
 	}
 
 	return recommendations
@@ -0,0 +1,195 @@
+package translator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+)
+
+// builtinPromptVersion - версия встроенных промптов. Ее нужно увеличивать (вместе с каталогом
+// prompts/vN) при изменении текста: версия входит в ключ кэша описаний и сохраняется в отчете.
+const builtinPromptVersion = "v3"
+
+//go:embed prompts/v3/*.tmpl
+var builtinPromptFiles embed.FS
+
+// Имена промптов - блоков define в файлах шаблонов
+const (
+	promptTranslatorSystem = "translator_system"
+	promptSummarySystem    = "summary_system"
+	promptTranslate        = "translate"
+	promptEnrich           = "enrich"
+	promptEnrichRetry      = "enrich_retry"
+	promptNodeFix          = "node_fix"
+	promptSummary          = "summary"
+	promptDemo             = "demo"
+	promptDemoSummary      = "demo_summary"
+)
+
+// promptNames - все промпты, которые должны быть в шаблонах каждого языка
+var promptNames = []string{
+	promptTranslatorSystem, promptSummarySystem, promptTranslate, promptEnrich, promptEnrichRetry,
+	promptNodeFix, promptSummary, promptDemo, promptDemoSummary,
+}
+
+// promptsWithData - промпты, которые обязаны включать данные страницы (.Data)
+var promptsWithData = []string{promptTranslate, promptEnrich, promptNodeFix, promptSummary}
+
+// promptData - данные для шаблонов промптов
+type promptData struct {
+	// Data - данные страницы, уже помещенные между untrustedOpen и untrustedClose
+	Data string
+	// Count - число проблем в батче
+	Count int
+	// Problems - ошибки проверки ответа, Rules - ID правил для повторного запроса
+	Problems []string
+	Rules    []string
+}
+
+var promptFuncs = template.FuncMap{"join": strings.Join}
+
+// Prompts - шаблоны промптов по языкам. Ответ модели должен быть на языке промпта.
+type Prompts struct {
+	version   string
+	templates map[string]*template.Template
+}
+
+// defaultPrompts - встроенные промпты, которые используются без LoadPrompts
+var defaultPrompts = mustLoadBuiltinPrompts()
+
+func mustLoadBuiltinPrompts() *Prompts {
+	p, err := LoadPrompts("")
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// LoadPrompts загружает встроенные промпты и переопределения из каталога dir (пустой - без них).
+// Файл <язык>.tmpl в dir (например, ru.tmpl) может переопределить любые блоки define встроенного
+// файла этого языка, остальные промпты остаются встроенными. Все шаблоны проверяются пробным
+// выполнением. При переопределении к версии добавляется хэш файлов, например "v3+custom.1a2b3c4d".
+func LoadPrompts(dir string) (*Prompts, error) {
+	builtin, err := fs.Sub(builtinPromptFiles, "prompts/"+builtinPromptVersion)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prompts{version: builtinPromptVersion, templates: make(map[string]*template.Template)}
+	for _, locale := range i18n.Supported() {
+		tmpl, err := template.New(locale).Funcs(promptFuncs).ParseFS(builtin, locale+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("built-in prompts %s: %w", locale, err)
+		}
+		p.templates[locale] = tmpl
+	}
+
+	if dir != "" {
+		if err := p.override(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, locale := range i18n.Supported() {
+		if err := validatePrompts(p.templates[locale]); err != nil {
+			return nil, fmt.Errorf("prompts %s: %w", locale, err)
+		}
+	}
+	return p, nil
+}
+
+// override применяет файлы <язык>.tmpl из каталога dir поверх встроенных шаблонов
+func (p *Prompts) override(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no *.tmpl prompt files in %s", dir)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		locale := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		tmpl, ok := p.templates[locale]
+		if !ok {
+			return fmt.Errorf("prompt file %s: unsupported locale %q", file, locale)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := tmpl.Parse(string(content)); err != nil {
+			return fmt.Errorf("prompt file %s: %w", file, err)
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00", locale, content)
+	}
+	p.version = builtinPromptVersion + "+custom." + hex.EncodeToString(hash.Sum(nil))[:8]
+	return nil
+}
+
+// validatePrompts выполняет все промпты на пробных данных: так ошибки в именах полей и функций
+// обнаруживаются при запуске, а не при первом анализе
+func validatePrompts(tmpl *template.Template) error {
+	const marker = "\x00data\x00"
+	sample := promptData{Data: marker, Count: 1, Problems: []string{"problem"}, Rules: []string{"rule"}}
+
+	for _, name := range promptNames {
+		if tmpl.Lookup(name) == nil {
+			return fmt.Errorf("prompt %q is not defined", name)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, sample); err != nil {
+			return err
+		}
+		if strings.TrimSpace(strings.ReplaceAll(buf.String(), marker, "")) == "" {
+			return fmt.Errorf("prompt %q is empty", name)
+		}
+	}
+	for _, name := range promptsWithData {
+		var buf bytes.Buffer
+		tmpl.ExecuteTemplate(&buf, name, sample)
+		if !strings.Contains(buf.String(), marker) {
+			return fmt.Errorf("prompt %q must include page data {{.Data}}", name)
+		}
+	}
+	return nil
+}
+
+// Version возвращает версию промптов, которая сохраняется в отчете
+func (p *Prompts) Version() string {
+	return p.version
+}
+
+// render выполняет промпт name на языке locale (для языка без шаблонов - на i18n.Default)
+func (p *Prompts) render(locale, name string, data promptData) (string, error) {
+	tmpl, ok := p.templates[i18n.Resolve(locale)]
+	if !ok {
+		tmpl = p.templates[i18n.Default]
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %q: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// text выполняет промпт без данных страницы (системные инструкции и заглушки демо-режима).
+// Такие промпты проверены при загрузке, поэтому ошибка выполнения не ожидается.
+func (p *Prompts) text(locale, name string) string {
+	text, err := p.render(locale, name, promptData{})
+	if err != nil {
+		return ""
+	}
+	return text
+}
@@ -0,0 +1,115 @@
+package translator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// TestBuiltinPrompts проверяет, что встроенные промпты есть для всех языков и подставляют данные
+func TestBuiltinPrompts(t *testing.T) {
+	if defaultPrompts.Version() != builtinPromptVersion {
+		t.Errorf("unexpected built-in version %q", defaultPrompts.Version())
+	}
+
+	for _, locale := range i18n.Supported() {
+		enrich, err := defaultPrompts.render(locale, promptEnrich, promptData{Data: "<data>", Count: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(enrich, " 7 ") || !strings.HasSuffix(enrich, "\n<data>") {
+			t.Errorf("%s: unexpected enrich prompt:\n%s", locale, enrich)
+		}
+
+		retry, _ := defaultPrompts.render(locale, promptEnrichRetry, promptData{Problems: []string{"a", "b"}, Rules: []string{"label", "region"}})
+		if !strings.Contains(retry, "\n- a\n- b\n") || !strings.Contains(retry, "label, region.") {
+			t.Errorf("%s: unexpected retry prompt:\n%s", locale, retry)
+		}
+	}
+
+	if defaultPrompts.text(i18n.English, promptDemo) == defaultPrompts.text(i18n.Russian, promptDemo) {
+		t.Error("expected prompts to differ between locales")
+	}
+	if defaultPrompts.text("xx", promptDemo) != defaultPrompts.text(i18n.Default, promptDemo) {
+		t.Error("unknown locale must fall back to the default one")
+	}
+}
+
+// writePrompts создает каталог с файлами промптов
+func writePrompts(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestLoadPromptsOverride проверяет частичное переопределение промптов и версию в отчете
+func TestLoadPromptsOverride(t *testing.T) {
+	dir := writePrompts(t, map[string]string{
+		"ru.tmpl": `{{define "translator_system"}}Ты аудитор доступности банка.{{end}}`,
+	})
+	prompts, err := LoadPrompts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(prompts.Version(), builtinPromptVersion+"+custom.") {
+		t.Errorf("unexpected version %q", prompts.Version())
+	}
+	if again, _ := LoadPrompts(dir); again.Version() != prompts.Version() {
+		t.Error("version must not change for the same files")
+	}
+
+	if got := prompts.text(i18n.Russian, promptTranslatorSystem); got != "Ты аудитор доступности банка." {
+		t.Errorf("expected overridden system prompt, got %q", got)
+	}
+	// Остальные промпты и другие языки остаются встроенными
+	if prompts.text(i18n.Russian, promptDemo) != defaultPrompts.text(i18n.Russian, promptDemo) ||
+		prompts.text(i18n.English, promptTranslatorSystem) != defaultPrompts.text(i18n.English, promptTranslatorSystem) {
+		t.Error("prompts that are not overridden must stay built-in")
+	}
+
+	provider := &promptRecorder{}
+	trans := NewTranslator(provider, service.NewMemoryStorage(), Options{Workers: 1, Prompts: prompts})
+	violations := []domain.AxeViolation{{ID: "image-alt", Impact: "critical", Help: "Images must have alternate text"}}
+	report, err := trans.processor.ProcessViolations(context.Background(), "https://example.com", violations, "job", ProcessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.PromptVersion != prompts.Version() {
+		t.Errorf("expected report prompt version %q, got %q", prompts.Version(), report.PromptVersion)
+	}
+	if !strings.Contains(provider.prompts[0], "Проблемы:") {
+		t.Errorf("expected built-in enrich prompt, got:\n%s", provider.prompts[0])
+	}
+}
+
+// TestLoadPromptsRejectsInvalid проверяет ошибки в шаблонах при загрузке
+func TestLoadPromptsRejectsInvalid(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"syntax":         {"ru.tmpl": `{{define "summary"}}Отчет: {{.Data}{{end}}`},
+		"unknown field":  {"en.tmpl": `{{define "enrich"}}{{.Issues}}{{end}}`},
+		"unknown func":   {"en.tmpl": `{{define "enrich_retry"}}{{upper .Rules}}{{end}}`},
+		"no page data":   {"ru.tmpl": `{{define "node_fix"}}Исправь элементы{{end}}`},
+		"empty prompt":   {"ru.tmpl": `{{define "demo"}}{{if .Data}}{{.Data}}{{end}}{{end}}`},
+		"unknown locale": {"de.tmpl": `{{define "demo"}}Demo{{end}}`},
+		"no files":       {"README.md": "prompts"},
+	} {
+		if _, err := LoadPrompts(writePrompts(t, files)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := LoadPrompts(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
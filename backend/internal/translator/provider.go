@@ -0,0 +1,256 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Поддерживаемые провайдеры LLM
+const (
+	ProviderOpenAI    = "openai"
+	ProviderOllama    = "ollama"
+	ProviderAnthropic = "anthropic"
+)
+
+// Message представляет сообщение в чате
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest - запрос к чат-модели
+type ChatRequest struct {
+	// System - системная инструкция
+	System string
+	// Messages - сообщения диалога (роли user и assistant)
+	Messages []Message
+	// MaxTokens - ограничение длины ответа (0 - значение из настроек провайдера)
+	MaxTokens int
+	// Schema - JSON-схема ответа. Если задана, модель должна вернуть JSON по этой схеме
+	Schema *JSONSchema
+}
+
+// JSONSchema описывает ожидаемую структуру ответа модели
+type JSONSchema struct {
+	// Name - имя схемы (латиница, цифры, _ и -)
+	Name string
+	// Schema - JSON Schema объекта ответа
+	Schema json.RawMessage
+}
+
+// ChatResponse - ответ чат-модели
+type ChatResponse struct {
+	Content string
+	Model   string
+	// Usage - расход токенов, если провайдер его сообщает
+	Usage Usage
+}
+
+// Usage - расход токенов на один запрос
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// LLMProvider выполняет запросы к чат-модели конкретного API
+type LLMProvider interface {
+	// Name возвращает название провайдера для логов
+	Name() string
+	// Chat отправляет запрос и возвращает ответ модели.
+	// Отмена ctx прерывает выполняющийся HTTP-запрос.
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+}
+
+// ProviderConfig содержит настройки подключения к LLM
+type ProviderConfig struct {
+	// Provider - ProviderOpenAI (любой OpenAI-совместимый API), ProviderOllama или ProviderAnthropic
+	Provider string
+	APIKey   string
+	// BaseURL - адрес API (пустой - адрес провайдера по умолчанию)
+	BaseURL string
+	// Model - модель (пустая - модель провайдера по умолчанию)
+	Model string
+	// MaxTokens - верхняя граница длины ответа (0 - без дополнительного ограничения)
+	MaxTokens int
+	// Temperature - температура генерации (отрицательная - значение модели по умолчанию)
+	Temperature float64
+	// HTTPClient - HTTP-клиент (nil - клиент из newHTTPClient)
+	HTTPClient *http.Client
+}
+
+// responseHeaderTimeout - сколько клиент по умолчанию ждет заголовков ответа. Без потоковой
+// передачи API отвечает только после генерации всего текста, поэтому предел больше LLM_TIMEOUT:
+// он защищает от зависших соединений там, где провайдер используется без ResilientProvider.
+const responseHeaderTimeout = 5 * time.Minute
+
+// newHTTPClient создает HTTP-клиент для API моделей. Общий http.Client.Timeout не задается:
+// он ограничил бы и чтение потокового ответа, которое может идти дольше любого разумного предела.
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// NewProvider создает провайдера по настройкам.
+// Возвращает nil без ошибки, если для облачного API не задан ключ: AIClient тогда работает в демо-режиме.
+func NewProvider(cfg ProviderConfig) (LLMProvider, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = newHTTPClient()
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", ProviderOpenAI:
+		// Без ключа работают только локальные OpenAI-совместимые серверы с явно заданным адресом
+		if cfg.APIKey == "" && cfg.BaseURL == "" {
+			return nil, nil
+		}
+		return newOpenAIProvider(cfg), nil
+	case ProviderOllama:
+		return newOllamaProvider(cfg), nil
+	case ProviderAnthropic:
+		if cfg.APIKey == "" {
+			return nil, nil
+		}
+		return newAnthropicProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+// maxTokens возвращает длину ответа с учетом ограничения из настроек
+func (cfg ProviderConfig) maxTokens(requested int) int {
+	if cfg.MaxTokens > 0 && (requested <= 0 || requested > cfg.MaxTokens) {
+		return cfg.MaxTokens
+	}
+	return requested
+}
+
+// temperature возвращает температуру для запроса или nil, если используется значение модели
+func (cfg ProviderConfig) temperature() *float64 {
+	if cfg.Temperature < 0 {
+		return nil
+	}
+	t := cfg.Temperature
+	return &t
+}
+
+// StatusError - ответ API с кодом ошибки
+type StatusError struct {
+	StatusCode int
+	// RetryAfter - пауза из заголовка Retry-After (0, если заголовка нет)
+	RetryAfter time.Duration
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("LLM API error: status %d: %s", e.StatusCode, e.Message)
+}
+
+// postJSON отправляет JSON-запрос и разбирает JSON-ответ в out.
+// Для ответов с кодом ошибки возвращает *StatusError.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload, out interface{}) error {
+	resp, err := post(ctx, client, url, headers, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// post отправляет JSON-запрос и возвращает успешный ответ, тело которого закрывает вызывающий.
+// Для ответов с кодом ошибки возвращает *StatusError.
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Message:    apiErrorMessage(body),
+		}
+	}
+	return resp, nil
+}
+
+// apiErrorMessage извлекает текст ошибки из тела ответа.
+// Поддерживает {"error": {"message": ...}} (OpenAI, Anthropic) и {"error": "..."} (Ollama).
+func apiErrorMessage(body []byte) string {
+	var structured struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &structured) == nil && len(structured.Error) > 0 {
+		var apiErr APIError
+		if json.Unmarshal(structured.Error, &apiErr) == nil && apiErr.Message != "" {
+			return apiErr.Message
+		}
+		var text string
+		if json.Unmarshal(structured.Error, &text) == nil && text != "" {
+			return text
+		}
+	}
+
+	// Не JSON (например, HTML-страница балансировщика): берем начало тела,
+	// не разрезая многобайтовый символ UTF-8
+	text := strings.TrimSpace(string(body))
+	if len(text) > 200 {
+		cut := 200
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
+	}
+	return text
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в виде HTTP-даты
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
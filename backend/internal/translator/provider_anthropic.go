@@ -0,0 +1,217 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Значения по умолчанию для Anthropic Messages API
+const (
+	defaultAnthropicBaseURL   = "https://api.anthropic.com"
+	defaultAnthropicModel     = "claude-sonnet-4-5"
+	defaultAnthropicMaxTokens = 4096
+	anthropicVersion          = "2023-06-01"
+)
+
+// anthropicRequest - запрос к /v1/messages
+type anthropicRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	// Tools и ToolChoice используются для ответа по JSON-схеме:
+	// модель обязана вызвать единственный инструмент, и его аргументы - это ответ
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+	// Stream включает потоковую передачу ответа (SSE)
+	Stream bool `json:"stream,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// anthropicResponse - ответ /v1/messages
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *APIError `json:"error,omitempty"`
+}
+
+// anthropicStreamEvent - событие потокового ответа /v1/messages. Используются
+// message_start (модель и токены промпта), content_block_delta (фрагмент текста),
+// message_delta (токены ответа) и error.
+type anthropicStreamEvent struct {
+	Type    string            `json:"type"`
+	Message anthropicResponse `json:"message"`
+	Delta   struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *APIError `json:"error,omitempty"`
+}
+
+// anthropicProvider работает с Anthropic Messages API
+type anthropicProvider struct {
+	cfg ProviderConfig
+}
+
+func newAnthropicProvider(cfg ProviderConfig) *anthropicProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultAnthropicBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultAnthropicModel
+	}
+	return &anthropicProvider{cfg: cfg}
+}
+
+// Name возвращает название провайдера
+func (p *anthropicProvider) Name() string {
+	return ProviderAnthropic + " (" + p.cfg.Model + ")"
+}
+
+// Chat отправляет запрос в /v1/messages
+func (p *anthropicProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var resp anthropicResponse
+	if err := postJSON(ctx, p.cfg.HTTPClient, p.cfg.BaseURL+"/v1/messages", p.headers(), p.request(req), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("Anthropic API error: %s", resp.Error.Message)
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch {
+		case block.Type == "text" && req.Schema == nil:
+			text.WriteString(block.Text)
+		case block.Type == "tool_use" && req.Schema != nil:
+			text.Write(block.Input)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no response from Anthropic")
+	}
+
+	return &ChatResponse{
+		Content: text.String(),
+		Model:   p.model(resp.Model),
+		Usage:   Usage{PromptTokens: resp.Usage.InputTokens, CompletionTokens: resp.Usage.OutputTokens},
+	}, nil
+}
+
+// ChatStream отправляет запрос в /v1/messages с потоковой передачей ответа.
+// Ответ по JSON-схеме приходит аргументами инструмента, поэтому поддерживается только текст.
+func (p *anthropicProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	if req.Schema != nil {
+		return nil, fmt.Errorf("streaming is not supported for structured responses")
+	}
+	reqBody := p.request(req)
+	reqBody.Stream = true
+
+	resp, err := post(ctx, p.cfg.HTTPClient, p.cfg.BaseURL+"/v1/messages", p.headers(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		text  strings.Builder
+		model string
+		usage Usage
+	)
+	err = readSSE(resp.Body, func(_, data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			model = event.Message.Model
+			usage.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				text.WriteString(event.Delta.Text)
+				onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			usage.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			if event.Error != nil {
+				return fmt.Errorf("Anthropic API error: %s", event.Error.Message)
+			}
+			return fmt.Errorf("Anthropic API error")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no response from Anthropic")
+	}
+	return &ChatResponse{Content: text.String(), Model: p.model(model), Usage: usage}, nil
+}
+
+// request собирает тело запроса к /v1/messages
+func (p *anthropicProvider) request(req ChatRequest) anthropicRequest {
+	// В Messages API длина ответа обязательна
+	maxTokens := p.cfg.maxTokens(req.MaxTokens)
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+
+	reqBody := anthropicRequest{
+		Model:       p.cfg.Model,
+		System:      req.System,
+		Messages:    req.Messages,
+		MaxTokens:   maxTokens,
+		Temperature: p.cfg.temperature(),
+	}
+	if req.Schema != nil {
+		reqBody.Tools = []anthropicTool{{
+			Name:        req.Schema.Name,
+			Description: "Return the response in the required structure",
+			InputSchema: req.Schema.Schema,
+		}}
+		reqBody.ToolChoice = &anthropicToolChoice{Type: "tool", Name: req.Schema.Name}
+	}
+	return reqBody
+}
+
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.cfg.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// model возвращает модель из ответа или модель из настроек, если API ее не сообщил
+func (p *anthropicProvider) model(reported string) string {
+	if reported == "" {
+		return p.cfg.Model
+	}
+	return reported
+}
@@ -0,0 +1,166 @@
+package translator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Значения по умолчанию для локального Ollama
+const (
+	defaultOllamaBaseURL = "http://localhost:11434"
+	defaultOllamaModel   = "qwen3:8b"
+)
+
+// ollamaRequest - запрос к /api/chat
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options,omitempty"`
+	// Format - JSON-схема ответа
+	Format json.RawMessage `json:"format,omitempty"`
+}
+
+// ollamaOptions - параметры генерации Ollama
+type ollamaOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// ollamaResponse - ответ /api/chat. При потоковой передаче каждая строка ответа -
+// такой же объект с фрагментом в Message, а счетчики токенов приходят в последней (Done).
+type ollamaResponse struct {
+	Model   string  `json:"model"`
+	Message Message `json:"message"`
+	// PromptEvalCount и EvalCount - число токенов промпта и ответа
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ollamaProvider работает с локальным Ollama через /api/chat
+type ollamaProvider struct {
+	cfg ProviderConfig
+}
+
+func newOllamaProvider(cfg ProviderConfig) *ollamaProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOllamaBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOllamaModel
+	}
+	return &ollamaProvider{cfg: cfg}
+}
+
+// Name возвращает название провайдера
+func (p *ollamaProvider) Name() string {
+	return ProviderOllama + " (" + p.cfg.Model + ")"
+}
+
+// Chat отправляет запрос в /api/chat
+func (p *ollamaProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var resp ollamaResponse
+	if err := postJSON(ctx, p.cfg.HTTPClient, p.cfg.BaseURL+"/api/chat", nil, p.request(req), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("Ollama API error: %s", resp.Error)
+	}
+	if resp.Message.Content == "" {
+		return nil, fmt.Errorf("no response from Ollama")
+	}
+
+	return &ChatResponse{
+		Content: resp.Message.Content,
+		Model:   p.model(resp.Model),
+		Usage:   Usage{PromptTokens: resp.PromptEvalCount, CompletionTokens: resp.EvalCount},
+	}, nil
+}
+
+// ChatStream отправляет запрос в /api/chat с потоковой передачей ответа (NDJSON)
+func (p *ollamaProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	reqBody := p.request(req)
+	reqBody.Stream = true
+
+	resp, err := post(ctx, p.cfg.HTTPClient, p.cfg.BaseURL+"/api/chat", nil, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		content strings.Builder
+		last    ollamaResponse
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		last = chunk
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("no response from Ollama")
+	}
+
+	return &ChatResponse{
+		Content: content.String(),
+		Model:   p.model(last.Model),
+		Usage:   Usage{PromptTokens: last.PromptEvalCount, CompletionTokens: last.EvalCount},
+	}, nil
+}
+
+// request собирает тело запроса к /api/chat
+func (p *ollamaProvider) request(req ChatRequest) ollamaRequest {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	reqBody := ollamaRequest{
+		Model:    p.cfg.Model,
+		Messages: messages,
+		Options: ollamaOptions{
+			NumPredict:  p.cfg.maxTokens(req.MaxTokens),
+			Temperature: p.cfg.temperature(),
+		},
+	}
+	if req.Schema != nil {
+		reqBody.Format = req.Schema.Schema
+	}
+	return reqBody
+}
+
+// model возвращает модель из ответа или модель из настроек, если API ее не сообщил
+func (p *ollamaProvider) model(reported string) string {
+	if reported == "" {
+		return p.cfg.Model
+	}
+	return reported
+}
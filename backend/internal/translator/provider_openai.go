@@ -0,0 +1,202 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Значения по умолчанию для OpenAI-совместимого API (Groq)
+const (
+	defaultOpenAIBaseURL = "https://api.groq.com/openai/v1"
+	defaultOpenAIModel   = "qwen/qwen3-32b"
+)
+
+// OpenAIRequest представляет запрос к OpenAI
+type OpenAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	// ResponseFormat ограничивает ответ JSON-схемой (structured outputs)
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Stream включает потоковую передачу ответа (SSE)
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions - параметры потоковой передачи OpenAI
+type StreamOptions struct {
+	// IncludeUsage просит прислать расход токенов последним фрагментом
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ResponseFormat задает формат ответа OpenAI
+type ResponseFormat struct {
+	Type       string              `json:"type"`
+	JSONSchema *ResponseJSONSchema `json:"json_schema,omitempty"`
+}
+
+// ResponseJSONSchema - схема ответа для ResponseFormat с типом json_schema
+type ResponseJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// OpenAIResponse представляет ответ от OpenAI
+type OpenAIResponse struct {
+	Model   string       `json:"model"`
+	Choices []Choice     `json:"choices"`
+	Usage   *OpenAIUsage `json:"usage,omitempty"`
+	Error   *APIError    `json:"error,omitempty"`
+}
+
+// OpenAIUsage - расход токенов на запрос
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Choice представляет вариант ответа
+type Choice struct {
+	Message Message `json:"message"`
+	// Delta - фрагмент ответа при потоковой передаче
+	Delta Message `json:"delta"`
+}
+
+// APIError представляет ошибку API
+type APIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// openAIProvider работает с любым API, совместимым с OpenAI Chat Completions
+// (OpenAI, Groq, OpenRouter, vLLM, LM Studio и т.п.)
+type openAIProvider struct {
+	cfg ProviderConfig
+}
+
+func newOpenAIProvider(cfg ProviderConfig) *openAIProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOpenAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOpenAIModel
+	}
+	return &openAIProvider{cfg: cfg}
+}
+
+// Name возвращает название провайдера
+func (p *openAIProvider) Name() string {
+	return ProviderOpenAI + " (" + p.cfg.Model + ")"
+}
+
+// Chat отправляет запрос в /chat/completions
+func (p *openAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var resp OpenAIResponse
+	if err := postJSON(ctx, p.cfg.HTTPClient, p.cfg.BaseURL+"/chat/completions", p.headers(), p.request(req), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("OpenAI API error: %s", resp.Error.Message)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from OpenAI")
+	}
+
+	result := &ChatResponse{Content: resp.Choices[0].Message.Content, Model: p.model(resp.Model)}
+	if resp.Usage != nil {
+		result.Usage = Usage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+	}
+	return result, nil
+}
+
+// ChatStream отправляет запрос в /chat/completions с потоковой передачей ответа
+func (p *openAIProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	reqBody := p.request(req)
+	reqBody.Stream = true
+	reqBody.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	resp, err := post(ctx, p.cfg.HTTPClient, p.cfg.BaseURL+"/chat/completions", p.headers(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		content strings.Builder
+		model   string
+		usage   Usage
+	)
+	err = readSSE(resp.Body, func(event, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk OpenAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("OpenAI API error: %s", chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("no response from OpenAI")
+	}
+	return &ChatResponse{Content: content.String(), Model: p.model(model), Usage: usage}, nil
+}
+
+// request собирает тело запроса к /chat/completions
+func (p *openAIProvider) request(req ChatRequest) OpenAIRequest {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	reqBody := OpenAIRequest{
+		Model:       p.cfg.Model,
+		Messages:    messages,
+		MaxTokens:   p.cfg.maxTokens(req.MaxTokens),
+		Temperature: p.cfg.temperature(),
+	}
+	if req.Schema != nil {
+		reqBody.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &ResponseJSONSchema{Name: req.Schema.Name, Schema: req.Schema.Schema, Strict: true},
+		}
+	}
+	return reqBody
+}
+
+func (p *openAIProvider) headers() map[string]string {
+	headers := map[string]string{}
+	if p.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.cfg.APIKey
+	}
+	return headers
+}
+
+// model возвращает модель из ответа или модель из настроек, если API ее не сообщил
+func (p *openAIProvider) model(reported string) string {
+	if reported == "" {
+		return p.cfg.Model
+	}
+	return reported
+}
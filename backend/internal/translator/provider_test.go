@@ -0,0 +1,220 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testProvider возвращает OpenAI-совместимого провайдера, обращающегося к тестовому серверу
+func testProvider(baseURL string) LLMProvider {
+	provider, _ := NewProvider(ProviderConfig{Provider: ProviderOpenAI, APIKey: "test-key", BaseURL: baseURL})
+	return provider
+}
+
+// llmStub - тестовый сервер, записывающий последний запрос
+type llmStub struct {
+	*httptest.Server
+	path    string
+	headers http.Header
+	body    map[string]interface{}
+}
+
+func newLLMStub(t *testing.T, status int, response string) *llmStub {
+	stub := &llmStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.path = r.URL.Path
+		stub.headers = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&stub.body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+var testChatRequest = ChatRequest{
+	System:    "system prompt",
+	Messages:  []Message{{Role: "user", Content: "hello"}},
+	MaxTokens: 500,
+}
+
+// TestOpenAIProviderChat проверяет формат запроса к OpenAI-совместимому API
+func TestOpenAIProviderChat(t *testing.T) {
+	stub := newLLMStub(t, http.StatusOK, `{"model":"test-model","choices":[{"message":{"role":"assistant","content":"<think>hmm</think>ответ"}}],"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`)
+
+	provider, err := NewProvider(ProviderConfig{
+		Provider:    ProviderOpenAI,
+		APIKey:      "secret",
+		BaseURL:     stub.URL + "/v1/",
+		Model:       "test-model",
+		MaxTokens:   100,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+
+	resp, err := provider.Chat(context.Background(), testChatRequest)
+	if err != nil {
+		t.Fatalf("Chat returned error: %v", err)
+	}
+	if resp.Content != "<think>hmm</think>ответ" || resp.Model != "test-model" || resp.Usage != (Usage{PromptTokens: 12, CompletionTokens: 5}) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if stub.path != "/v1/chat/completions" {
+		t.Errorf("unexpected path %s", stub.path)
+	}
+	if got := stub.headers.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+	messages := stub.body["messages"].([]interface{})
+	if len(messages) != 2 || messages[0].(map[string]interface{})["role"] != "system" {
+		t.Errorf("expected system message first, got %v", messages)
+	}
+	// MaxTokens запроса ограничивается настройкой провайдера
+	if stub.body["max_tokens"] != float64(100) || stub.body["temperature"] != 0.3 {
+		t.Errorf("unexpected generation options: %v", stub.body)
+	}
+
+	// Текст ошибки API возвращается вызывающему
+	failing := newLLMStub(t, http.StatusBadRequest, `{"error":{"message":"bad model","type":"invalid_request_error"}}`)
+	if _, err := testProvider(failing.URL).Chat(context.Background(), testChatRequest); err == nil || !strings.Contains(err.Error(), "bad model") {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
+
+// TestOllamaProviderChat проверяет формат запроса к Ollama
+func TestOllamaProviderChat(t *testing.T) {
+	stub := newLLMStub(t, http.StatusOK, `{"model":"qwen3:8b","message":{"role":"assistant","content":"ответ"},"done":true,"prompt_eval_count":20,"eval_count":7}`)
+
+	provider, err := NewProvider(ProviderConfig{Provider: ProviderOllama, BaseURL: stub.URL, Temperature: -1})
+	if err != nil || provider == nil {
+		t.Fatalf("NewProvider returned %v, %v", provider, err)
+	}
+
+	resp, err := provider.Chat(context.Background(), testChatRequest)
+	if err != nil {
+		t.Fatalf("Chat returned error: %v", err)
+	}
+	if resp.Content != "ответ" || resp.Usage != (Usage{PromptTokens: 20, CompletionTokens: 7}) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if stub.path != "/api/chat" {
+		t.Errorf("unexpected path %s", stub.path)
+	}
+	if stub.body["stream"] != false || stub.body["model"] != defaultOllamaModel {
+		t.Errorf("unexpected request: %v", stub.body)
+	}
+	options := stub.body["options"].(map[string]interface{})
+	if options["num_predict"] != float64(500) {
+		t.Errorf("expected num_predict 500, got %v", options)
+	}
+	if _, ok := options["temperature"]; ok {
+		t.Errorf("temperature must be omitted when not configured, got %v", options)
+	}
+}
+
+// TestAnthropicProviderChat проверяет формат запроса к Anthropic Messages API
+func TestAnthropicProviderChat(t *testing.T) {
+	stub := newLLMStub(t, http.StatusOK, `{"model":"claude-test","content":[{"type":"text","text":"от"},{"type":"text","text":"вет"}],"usage":{"input_tokens":30,"output_tokens":9}}`)
+
+	provider, err := NewProvider(ProviderConfig{Provider: ProviderAnthropic, APIKey: "secret", BaseURL: stub.URL, Model: "claude-test", Temperature: 0})
+	if err != nil || provider == nil {
+		t.Fatalf("NewProvider returned %v, %v", provider, err)
+	}
+
+	resp, err := provider.Chat(context.Background(), ChatRequest{Messages: testChatRequest.Messages, System: "system prompt"})
+	if err != nil {
+		t.Fatalf("Chat returned error: %v", err)
+	}
+	if resp.Content != "ответ" || resp.Model != "claude-test" || resp.Usage != (Usage{PromptTokens: 30, CompletionTokens: 9}) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if stub.path != "/v1/messages" {
+		t.Errorf("unexpected path %s", stub.path)
+	}
+	if stub.headers.Get("x-api-key") != "secret" || stub.headers.Get("anthropic-version") != anthropicVersion {
+		t.Errorf("unexpected headers: %v", stub.headers)
+	}
+	if stub.body["system"] != "system prompt" || len(stub.body["messages"].([]interface{})) != 1 {
+		t.Errorf("system prompt must be sent separately from messages: %v", stub.body)
+	}
+	// max_tokens обязателен и подставляется по умолчанию
+	if stub.body["max_tokens"] != float64(defaultAnthropicMaxTokens) || stub.body["temperature"] != float64(0) {
+		t.Errorf("unexpected generation options: %v", stub.body)
+	}
+
+	failing := newLLMStub(t, http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+	provider, _ = NewProvider(ProviderConfig{Provider: ProviderAnthropic, APIKey: "wrong", BaseURL: failing.URL})
+	if _, err := provider.Chat(context.Background(), testChatRequest); err == nil || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
+
+// TestNewProviderDemoMode проверяет выбор демо-режима и ошибку для неизвестного провайдера
+func TestNewProviderDemoMode(t *testing.T) {
+	for _, name := range []string{"", ProviderOpenAI, ProviderAnthropic} {
+		provider, err := NewProvider(ProviderConfig{Provider: name})
+		if err != nil || provider != nil {
+			t.Errorf("provider %q without API key should select demo mode, got %v, %v", name, provider, err)
+		}
+	}
+
+	// Локальному OpenAI-совместимому серверу ключ не нужен
+	if provider, _ := NewProvider(ProviderConfig{Provider: ProviderOpenAI, BaseURL: "http://localhost:1234/v1"}); provider == nil {
+		t.Error("expected provider for explicit base URL without API key")
+	}
+
+	if _, err := NewProvider(ProviderConfig{Provider: "unknown"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+// TestNewProviderDefaultHTTPClient проверяет, что клиент по умолчанию не ждет заголовков ответа
+// бесконечно, но и не ограничивает время чтения потокового ответа
+func TestNewProviderDefaultHTTPClient(t *testing.T) {
+	provider, err := NewProvider(ProviderConfig{Provider: ProviderOpenAI, APIKey: "test-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := provider.(*openAIProvider).cfg.HTTPClient
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport.ResponseHeaderTimeout != responseHeaderTimeout {
+		t.Errorf("expected transport with response header timeout, got %#v", client.Transport)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("client timeout would cut long streams, got %s", client.Timeout)
+	}
+}
+
+// TestAPIErrorMessage проверяет извлечение текста ошибки и обрезку тела не в формате JSON
+func TestAPIErrorMessage(t *testing.T) {
+	if got := apiErrorMessage([]byte(`{"error": {"message": "invalid api key"}}`)); got != "invalid api key" {
+		t.Errorf("unexpected structured message: %q", got)
+	}
+	if got := apiErrorMessage([]byte(`{"error": "model not found"}`)); got != "model not found" {
+		t.Errorf("unexpected string message: %q", got)
+	}
+
+	// После 199 байт ASCII идет кириллица: граница в 200 байт приходится на середину символа
+	body := strings.Repeat("a", 199) + strings.Repeat("ошибка", 50)
+	got := apiErrorMessage([]byte(body))
+	if !utf8.ValidString(got) {
+		t.Fatalf("message must stay valid UTF-8, got %q", got)
+	}
+	if want := strings.Repeat("a", 199) + "..."; got != want {
+		t.Errorf("expected truncation before the split rune, got %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package translator
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+var (
+	// ErrQueueFull возвращается, когда очередь анализа заполнена
+	ErrQueueFull = errors.New("analysis queue is full")
+	// ErrShuttingDown возвращается, когда сервер останавливается и не принимает новые задачи
+	ErrShuttingDown = errors.New("server is shutting down")
+)
+
+// analysisTask - задача анализа, ожидающая свободного обработчика
+type analysisTask struct {
+	jobID      string
+	url        string
+	locale     string
+	violations []domain.AxeViolation
+}
+
+// jobQueue - ограниченная FIFO-очередь задач анализа с фиксированным числом обработчиков.
+// В отличие от канала, очередь позволяет узнать позицию задачи.
+type jobQueue struct {
+	workers   int
+	maxLength int
+	handle    func(task *analysisTask)
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*analysisTask
+	closed  bool
+
+	// active отслеживает работающие обработчики, чтобы дождаться их при остановке
+	active sync.WaitGroup
+}
+
+// newJobQueue создает очередь и запускает обработчики
+func newJobQueue(workers, maxLength int, handle func(task *analysisTask)) *jobQueue {
+	if workers < 1 {
+		workers = 1
+	}
+
+	q := &jobQueue{
+		workers:   workers,
+		maxLength: maxLength,
+		handle:    handle,
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	q.active.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+
+	return q
+}
+
+// Enqueue добавляет задачу в конец очереди.
+// Возвращает ErrQueueFull, если ожидающих задач уже maxLength (0 - без ограничения).
+func (q *jobQueue) Enqueue(task *analysisTask) error {
+	return q.enqueue(task, true)
+}
+
+// EnqueueUnbounded добавляет задачу без учета maxLength.
+// Используется для задач, восстановленных после перезапуска: они уже были приняты.
+func (q *jobQueue) EnqueueUnbounded(task *analysisTask) error {
+	return q.enqueue(task, false)
+}
+
+func (q *jobQueue) enqueue(task *analysisTask, bounded bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrShuttingDown
+	}
+	if bounded && q.maxLength > 0 && len(q.pending) >= q.maxLength {
+		return ErrQueueFull
+	}
+
+	q.pending = append(q.pending, task)
+	q.cond.Signal()
+	return nil
+}
+
+// Position возвращает позицию задачи в очереди, начиная с 1.
+// 0 означает, что задача не ожидает в очереди (уже обрабатывается или неизвестна).
+func (q *jobQueue) Position(jobID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, task := range q.pending {
+		if task.jobID == jobID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Remove убирает ожидающую задачу из очереди. Возвращает false, если задачи в очереди нет.
+func (q *jobQueue) Remove(jobID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, task := range q.pending {
+		if task.jobID == jobID {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Len возвращает количество ожидающих задач
+func (q *jobQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Close перестает принимать задачи и выдавать их обработчикам.
+// Возвращает число задач, оставшихся в очереди необработанными.
+func (q *jobQueue) Close() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+	return len(q.pending)
+}
+
+// Wait дожидается, пока обработчики завершат текущие задачи. Вызывается после Close.
+func (q *jobQueue) Wait() {
+	q.active.Wait()
+}
+
+// work забирает задачи из очереди по одной и обрабатывает их до закрытия очереди
+func (q *jobQueue) work() {
+	defer q.active.Done()
+
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+		task := q.pending[0]
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		q.handle(task)
+	}
+}
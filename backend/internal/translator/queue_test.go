@@ -0,0 +1,141 @@
+package translator
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestJobQueueBoundsConcurrency проверяет лимит обработчиков, длины очереди и позиции задач
+func TestJobQueueBoundsConcurrency(t *testing.T) {
+	const workers, maxLength = 2, 3
+
+	var (
+		running, peak int32
+		release       = make(chan struct{})
+		started       = make(chan struct{}, workers+maxLength)
+		finished      sync.WaitGroup
+	)
+
+	q := newJobQueue(workers, maxLength, func(task *analysisTask) {
+		defer finished.Done()
+
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		started <- struct{}{}
+		<-release
+		atomic.AddInt32(&running, -1)
+	})
+
+	// Первые две задачи сразу забирают обработчики
+	finished.Add(workers + maxLength)
+	for i := 0; i < workers; i++ {
+		if err := q.Enqueue(&analysisTask{jobID: fmt.Sprintf("running-%d", i)}); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+	}
+	for i := 0; i < workers; i++ {
+		<-started
+	}
+
+	// Следующие ждут в очереди
+	for i := 0; i < maxLength; i++ {
+		if err := q.Enqueue(&analysisTask{jobID: fmt.Sprintf("queued-%d", i)}); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+	}
+
+	if err := q.Enqueue(&analysisTask{jobID: "overflow"}); err != ErrQueueFull {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	if pos := q.Position("queued-0"); pos != 1 {
+		t.Errorf("expected position 1, got %d", pos)
+	}
+	if pos := q.Position("queued-2"); pos != 3 {
+		t.Errorf("expected position 3, got %d", pos)
+	}
+	if pos := q.Position("running-0"); pos != 0 {
+		t.Errorf("running task should not have a queue position, got %d", pos)
+	}
+
+	close(release)
+
+	done := make(chan struct{})
+	go func() {
+		finished.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued tasks were not processed")
+	}
+
+	if peak > workers {
+		t.Errorf("expected at most %d concurrent tasks, got %d", workers, peak)
+	}
+	if q.Len() != 0 {
+		t.Errorf("expected empty queue, got %d", q.Len())
+	}
+}
+
+// TestJobQueueCloseKeepsPending проверяет, что после Close очередь не принимает задачи,
+// Wait дожидается текущей задачи, а ожидающие задачи не обрабатываются
+func TestJobQueueCloseKeepsPending(t *testing.T) {
+	var (
+		release   = make(chan struct{})
+		started   = make(chan string, 3)
+		processed int32
+	)
+
+	q := newJobQueue(1, 0, func(task *analysisTask) {
+		started <- task.jobID
+		<-release
+		atomic.AddInt32(&processed, 1)
+	})
+
+	for _, id := range []string{"running", "queued"} {
+		if err := q.Enqueue(&analysisTask{jobID: id}); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+	}
+	<-started
+
+	if left := q.Close(); left != 1 {
+		t.Errorf("expected 1 pending task, got %d", left)
+	}
+	if err := q.Enqueue(&analysisTask{jobID: "late"}); err != ErrShuttingDown {
+		t.Errorf("expected ErrShuttingDown, got %v", err)
+	}
+	if err := q.EnqueueUnbounded(&analysisTask{jobID: "late"}); err != ErrShuttingDown {
+		t.Errorf("expected ErrShuttingDown for unbounded enqueue, got %v", err)
+	}
+
+	close(release)
+
+	done := make(chan struct{})
+	go func() {
+		q.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return after the running task finished")
+	}
+
+	if n := atomic.LoadInt32(&processed); n != 1 {
+		t.Errorf("expected only the running task to be processed, got %d", n)
+	}
+	if q.Len() != 1 {
+		t.Errorf("pending task must stay in the queue, got %d", q.Len())
+	}
+}
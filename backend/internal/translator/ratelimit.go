@@ -0,0 +1,157 @@
+package translator
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RateLimiter ограничивает частоту запросов к LLM и расход токенов в минуту.
+// Один экземпляр используется всеми задачами, поэтому параллельные батчи
+// разных анализов вместе не превышают лимиты провайдера. К запросам он применяется
+// через ResilienceOptions.Limiter перед каждой попыткой.
+type RateLimiter struct {
+	// now и sleep подменяются в тестах
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// tokenBucket пополняется равномерно до capacity за одну минуту
+type tokenBucket struct {
+	capacity  float64
+	available float64
+	// perSecond - скорость пополнения
+	perSecond float64
+	updated   time.Time
+}
+
+// NewRateLimiter создает ограничитель. Нулевое значение лимита отключает его;
+// если отключены оба лимита, возвращает nil.
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+
+	l := &RateLimiter{now: time.Now, sleep: sleepContext}
+	now := l.now()
+	l.requests = newTokenBucket(requestsPerMinute, now)
+	l.tokens = newTokenBucket(tokensPerMinute, now)
+	return l
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		updated:   now,
+	}
+}
+
+// refill пополняет корзину за время, прошедшее с прошлого обновления
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.available += elapsed * b.perSecond
+		if b.available > b.capacity {
+			b.available = b.capacity
+		}
+	}
+	b.updated = now
+}
+
+// delay возвращает, сколько ждать, пока в корзине наберется n
+func (b *tokenBucket) delay(n float64) time.Duration {
+	if b == nil || b.available >= n {
+		return 0
+	}
+	return time.Duration((n - b.available) / b.perSecond * float64(time.Second))
+}
+
+// Wait ждет, пока можно будет выполнить запрос, расходующий tokens токенов.
+// Запрос больше лимита токенов в минуту ждет полного пополнения корзины.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	need := float64(tokens)
+	if l.tokens != nil && need > l.tokens.capacity {
+		need = l.tokens.capacity
+	}
+
+	logged := false
+	for {
+		l.mu.Lock()
+		now := l.now()
+		wait := time.Duration(0)
+		if l.requests != nil {
+			l.requests.refill(now)
+			wait = l.requests.delay(1)
+		}
+		if l.tokens != nil {
+			l.tokens.refill(now)
+			if d := l.tokens.delay(need); d > wait {
+				wait = d
+			}
+		}
+		if wait <= 0 {
+			if l.requests != nil {
+				l.requests.available--
+			}
+			if l.tokens != nil {
+				l.tokens.available -= need
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		if !logged {
+			log.Printf("[AI] Rate limit reached, waiting %s", wait.Round(time.Millisecond))
+			logged = true
+		}
+		if err := l.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// settle исправляет списанную в Wait оценку на фактический расход токенов:
+// недорасход возвращается в корзину, перерасход списывается дополнительно
+func (l *RateLimiter) settle(estimated, actual int) {
+	if l == nil || l.tokens == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	charged := float64(estimated)
+	if charged > l.tokens.capacity {
+		charged = l.tokens.capacity
+	}
+	l.tokens.refill(l.now())
+	l.tokens.available += charged - float64(actual)
+	if l.tokens.available > l.tokens.capacity {
+		l.tokens.available = l.tokens.capacity
+	}
+}
+
+// estimateTokens грубо оценивает расход токенов запроса: ~4 символа на токен
+// в промпте плюс максимальная длина ответа
+func estimateTokens(req ChatRequest) int {
+	chars := utf8.RuneCountInString(req.System)
+	for _, m := range req.Messages {
+		chars += utf8.RuneCountInString(m.Content)
+	}
+	return chars/4 + req.MaxTokens
+}
@@ -0,0 +1,277 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// newTestRateLimiter создает ограничитель с искусственными часами: пауза сдвигает время
+func newTestRateLimiter(requestsPerMinute, tokensPerMinute int, waits *[]time.Duration) *RateLimiter {
+	l := NewRateLimiter(requestsPerMinute, tokensPerMinute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	for _, bucket := range []*tokenBucket{l.requests, l.tokens} {
+		if bucket != nil {
+			bucket.updated = now
+		}
+	}
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		now = now.Add(d)
+		return nil
+	}
+	return l
+}
+
+// TestRateLimiterWaitsForRequestsAndTokens проверяет оба лимита токен-бакета
+func TestRateLimiterWaitsForRequestsAndTokens(t *testing.T) {
+	if NewRateLimiter(0, 0) != nil {
+		t.Error("limiter without limits should be nil")
+	}
+
+	var waits []time.Duration
+	l := newTestRateLimiter(2, 1200, &waits)
+	ctx := context.Background()
+
+	// Лимит запросов: третий запрос ждет пополнения на один запрос (30s при 2 в минуту)
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, 10); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	if len(waits) != 1 || waits[0] != 30*time.Second {
+		t.Fatalf("expected a single 30s pause, got %v", waits)
+	}
+
+	// Лимит токенов: после трех запросов в корзине осталось 1190 токенов
+	waits = nil
+	l.requests = nil
+	if err := l.Wait(ctx, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(ctx, 500); err != nil {
+		t.Fatal(err)
+	}
+	// Не хватало 310 токенов при 20 токенах в секунду
+	if len(waits) != 1 || waits[0] != 15500*time.Millisecond {
+		t.Errorf("expected a 15.5s pause for tokens, got %v", waits)
+	}
+
+	// Запрос больше минутного лимита ждет полного пополнения, а не бесконечно
+	waits = nil
+	if err := l.Wait(ctx, 5000); err != nil {
+		t.Fatal(err)
+	}
+	if len(waits) != 1 || waits[0] != time.Minute {
+		t.Errorf("expected a one minute pause for oversized request, got %v", waits)
+	}
+
+	// Отмена прерывает ожидание
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	l.sleep = sleepContext
+	if err := l.Wait(cancelled, 1200); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// usageProvider отвечает с заданным расходом токенов после нескольких ответов 429
+type usageProvider struct {
+	throttled int
+	calls     int
+	usage     Usage
+}
+
+func (p *usageProvider) Name() string { return "usage" }
+
+func (p *usageProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	p.calls++
+	if p.calls <= p.throttled {
+		return nil, &StatusError{StatusCode: 429}
+	}
+	return &ChatResponse{Content: "ok", Model: "usage", Usage: p.usage}, nil
+}
+
+// TestResilientProviderLimitsEveryAttempt проверяет, что повторы ResilientProvider
+// тоже расходуют общий лимит запросов
+func TestResilientProviderLimitsEveryAttempt(t *testing.T) {
+	var limiterWaits, retryWaits []time.Duration
+	provider := &usageProvider{throttled: 2}
+	r := newTestResilient(provider, ResilienceOptions{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Second,
+		Limiter:     newTestRateLimiter(1, 0, &limiterWaits),
+	}, &retryWaits)
+
+	if _, err := r.Chat(context.Background(), testChatRequest); err != nil {
+		t.Fatalf("Chat returned error: %v", err)
+	}
+	if provider.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", provider.calls)
+	}
+	// Лимит 1 запрос в минуту: вторая и третья попытки ждут пополнения
+	if len(limiterWaits) != 2 || limiterWaits[0] != time.Minute || limiterWaits[1] != time.Minute {
+		t.Errorf("expected each retry to wait for the limiter, got %v", limiterWaits)
+	}
+}
+
+// TestResilientProviderLimiterWaitIsNotFailure проверяет, что ожидание лимита дольше
+// таймаута попытки не прерывает запрос и не размыкает цепь
+func TestResilientProviderLimiterWaitIsNotFailure(t *testing.T) {
+	provider := &usageProvider{}
+	// 600 запросов в минуту: после первого запроса следующий ждет около 100ms
+	limiter := NewRateLimiter(600, 0)
+	limiter.requests.available = 1
+	r := NewResilientProvider(provider, ResilienceOptions{
+		Timeout:          20 * time.Millisecond,
+		MaxAttempts:      1,
+		FailureThreshold: 1,
+		Limiter:          limiter,
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Chat(context.Background(), testChatRequest); err != nil {
+			t.Fatalf("request %d failed: %v", i+1, err)
+		}
+	}
+	if status := r.CircuitStatus(); status.State != CircuitClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("limiter waits must not count as failures, got %+v", status)
+	}
+
+	// Отмена во время ожидания лимита тоже не считается отказом провайдера
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := r.Chat(ctx, testChatRequest); err == nil {
+		t.Fatal("expected cancelled wait to fail")
+	}
+	if status := r.CircuitStatus(); status.State != CircuitClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("cancelled limiter wait must not count as failure, got %+v", status)
+	}
+}
+
+// TestResilientProviderSettlesActualUsage проверяет замену оценки токенов фактическим расходом
+func TestResilientProviderSettlesActualUsage(t *testing.T) {
+	var waits, retryWaits []time.Duration
+	limiter := newTestRateLimiter(0, 3000, &waits)
+	req := ChatRequest{Messages: []Message{{Role: "user", Content: strings.Repeat("a", 400)}}, MaxTokens: 1900}
+
+	// Оценка 2000 токенов, фактически израсходовано 300: в корзине остается 2700
+	provider := newTestResilient(&usageProvider{usage: Usage{PromptTokens: 100, CompletionTokens: 200}},
+		ResilienceOptions{Limiter: limiter}, &retryWaits)
+	if _, err := provider.Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := limiter.tokens.available; got != 2700 {
+		t.Errorf("expected unused estimate to be refunded, got %v tokens available", got)
+	}
+
+	// Ответ длиннее оценки списывает разницу
+	provider = newTestResilient(&usageProvider{usage: Usage{PromptTokens: 500, CompletionTokens: 2000}},
+		ResilienceOptions{Limiter: limiter}, &retryWaits)
+	if _, err := provider.Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := limiter.tokens.available; got != 200 {
+		t.Errorf("expected overspend to be charged, got %v tokens available", got)
+	}
+	if len(waits) != 0 {
+		t.Errorf("expected no waits, got %v", waits)
+	}
+}
+
+// concurrencyProvider отвечает описаниями для правил из промпта и считает одновременные запросы
+type concurrencyProvider struct {
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (p *concurrencyProvider) Name() string { return "concurrency" }
+
+func (p *concurrencyProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	p.mu.Lock()
+	p.active++
+	if p.active > p.maxSeen {
+		p.maxSeen = p.active
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.active--
+		p.mu.Unlock()
+	}()
+
+	prompt := req.Messages[0].Content
+	prompt = prompt[strings.LastIndex(prompt, "Проблемы:"):]
+	var items []struct {
+		RuleID string `json:"rule_id"`
+	}
+	json.Unmarshal([]byte(prompt[strings.Index(prompt, "["):strings.LastIndex(prompt, "]")+1]), &items)
+
+	// Первые батчи отвечают дольше, чтобы завершиться последними
+	var number int
+	fmt.Sscanf(items[0].RuleID, "rule-%d", &number)
+	time.Sleep(time.Duration(40-number) * time.Millisecond)
+
+	var resp enrichmentResponse
+	for _, item := range items {
+		resp.Items = append(resp.Items, Enrichment{RuleID: item.RuleID, Description: "AI " + item.RuleID, HowToFix: "Исправить", Confidence: 1})
+	}
+	content, _ := json.Marshal(resp)
+	return &ChatResponse{Content: string(content), Model: "concurrency"}, nil
+}
+
+// TestProcessViolationsConcurrentBatches проверяет ограничение параллельности и порядок проблем
+func TestProcessViolationsConcurrentBatches(t *testing.T) {
+	impacts := []string{"critical", "serious", "moderate", "minor"}
+	violations := make([]domain.AxeViolation, 35)
+	for i := range violations {
+		violations[i] = domain.AxeViolation{ID: fmt.Sprintf("rule-%02d", i), Impact: impacts[i%len(impacts)], Help: "help"}
+	}
+
+	provider := &concurrencyProvider{}
+	processor := NewProcessor(provider)
+	processor.concurrency = 3
+
+	var (
+		updates []BatchProgress
+		last    BatchProgress
+	)
+	report, err := processor.ProcessViolations(context.Background(), "https://example.com", violations, "job",
+		ProcessOptions{OnProgress: func(p BatchProgress) {
+			updates = append(updates, p)
+			last = p
+		}})
+	if err != nil {
+		t.Fatalf("ProcessViolations returned error: %v", err)
+	}
+
+	if provider.maxSeen < 2 || provider.maxSeen > 3 {
+		t.Errorf("expected 2-3 concurrent requests, got %d", provider.maxSeen)
+	}
+	if len(updates) != 4 || last.Batch != 4 || last.ProcessedIssues != 35 || last.EnrichedIssues != 35 {
+		t.Errorf("unexpected progress updates: %+v", updates)
+	}
+
+	// Внутри каждой группы проблемы идут в исходном порядке нарушений
+	for level, offset := range map[string]int{"critical": 0, "serious": 1, "moderate": 2, "minor": 3} {
+		issues := report.IssuesByImpact[level]
+		for j, issue := range issues {
+			want := fmt.Sprintf("rule-%02d", offset+4*j)
+			if issue.ID != want || issue.Description != "AI "+want {
+				t.Fatalf("%s issue %d: expected %s, got %s (%q)", level, j, want, issue.ID, issue.Description)
+			}
+		}
+	}
+	if report.Summary.TotalIssues != 35 {
+		t.Errorf("expected 35 issues, got %d", report.Summary.TotalIssues)
+	}
+}
@@ -0,0 +1,320 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается без обращения к API, пока провайдер считается недоступным
+var ErrCircuitOpen = errors.New("LLM provider is temporarily unavailable")
+
+// errAttemptTimeout возвращается, если попытка не уложилась в ResilienceOptions.Timeout
+var errAttemptTimeout = fmt.Errorf("LLM request timed out: %w", context.DeadlineExceeded)
+
+// Состояния circuit breaker
+const (
+	// CircuitClosed - запросы выполняются как обычно
+	CircuitClosed = "closed"
+	// CircuitOpen - провайдер недоступен, запросы сразу завершаются ErrCircuitOpen
+	CircuitOpen = "open"
+	// CircuitHalfOpen - пауза истекла, выполняется пробный запрос
+	CircuitHalfOpen = "half_open"
+)
+
+// ResilienceOptions содержит настройки повторов и circuit breaker
+type ResilienceOptions struct {
+	// Timeout - таймаут одной попытки (0 - без ограничения). Для потокового запроса
+	// ограничивает ожидание первого фрагмента и паузы между фрагментами, а не весь ответ.
+	Timeout time.Duration
+	// MaxAttempts - максимальное число попыток одного запроса
+	MaxAttempts int
+	// Backoff - пауза перед второй попыткой, далее она удваивается
+	Backoff time.Duration
+	// MaxBackoff - верхняя граница паузы. Если Retry-After требует ждать дольше, запрос не повторяется
+	MaxBackoff time.Duration
+	// FailureThreshold - сколько запросов подряд должно завершиться ошибкой, чтобы цепь разомкнулась
+	FailureThreshold int
+	// Cooldown - сколько цепь остается разомкнутой до пробного запроса
+	Cooldown time.Duration
+	// Limiter - общий ограничитель запросов и токенов (nil - без ограничения). Лимит расходует
+	// каждая попытка; ожидание лимита не входит в Timeout и не считается отказом провайдера.
+	Limiter *RateLimiter
+}
+
+// CircuitStatus - состояние circuit breaker для /health
+type CircuitStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	// RetryAt - когда будет выполнен пробный запрос (для разомкнутой цепи)
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// ResilientProvider оборачивает LLMProvider: ограничивает время попытки, повторяет
+// временные ошибки (429, 5xx, сетевые) с экспоненциальной паузой и размыкает цепь,
+// если провайдер недоступен. Пока цепь разомкнута, AIClient использует статические описания.
+type ResilientProvider struct {
+	provider LLMProvider
+	opts     ResilienceOptions
+
+	// now и sleep подменяются в тестах
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	lastError string
+	openedAt  time.Time
+	// probing - в полуоткрытом состоянии уже выполняется пробный запрос
+	probing bool
+}
+
+// NewResilientProvider создает обертку над provider
+func NewResilientProvider(provider LLMProvider, opts ResilienceOptions) *ResilientProvider {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+
+	return &ResilientProvider{
+		provider: provider,
+		opts:     opts,
+		now:      time.Now,
+		sleep:    sleepContext,
+		state:    CircuitClosed,
+	}
+}
+
+// Name возвращает название обернутого провайдера
+func (r *ResilientProvider) Name() string {
+	return r.provider.Name()
+}
+
+// Chat выполняет запрос с повторами. Пока цепь разомкнута, сразу возвращает ErrCircuitOpen.
+func (r *ResilientProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return r.do(ctx, req, func(ctx context.Context, touch func()) (*ChatResponse, error) {
+		return r.provider.Chat(ctx, req)
+	}, nil)
+}
+
+// ChatStream выполняет потоковый запрос. Повтор возможен только до первого фрагмента:
+// уже переданный текст нельзя отозвать. Если обернутый провайдер не поддерживает
+// потоковую передачу, ответ передается в onDelta целиком.
+func (r *ResilientProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	streamed := false
+	return r.do(ctx, req, func(ctx context.Context, touch func()) (*ChatResponse, error) {
+		return chatStream(ctx, r.provider, req, func(text string) {
+			streamed = true
+			touch()
+			onDelta(text)
+		})
+	}, func() bool { return !streamed })
+}
+
+// attemptFunc выполняет одну попытку запроса. touch сообщает, что от провайдера пришли данные,
+// и откладывает таймаут попытки.
+type attemptFunc func(ctx context.Context, touch func()) (*ChatResponse, error)
+
+// do выполняет call с повторами временных ошибок и учетом состояния цепи.
+// canRetry (если задан) запрещает повтор, когда он уже невозможен.
+func (r *ResilientProvider) do(ctx context.Context, req ChatRequest, call attemptFunc, canRetry func() bool) (*ChatResponse, error) {
+	if err := r.acquire(); err != nil {
+		return nil, err
+	}
+
+	estimated := estimateTokens(req)
+	delay := r.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		// Лимит ждем до начала попытки: таймаут попытки относится только к провайдеру
+		if err := r.opts.Limiter.Wait(ctx, estimated); err != nil {
+			r.release()
+			return nil, err
+		}
+
+		resp, err := r.attempt(ctx, call)
+		if err == nil {
+			// Если провайдер не сообщил расход, остается списанной оценка
+			if used := resp.Usage.PromptTokens + resp.Usage.CompletionTokens; used > 0 {
+				r.opts.Limiter.settle(estimated, used)
+			}
+			r.record(nil)
+			return resp, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// Запрос отменил вызывающий: это не говорит о состоянии провайдера
+			r.release()
+			return nil, ctxErr
+		}
+		if !retryableError(err) {
+			// Провайдер ответил, но отклонил запрос: он доступен, повтор не поможет
+			r.record(nil)
+			return nil, err
+		}
+
+		wait := jitter(delay)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			wait = statusErr.RetryAfter
+		}
+		if attempt >= r.opts.MaxAttempts || wait > r.opts.MaxBackoff || (canRetry != nil && !canRetry()) {
+			r.record(err)
+			return nil, err
+		}
+
+		log.Printf("[AI] %s attempt %d failed: %v; retrying in %s", r.provider.Name(), attempt, err, wait.Round(time.Millisecond))
+		if err := r.sleep(ctx, wait); err != nil {
+			r.release()
+			return nil, err
+		}
+
+		delay *= 2
+		if delay > r.opts.MaxBackoff {
+			delay = r.opts.MaxBackoff
+		}
+	}
+}
+
+// attempt выполняет одну попытку с таймаутом. Таймаут отсчитывается заново после каждого
+// touch, поэтому потоковый ответ прерывается, только если провайдер замолчал.
+func (r *ResilientProvider) attempt(ctx context.Context, call attemptFunc) (*ChatResponse, error) {
+	if r.opts.Timeout <= 0 {
+		return call(ctx, func() {})
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	timer := time.AfterFunc(r.opts.Timeout, func() { cancel(errAttemptTimeout) })
+	defer timer.Stop()
+
+	resp, err := call(ctx, func() { timer.Reset(r.opts.Timeout) })
+	if err != nil && errors.Is(context.Cause(ctx), errAttemptTimeout) {
+		return nil, errAttemptTimeout
+	}
+	return resp, err
+}
+
+// CircuitStatus возвращает текущее состояние circuit breaker
+func (r *ResilientProvider) CircuitStatus() CircuitStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := CircuitStatus{
+		State:               r.state,
+		ConsecutiveFailures: r.failures,
+		LastError:           r.lastError,
+	}
+	if r.state != CircuitClosed {
+		openedAt := r.openedAt
+		retryAt := openedAt.Add(r.opts.Cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// acquire проверяет, можно ли выполнить запрос. После паузы пропускает один пробный запрос.
+func (r *ResilientProvider) acquire() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.state {
+	case CircuitOpen:
+		if r.now().Before(r.openedAt.Add(r.opts.Cooldown)) {
+			return ErrCircuitOpen
+		}
+		r.state = CircuitHalfOpen
+		r.probing = true
+	case CircuitHalfOpen:
+		if r.probing {
+			return ErrCircuitOpen
+		}
+		r.probing = true
+	}
+	return nil
+}
+
+// record учитывает результат запроса: err == nil означает, что провайдер доступен
+func (r *ResilientProvider) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.probing = false
+	if err == nil {
+		if r.state != CircuitClosed {
+			log.Printf("[AI] %s is available again, circuit closed", r.provider.Name())
+		}
+		r.state = CircuitClosed
+		r.failures = 0
+		r.lastError = ""
+		return
+	}
+
+	r.failures++
+	r.lastError = err.Error()
+	if r.state == CircuitHalfOpen || r.failures >= r.opts.FailureThreshold {
+		if r.state != CircuitOpen {
+			log.Printf("[AI] %s is unavailable after %d failed requests, circuit opened for %s", r.provider.Name(), r.failures, r.opts.Cooldown)
+		}
+		r.state = CircuitOpen
+		r.openedAt = r.now()
+	}
+}
+
+// release снимает признак пробного запроса, если запрос отменен вызывающим
+func (r *ResilientProvider) release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probing = false
+}
+
+// retryableError сообщает, имеет ли смысл повторять запрос после такой ошибки
+func retryableError(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests ||
+			statusErr.StatusCode >= 500
+	}
+	// Сетевые ошибки и таймаут попытки
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// jitter возвращает случайную паузу в диапазоне [d/2, d], чтобы повторы разных задач не совпадали
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// sleepContext ждет d или отмены ctx
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
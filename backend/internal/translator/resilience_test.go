@@ -0,0 +1,218 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider возвращает заранее заданные ошибки, затем успешный ответ
+type fakeProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return nil, p.errs[p.calls-1]
+	}
+	return &ChatResponse{Content: "ok", Model: "fake"}, nil
+}
+
+// newTestResilient создает обертку без реальных пауз; паузы записываются в waits
+func newTestResilient(provider LLMProvider, opts ResilienceOptions, waits *[]time.Duration) *ResilientProvider {
+	r := NewResilientProvider(provider, opts)
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return r
+}
+
+// TestResilientProviderRetriesTransientErrors проверяет повтор 429/5xx с учетом Retry-After
+func TestResilientProviderRetriesTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("<html><body>Too Many Requests</body></html>"))
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ответ"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	r := newTestResilient(testProvider(server.URL), ResilienceOptions{
+		MaxAttempts: 3,
+		Backoff:     100 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+	}, &waits)
+
+	resp, err := r.Chat(context.Background(), testChatRequest)
+	if err != nil {
+		t.Fatalf("Chat returned error: %v", err)
+	}
+	if resp.Content != "ответ" {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+
+	// Первая пауза взята из Retry-After, вторая - экспоненциальная с jitter
+	if len(waits) != 2 || waits[0] != 2*time.Second {
+		t.Fatalf("expected Retry-After pause first, got %v", waits)
+	}
+	if waits[1] < 100*time.Millisecond || waits[1] > 200*time.Millisecond {
+		t.Errorf("expected jittered backoff in [100ms, 200ms], got %s", waits[1])
+	}
+	if status := r.CircuitStatus(); status.State != CircuitClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected closed circuit, got %+v", status)
+	}
+}
+
+// TestResilientProviderDoesNotRetryClientErrors проверяет, что ошибки запроса не повторяются
+func TestResilientProviderDoesNotRetryClientErrors(t *testing.T) {
+	provider := &fakeProvider{errs: []error{
+		&StatusError{StatusCode: http.StatusUnauthorized, Message: "invalid api key"},
+	}}
+
+	var waits []time.Duration
+	r := newTestResilient(provider, ResilienceOptions{MaxAttempts: 3, FailureThreshold: 1}, &waits)
+
+	_, err := r.Chat(context.Background(), testChatRequest)
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("expected status error, got %v", err)
+	}
+	if provider.calls != 1 || len(waits) != 0 {
+		t.Errorf("expected a single attempt, got %d calls and pauses %v", provider.calls, waits)
+	}
+	// Провайдер ответил, значит доступен: цепь не размыкается
+	if state := r.CircuitStatus().State; state != CircuitClosed {
+		t.Errorf("client errors must not open the circuit, got %s", state)
+	}
+}
+
+// TestResilientProviderAttemptTimeout проверяет таймаут одной попытки
+func TestResilientProviderAttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Сервер замечает разрыв соединения только после чтения тела запроса
+		io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	r := newTestResilient(testProvider(server.URL), ResilienceOptions{
+		Timeout:     50 * time.Millisecond,
+		MaxAttempts: 2,
+	}, &waits)
+
+	started := time.Now()
+	if _, err := r.Chat(context.Background(), testChatRequest); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("attempt timeout was not applied, took %s", elapsed)
+	}
+	if len(waits) != 1 {
+		t.Errorf("timed out attempt should be retried once, got pauses %v", waits)
+	}
+}
+
+// TestResilientProviderCircuitBreaker проверяет размыкание цепи, пробный запрос и восстановление
+func TestResilientProviderCircuitBreaker(t *testing.T) {
+	unavailable := &StatusError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+	provider := &fakeProvider{errs: []error{unavailable, unavailable, unavailable}}
+
+	var waits []time.Duration
+	r := newTestResilient(provider, ResilienceOptions{
+		MaxAttempts:      1,
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	}, &waits)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Chat(context.Background(), testChatRequest); !errors.As(err, new(*StatusError)) {
+			t.Fatalf("expected status error, got %v", err)
+		}
+	}
+
+	status := r.CircuitStatus()
+	if status.State != CircuitOpen || status.ConsecutiveFailures != 2 || status.LastError == "" {
+		t.Fatalf("expected open circuit, got %+v", status)
+	}
+	if status.RetryAt == nil || !status.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("unexpected retry time %v", status.RetryAt)
+	}
+
+	// Пока цепь разомкнута, провайдер не вызывается
+	if _, err := r.Chat(context.Background(), testChatRequest); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("provider must not be called while circuit is open, got %d calls", provider.calls)
+	}
+
+	// Неудачный пробный запрос снова размыкает цепь
+	now = now.Add(time.Minute)
+	if _, err := r.Chat(context.Background(), testChatRequest); errors.Is(err, ErrCircuitOpen) || err == nil {
+		t.Errorf("expected probe request to reach provider, got %v", err)
+	}
+	if state := r.CircuitStatus().State; state != CircuitOpen {
+		t.Errorf("failed probe should reopen circuit, got %s", state)
+	}
+
+	// Успешный пробный запрос замыкает цепь
+	now = now.Add(time.Minute)
+	if _, err := r.Chat(context.Background(), testChatRequest); err != nil {
+		t.Fatalf("expected successful probe, got %v", err)
+	}
+	if status := r.CircuitStatus(); status.State != CircuitClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected closed circuit after recovery, got %+v", status)
+	}
+}
+
+// TestProcessorFallsBackWhenCircuitOpen проверяет статические описания при недоступном провайдере
+func TestProcessorFallsBackWhenCircuitOpen(t *testing.T) {
+	violations, err := loadDemoJSON()
+	if err != nil {
+		t.Fatalf("failed to load demo json: %v", err)
+	}
+
+	r := NewResilientProvider(&fakeProvider{}, ResilienceOptions{Cooldown: time.Hour})
+	r.state = CircuitOpen
+	r.openedAt = time.Now()
+
+	var last BatchProgress
+	opts := ProcessOptions{OnProgress: func(p BatchProgress) { last = p }}
+	report, err := NewProcessor(r).ProcessViolations(context.Background(), "https://example.com", violations, "job", opts)
+	if err != nil {
+		t.Fatalf("ProcessViolations returned error: %v", err)
+	}
+	if report.Summary.TotalIssues != len(violations) {
+		t.Errorf("expected %d issues, got %d", len(violations), report.Summary.TotalIssues)
+	}
+	if last.EnrichedIssues != 0 {
+		t.Errorf("expected no AI-enriched issues while circuit is open, got %d", last.EnrichedIssues)
+	}
+}
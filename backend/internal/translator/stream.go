@@ -0,0 +1,135 @@
+package translator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// StreamingProvider - LLMProvider, который умеет отдавать ответ по мере генерации
+type StreamingProvider interface {
+	LLMProvider
+	// ChatStream выполняет запрос с потоковой передачей ответа: каждый полученный
+	// фрагмент текста передается в onDelta, итоговый ответ возвращается после завершения.
+	ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error)
+}
+
+// chatStream выполняет потоковый запрос, если провайдер его поддерживает.
+// Иначе выполняет обычный запрос и передает ответ в onDelta целиком.
+func chatStream(ctx context.Context, provider LLMProvider, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	if streaming, ok := provider.(StreamingProvider); ok {
+		return streaming.ChatStream(ctx, req, onDelta)
+	}
+
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	onDelta(resp.Content)
+	return resp, nil
+}
+
+// readSSE читает поток Server-Sent Events и вызывает fn для каждого события
+func readSSE(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := fn(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	if len(data) > 0 {
+		return fn(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
+
+// thinkFilter убирает блоки <think>...</think> из текста, поступающего фрагментами.
+// Тег может прийти разрезанным между фрагментами, поэтому возможное начало тега
+// придерживается до следующего фрагмента. Как и removeThinkTags, незакрытый блок
+// удаляется до конца текста, а пробелы в начале ответа отбрасываются.
+type thinkFilter struct {
+	pending string
+	inThink bool
+	started bool
+}
+
+// Write принимает очередной фрагмент и возвращает текст, который можно показать
+func (f *thinkFilter) Write(chunk string) string {
+	f.pending += chunk
+
+	var out strings.Builder
+	for {
+		if f.inThink {
+			end := strings.Index(f.pending, "</think>")
+			if end == -1 {
+				f.pending = f.pending[len(f.pending)-partialTagSuffix(f.pending, "</think>"):]
+				break
+			}
+			f.pending = f.pending[end+len("</think>"):]
+			f.inThink = false
+			continue
+		}
+
+		start := strings.Index(f.pending, "<think>")
+		if start == -1 {
+			keep := partialTagSuffix(f.pending, "<think>")
+			out.WriteString(f.pending[:len(f.pending)-keep])
+			f.pending = f.pending[len(f.pending)-keep:]
+			break
+		}
+		out.WriteString(f.pending[:start])
+		f.pending = f.pending[start+len("<think>"):]
+		f.inThink = true
+	}
+	return f.visible(out.String())
+}
+
+// Flush возвращает придержанный остаток после завершения потока
+func (f *thinkFilter) Flush() string {
+	rest := f.pending
+	f.pending = ""
+	if f.inThink {
+		return ""
+	}
+	return f.visible(rest)
+}
+
+// visible отбрасывает пробелы до начала ответа
+func (f *thinkFilter) visible(text string) string {
+	if !f.started {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		f.started = text != ""
+	}
+	return text
+}
+
+// partialTagSuffix возвращает длину самого длинного окончания s, с которого может начинаться tag
+func partialTagSuffix(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
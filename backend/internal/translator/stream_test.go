@@ -0,0 +1,254 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// TestThinkFilterMatchesRemoveThinkTags проверяет фильтр при любом разбиении текста на фрагменты
+func TestThinkFilterMatchesRemoveThinkTags(t *testing.T) {
+	inputs := []string{
+		"<think>рассуждения</think>\n\nОбщая оценка: хорошо",
+		"  Начало <think>a</think>середина<think>b</think> конец  ",
+		"Текст с < и <thin без тега",
+		"Ответ<think>незакрытый блок",
+		"<think></think><think>x</think>",
+	}
+
+	for _, input := range inputs {
+		runes := []rune(input)
+		for i := 0; i <= len(runes); i++ {
+			for j := i; j <= len(runes); j++ {
+				var f thinkFilter
+				out := f.Write(string(runes[:i])) + f.Write(string(runes[i:j])) + f.Write(string(runes[j:])) + f.Flush()
+				if got, want := strings.TrimSpace(out), removeThinkTags(input); got != want {
+					t.Fatalf("input %q split at %d/%d: got %q, want %q", input, i, j, got, want)
+				}
+			}
+		}
+	}
+}
+
+// TestProvidersChatStream проверяет разбор потоковых ответов каждого API
+func TestProvidersChatStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		response string
+	}{
+		{
+			name:     "openai",
+			provider: ProviderOpenAI,
+			response: `data: {"model":"stream-model","choices":[{"delta":{"role":"assistant","content":"При"}}]}` + "\n\n" +
+				`data: {"choices":[{"delta":{"content":"вет"}}]}` + "\n\n" +
+				`data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}` + "\n\n" +
+				"data: [DONE]\n\n",
+		},
+		{
+			name:     "anthropic",
+			provider: ProviderAnthropic,
+			response: "event: message_start\n" + `data: {"type":"message_start","message":{"model":"stream-model","usage":{"input_tokens":7}}}` + "\n\n" +
+				"event: content_block_start\n" + `data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+				"event: content_block_delta\n" + `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"При"}}` + "\n\n" +
+				"event: ping\n" + `data: {"type":"ping"}` + "\n\n" +
+				"event: content_block_delta\n" + `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"вет"}}` + "\n\n" +
+				"event: message_delta\n" + `data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}` + "\n\n" +
+				"event: message_stop\n" + `data: {"type":"message_stop"}` + "\n\n",
+		},
+		{
+			name:     "ollama",
+			provider: ProviderOllama,
+			response: `{"model":"stream-model","message":{"role":"assistant","content":"При"},"done":false}` + "\n" +
+				`{"model":"stream-model","message":{"role":"assistant","content":"вет"},"done":false}` + "\n" +
+				`{"model":"stream-model","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":7,"eval_count":3}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newLLMStub(t, http.StatusOK, tt.response)
+			provider, err := NewProvider(ProviderConfig{Provider: tt.provider, APIKey: "key", BaseURL: stub.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var deltas []string
+			resp, err := provider.(StreamingProvider).ChatStream(context.Background(), testChatRequest, func(text string) {
+				deltas = append(deltas, text)
+			})
+			if err != nil {
+				t.Fatalf("ChatStream returned error: %v", err)
+			}
+			if strings.Join(deltas, "|") != "При|вет" {
+				t.Errorf("unexpected deltas %q", deltas)
+			}
+			if resp.Content != "Привет" || resp.Model != "stream-model" || resp.Usage != (Usage{PromptTokens: 7, CompletionTokens: 3}) {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+			if stub.body["stream"] != true {
+				t.Errorf("expected stream flag in request, got %v", stub.body["stream"])
+			}
+		})
+	}
+}
+
+// streamProvider передает фрагменты ответа и завершается ошибкой из errs
+type streamProvider struct {
+	fakeProvider
+	// deltas - фрагменты, которые передаются перед ошибкой или ответом
+	deltas []string
+}
+
+func (p *streamProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	p.calls++
+	for _, delta := range p.deltas {
+		onDelta(delta)
+	}
+	if p.calls <= len(p.errs) {
+		return nil, p.errs[p.calls-1]
+	}
+	return &ChatResponse{Content: strings.Join(p.deltas, ""), Model: "fake"}, nil
+}
+
+// TestResilientProviderStreamRetriesOnlyBeforeFirstDelta проверяет, что уже начатый поток не повторяется
+func TestResilientProviderStreamRetriesOnlyBeforeFirstDelta(t *testing.T) {
+	unavailable := &StatusError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+	opts := ResilienceOptions{MaxAttempts: 3, Backoff: time.Millisecond}
+	var waits []time.Duration
+
+	// Ошибка до первого фрагмента повторяется
+	provider := &streamProvider{fakeProvider: fakeProvider{errs: []error{unavailable}}}
+	r := newTestResilient(provider, opts, &waits)
+	if _, err := r.ChatStream(context.Background(), testChatRequest, func(string) {}); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected 2 calls, got %d", provider.calls)
+	}
+
+	// Ошибка после переданного фрагмента возвращается сразу
+	provider = &streamProvider{fakeProvider: fakeProvider{errs: []error{unavailable}}, deltas: []string{"начало"}}
+	r = newTestResilient(provider, opts, &waits)
+	if _, err := r.ChatStream(context.Background(), testChatRequest, func(string) {}); err != unavailable {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected no retry after delta, got %d calls", provider.calls)
+	}
+
+	// Провайдер без потоковой передачи отдает ответ одним фрагментом
+	r = newTestResilient(&fakeProvider{}, opts, &waits)
+	var deltas []string
+	if _, err := r.ChatStream(context.Background(), testChatRequest, func(text string) { deltas = append(deltas, text) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 1 || deltas[0] != "ok" {
+		t.Errorf("expected single delta, got %q", deltas)
+	}
+}
+
+// slowStreamProvider передает фрагменты с паузой pause, а после них молчит stall
+// (или до отмены запроса)
+type slowStreamProvider struct {
+	fakeProvider
+	deltas []string
+	pause  time.Duration
+	stall  time.Duration
+}
+
+func (p *slowStreamProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(text string)) (*ChatResponse, error) {
+	for _, delta := range p.deltas {
+		if err := sleepContext(ctx, p.pause); err != nil {
+			return nil, err
+		}
+		onDelta(delta)
+	}
+	if err := sleepContext(ctx, p.stall); err != nil {
+		return nil, err
+	}
+	return &ChatResponse{Content: strings.Join(p.deltas, ""), Model: "fake"}, nil
+}
+
+// TestResilientProviderStreamIdleTimeout проверяет, что таймаут попытки ограничивает паузы
+// потока, а не весь ответ: длинный поток завершается, замолчавший прерывается
+func TestResilientProviderStreamIdleTimeout(t *testing.T) {
+	opts := ResilienceOptions{Timeout: 100 * time.Millisecond, MaxAttempts: 2, Backoff: time.Millisecond}
+	var waits []time.Duration
+
+	// Поток идет дольше таймаута, но фрагменты приходят чаще
+	provider := &slowStreamProvider{deltas: []string{"a", "b", "c", "d", "e", "f"}, pause: 30 * time.Millisecond}
+	r := newTestResilient(provider, opts, &waits)
+	resp, err := r.ChatStream(context.Background(), testChatRequest, func(string) {})
+	if err != nil || resp.Content != "abcdef" {
+		t.Fatalf("expected the whole stream, got %+v, %v", resp, err)
+	}
+
+	// После первого фрагмента провайдер замолчал: попытка прерывается по таймауту без повтора
+	provider = &slowStreamProvider{deltas: []string{"начало"}, stall: 5 * time.Second}
+	r = newTestResilient(provider, opts, &waits)
+	started := time.Now()
+	if _, err := r.ChatStream(context.Background(), testChatRequest, func(string) {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("idle stream was not interrupted, took %s", elapsed)
+	}
+	if len(waits) != 0 {
+		t.Errorf("started stream must not be retried, got pauses %v", waits)
+	}
+}
+
+// TestTranslatorStreamSummarySavesReport проверяет фильтрацию потока и сохранение резюме в отчёте
+func TestTranslatorStreamSummarySavesReport(t *testing.T) {
+	stub := newLLMStub(t, http.StatusOK,
+		`data: {"model":"test-model","choices":[{"delta":{"content":"<thi"}}]}`+"\n\n"+
+			`data: {"choices":[{"delta":{"content":"nk>план</think>\n\nОбщая "}}]}`+"\n\n"+
+			`data: {"choices":[{"delta":{"content":"оценка"}}],"usage":{"prompt_tokens":10,"completion_tokens":4}}`+"\n\n"+
+			"data: [DONE]\n\n")
+
+	storage := service.NewMemoryStorage()
+	trans := NewTranslator(testProvider(stub.URL), storage, Options{Workers: 1})
+
+	job := service.NewJob("https://example.com")
+	storage.SaveJob(job)
+	storage.SaveReport(&domain.Report{ID: job.ID, URL: job.URL})
+
+	var streamed strings.Builder
+	summary, err := trans.StreamSummary(context.Background(), job.ID, i18n.Russian, "{}", func(text string) {
+		streamed.WriteString(text)
+	})
+	if err != nil {
+		t.Fatalf("StreamSummary returned error: %v", err)
+	}
+	if summary != "Общая оценка" || streamed.String() != "Общая оценка" {
+		t.Errorf("unexpected summary %q, streamed %q", summary, streamed.String())
+	}
+
+	report, _ := storage.GetReport(job.ID)
+	if report.ExecutiveSummary != "Общая оценка" || report.ExecutiveSummaryAt == nil {
+		t.Errorf("summary not saved: %q at %v", report.ExecutiveSummary, report.ExecutiveSummaryAt)
+	}
+	if got, _ := storage.GetJob(job.ID); got.Usage == nil || got.Usage.TotalTokens != 14 {
+		t.Errorf("expected usage to be recorded, got %+v", got.Usage)
+	}
+
+	// В демо-режиме заглушка не сохраняется
+	demoStorage := service.NewMemoryStorage()
+	demo := NewTranslator(nil, demoStorage, Options{Workers: 1})
+	demoStorage.SaveJob(job)
+	demoStorage.SaveReport(&domain.Report{ID: job.ID, URL: job.URL})
+	if _, err := demo.GenerateSummary(context.Background(), job.ID, i18n.Russian, "{}"); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ := demoStorage.GetReport(job.ID); report.ExecutiveSummary != "" {
+		t.Errorf("demo summary should not be saved, got %q", report.ExecutiveSummary)
+	}
+}
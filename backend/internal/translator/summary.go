@@ -0,0 +1,65 @@
+package translator
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+)
+
+// SummaryReportJSON формирует упрощённую версию отчёта для резюме (только ключевые данные).
+// Отправка полного JSON с тысячами строк технических деталей даёт странные результаты.
+func SummaryReportJSON(report *domain.Report) ([]byte, error) {
+	type SimplifiedIssue struct {
+		Impact      string `json:"impact"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Count       int    `json:"count"`
+	}
+
+	type SimplifiedReport struct {
+		URL         string            `json:"url"`
+		TotalIssues int               `json:"total_issues"`
+		Critical    int               `json:"critical"`
+		Serious     int               `json:"serious"`
+		Moderate    int               `json:"moderate"`
+		Minor       int               `json:"minor"`
+		Issues      []SimplifiedIssue `json:"issues"`
+	}
+
+	// Уровни перебираются в постоянном порядке, чтобы один и тот же отчет давал один и тот же
+	// промпт: по нему ищутся записанные ответы модели (LLM_FIXTURE_MODE=replay)
+	impacts := make([]string, 0, len(report.IssuesByImpact))
+	for impact := range report.IssuesByImpact {
+		impacts = append(impacts, impact)
+	}
+	sort.Slice(impacts, func(i, j int) bool {
+		if a, b := domain.ImpactRank(impacts[i]), domain.ImpactRank(impacts[j]); a != b {
+			return a < b
+		}
+		return impacts[i] < impacts[j]
+	})
+
+	// Собираем упрощённые данные
+	var simplifiedIssues []SimplifiedIssue
+	for _, impact := range impacts {
+		for _, issue := range report.IssuesByImpact[impact] {
+			simplifiedIssues = append(simplifiedIssues, SimplifiedIssue{
+				Impact:      issue.Impact,
+				Title:       issue.Title,
+				Description: issue.Description,
+				Count:       issue.AffectedElements,
+			})
+		}
+	}
+
+	return json.Marshal(SimplifiedReport{
+		URL:         report.URL,
+		TotalIssues: report.Summary.TotalIssues,
+		Critical:    report.Summary.Critical,
+		Serious:     report.Summary.Serious,
+		Moderate:    report.Summary.Moderate,
+		Minor:       report.Summary.Minor,
+		Issues:      simplifiedIssues,
+	})
+}
@@ -1,64 +1,534 @@
 package translator
 
 import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
 	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/redact"
 	"github.com/danil/accessibility-analyzer/internal/service"
 )
 
+// Options содержит настройки обработки анализов
+type Options struct {
+	// Workers - число задач, обрабатываемых одновременно
+	Workers int
+	// QueueLength - максимальное число задач, ожидающих обработки (0 - без ограничения)
+	QueueLength int
+	// RetryAfter - через сколько клиенту стоит повторить запрос при заполненной очереди
+	RetryAfter time.Duration
+	// Notifier получает задачи, перешедшие в итоговый статус (может быть nil)
+	Notifier JobNotifier
+	// EnrichmentCacheTTL - сколько хранить описания от AI в кэше (0 - кэш отключен)
+	EnrichmentCacheTTL time.Duration
+	// AIConcurrency - сколько батчей одного анализа отправляется в AI одновременно
+	AIConcurrency int
+	// Prices - цены моделей для оценки стоимости анализа (может быть nil)
+	Prices PriceTable
+	// NodeFixesPerRule - для скольких элементов каждого правила запрашивать у AI исправленный HTML
+	// (0 - исправления отдельных элементов отключены)
+	NodeFixesPerRule int
+	// NodeFixesMax - общий лимит таких элементов на анализ (0 - без ограничения)
+	NodeFixesMax int
+	// Redactor убирает персональные данные и секреты из промптов (nil - промпты не меняются)
+	Redactor *redact.Redactor
+	// Prompts - шаблоны промптов (nil - встроенные, см. LoadPrompts)
+	Prompts *Prompts
+}
+
+// JobNotifier уведомляет внешние системы о завершении задач
+type JobNotifier interface {
+	JobFinished(job *service.Job)
+}
+
+var (
+	// ErrJobFinished возвращается при попытке отменить уже завершенную задачу
+	ErrJobFinished = errors.New("job is already finished")
+	// ErrInvalidJobStatus возвращается, если операция недоступна в текущем статусе задачи
+	ErrInvalidJobStatus = errors.New("operation is not allowed in the current job status")
+
+	// errJobNotActive означает, что задачу отменили или удалили во время обработки
+	errJobNotActive = errors.New("job is no longer active")
+)
+
+// eventHistorySize - сколько последних событий задачи хранится для переподключения клиентов
+const eventHistorySize = 100
+
 // Translator обрабатывает анализ и создает отчеты
 type Translator struct {
 	processor *Processor
-	storage   *service.Storage
+	storage   service.Storage
+	queue     *jobQueue
+	events    *service.EventBus
+	opts      Options
+
+	// running хранит функции отмены для задач, которые сейчас обрабатываются
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
 }
 
-// NewTranslator создает новый транслятор
-func NewTranslator(apiKey string, storage *service.Storage) *Translator {
-	aiClient := NewAIClient(apiKey)
-	processor := NewProcessor(aiClient)
+// NewTranslator создает новый транслятор и запускает обработчиков очереди.
+// Если provider равен nil, анализ выполняется в демо-режиме без обращения к LLM.
+func NewTranslator(provider LLMProvider, storage service.Storage, opts Options) *Translator {
+	processor := NewProcessor(provider)
+	if opts.EnrichmentCacheTTL > 0 {
+		processor.aiClient.cache = newEnrichmentCache(storage, opts.EnrichmentCacheTTL)
+	}
+	if opts.AIConcurrency > 1 {
+		processor.concurrency = opts.AIConcurrency
+	}
+	processor.aiClient.prices = opts.Prices
+	processor.nodeFixesPerRule = opts.NodeFixesPerRule
+	processor.nodeFixesMax = opts.NodeFixesMax
+	processor.aiClient.redactor = opts.Redactor
+	if opts.Prompts != nil {
+		processor.aiClient.prompts = opts.Prompts
+	}
+
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
 
-	return &Translator{
+	t := &Translator{
 		processor: processor,
 		storage:   storage,
+		events:    service.NewEventBus(eventHistorySize),
+		opts:      opts,
+		running:   make(map[string]context.CancelFunc),
 	}
+	t.queue = newJobQueue(opts.Workers, opts.QueueLength, t.process)
+
+	return t
 }
 
-// ProcessAnalysis обрабатывает анализ асинхронно
-func (t *Translator) ProcessAnalysis(job *service.Job, violations []domain.AxeViolation) {
+// ProcessAnalysis ставит анализ задачи job по запросу req в очередь на асинхронную обработку.
+// Возвращает ErrQueueFull, если очередь заполнена.
+func (t *Translator) ProcessAnalysis(job *service.Job, req *domain.AnalysisRequest) error {
+	return t.queue.Enqueue(&analysisTask{
+		jobID:      job.ID,
+		url:        job.URL,
+		locale:     req.Locale,
+		violations: req.Violations,
+	})
+}
+
+// Shutdown перестает принимать задачи и ждет, пока обработчики завершат текущие.
+// Задачи, оставшиеся в очереди, остаются в хранилище в статусе pending и возобновляются
+// при следующем запуске (см. ResumeInterrupted). Если ctx истекает раньше, выполняющиеся
+// задачи прерываются и тоже будут возобновлены при запуске.
+func (t *Translator) Shutdown(ctx context.Context) error {
+	if left := t.queue.Close(); left > 0 {
+		log.Printf("[Translator] %d queued jobs will resume after restart", left)
+	}
+
+	done := make(chan struct{})
 	go func() {
-		// Обновляем статус
-		job.UpdateStatus(service.StatusProcessing)
-		job.SetProgress(10)
-		t.storage.SaveJob(job)
+		t.queue.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	log.Printf("[Translator] Drain timeout exceeded, interrupting %d running jobs", len(t.running))
+	for _, cancel := range t.running {
+		cancel()
+	}
+	t.mu.Unlock()
+
+	<-done
+	return ctx.Err()
+}
+
+// ResumeInterrupted находит задачи, оставшиеся в статусе pending или processing после
+// остановки сервера, и ставит их в очередь заново по сохраненному запросу.
+// Если resume выключен или запрос не сохранен, задача помечается как failed.
+func (t *Translator) ResumeInterrupted(resume bool) (requeued, failed int, err error) {
+	jobs, err := t.storage.ListJobs()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, job := range jobs {
+		if job.Status != service.StatusPending && job.Status != service.StatusProcessing {
+			continue
+		}
 
-		// Обрабатываем нарушения
-		job.SetProgress(50)
-		t.storage.SaveJob(job)
+		var req *domain.AnalysisRequest
+		if resume {
+			req, err = t.storage.GetRequest(job.ID)
+			if err != nil && !errors.Is(err, service.ErrRequestNotFound) {
+				return requeued, failed, err
+			}
+		}
+
+		if req == nil {
+			failedJob, err := t.storage.UpdateJob(job.ID, func(j *service.Job) error {
+				j.SetError("analysis was interrupted by a server restart")
+				return nil
+			})
+			if errors.Is(err, service.ErrJobNotFound) {
+				continue
+			}
+			if err != nil {
+				return requeued, failed, err
+			}
+			// Как и при других ошибках, о завершении задачи сообщают событие и вебхук
+			t.publish(failedJob)
+			failed++
+			continue
+		}
 
-		report, err := t.processor.ProcessViolations(job.URL, violations, job.ID)
+		requeuedJob, err := t.storage.UpdateJob(job.ID, func(j *service.Job) error {
+			j.Requeue()
+			return nil
+		})
+		if errors.Is(err, service.ErrJobNotFound) {
+			continue
+		}
 		if err != nil {
-			job.SetError(err.Error())
-			t.storage.SaveJob(job)
-			return
+			return requeued, failed, err
+		}
+		if err := t.queue.EnqueueUnbounded(&analysisTask{
+			jobID:      requeuedJob.ID,
+			url:        requeuedJob.URL,
+			locale:     req.Locale,
+			violations: req.Violations,
+		}); err != nil {
+			return requeued, failed, err
 		}
+		requeued++
+	}
+
+	return requeued, failed, nil
+}
 
-		// Сохраняем отчет
-		job.SetProgress(90)
-		t.storage.SaveJob(job)
+// QueuePosition возвращает позицию задачи в очереди (с 1) или 0, если задача не ожидает
+func (t *Translator) QueuePosition(jobID string) int {
+	return t.queue.Position(jobID)
+}
 
-		if err := t.storage.SaveReport(report); err != nil {
-			job.SetError(err.Error())
-			t.storage.SaveJob(job)
-			return
+// Events возвращает шину событий задач
+func (t *Translator) Events() *service.EventBus {
+	return t.events
+}
+
+// RetryAfter возвращает рекомендуемую задержку перед повтором при заполненной очереди
+func (t *Translator) RetryAfter() time.Duration {
+	return t.opts.RetryAfter
+}
+
+// Cancel отменяет задачу: убирает ее из очереди или прерывает обработку,
+// включая выполняющиеся запросы к AI. Возвращает ErrJobFinished, если задача уже завершена.
+func (t *Translator) Cancel(jobID string) (*service.Job, error) {
+	return t.cancel(jobID, true)
+}
+
+// Delete отменяет незавершенную задачу и удаляет ее из хранилища. Уведомление об отмене
+// не отправляется: задачи, о которой оно сообщает, больше нет.
+func (t *Translator) Delete(jobID string) error {
+	if _, err := t.cancel(jobID, false); err != nil && !errors.Is(err, ErrJobFinished) {
+		return err
+	}
+	return t.storage.DeleteJob(jobID)
+}
+
+// cancel отменяет задачу; notify отправляет уведомление о ее завершении
+func (t *Translator) cancel(jobID string, notify bool) (*service.Job, error) {
+	job, err := t.storage.UpdateJob(jobID, func(j *service.Job) error {
+		if j.Status.IsFinal() {
+			return ErrJobFinished
+		}
+		j.Cancel()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if notify {
+		t.publish(job)
+	} else {
+		t.events.Publish(job.ID, service.EventDone, job)
+	}
+
+	if t.queue.Remove(jobID) {
+		log.Printf("[Translator] Job %s removed from queue", jobID)
+	}
+
+	t.mu.Lock()
+	cancel, running := t.running[jobID]
+	t.mu.Unlock()
+	if running {
+		cancel()
+		log.Printf("[Translator] Job %s cancelled during processing", jobID)
+	}
+
+	return job, nil
+}
+
+// Retry повторно ставит в очередь задачу, завершившуюся ошибкой
+func (t *Translator) Retry(jobID string) (*service.Job, error) {
+	return t.restart(jobID, service.StatusFailed)
+}
+
+// Reprocess заново обрабатывает завершенную задачу с текущими правилами и настройками AI.
+// Предыдущий отчет сохраняется как ревизия.
+func (t *Translator) Reprocess(jobID string) (*service.Job, error) {
+	return t.restart(jobID, service.StatusCompleted)
+}
+
+// restart возвращает задачу в статусе from в очередь, используя сохраненный исходный запрос
+func (t *Translator) restart(jobID string, from service.JobStatus) (*service.Job, error) {
+	previous, err := t.storage.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status != from {
+		return nil, ErrInvalidJobStatus
+	}
+
+	req, err := t.storage.GetRequest(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := t.storage.UpdateJob(jobID, func(j *service.Job) error {
+		if j.Status != from {
+			return ErrInvalidJobStatus
 		}
+		j.Requeue()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.ProcessAnalysis(job, req); err != nil {
+		// Не удалось встать в очередь: возвращаем задаче прежнее состояние
+		// Подписчики еще не видели статус pending, поэтому событие не публикуем
+		t.storage.UpdateJob(jobID, func(j *service.Job) error {
+			if j.Status != service.StatusPending {
+				return errJobNotActive
+			}
+			*j = *previous
+			return nil
+		})
+		return nil, err
+	}
+	t.publish(job)
+
+	log.Printf("[Translator] Job %s requeued from %s", jobID, from)
+	return job, nil
+}
+
+// process обрабатывает одну задачу из очереди.
+// Задача не изменяется напрямую: все обновления проходят через Storage.UpdateJob,
+// поэтому параллельные чтения статуса всегда видят согласованную копию.
+func (t *Translator) process(task *analysisTask) {
+	jobID := task.jobID
 
-		// Завершаем задачу
-		job.UpdateStatus(service.StatusCompleted)
-		job.SetProgress(100)
-		t.storage.SaveJob(job)
+	// Контекст регистрируем до смены статуса, чтобы Cancel, увидевший статус
+	// processing, гарантированно нашел и отменил его
+	ctx, cancel := context.WithCancel(context.Background())
+	t.mu.Lock()
+	t.running[jobID] = cancel
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.running, jobID)
+		t.mu.Unlock()
+		cancel()
 	}()
+
+	// Обновляем статус, если задачу не отменили, пока она ждала в очереди
+	job, err := t.storage.UpdateJob(jobID, func(j *service.Job) error {
+		if j.Status != service.StatusPending {
+			return errJobNotActive
+		}
+		j.UpdateStatus(service.StatusProcessing)
+		j.SetProgress(10)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[Translator] Skipping job %s: %v", jobID, err)
+		return
+	}
+	t.publish(job)
+
+	// Обрабатываем нарушения: прогресс растет от 10 до 90 по мере обработки батчей
+	opts := ProcessOptions{
+		Locale: task.locale,
+		OnProgress: func(progress BatchProgress) {
+			err := t.updateJob(jobID, func(j *service.Job) {
+				j.SetProgress(10 + 80*progress.Batch/progress.TotalBatches)
+			})
+			if err == nil {
+				t.events.Publish(jobID, service.EventProgress, progress)
+			}
+		},
+	}
+
+	meter := &usageMeter{}
+	report, err := t.processor.ProcessViolations(withUsageMeter(ctx, meter), task.url, task.violations, jobID, opts)
+	// Потраченные токены учитываем и для отмененной задачи
+	t.recordUsage(jobID, meter)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		t.updateJob(jobID, func(j *service.Job) { j.SetError(err.Error()) })
+		return
+	}
+
+	// Сохраняем отчет
+	if err := t.updateJob(jobID, func(j *service.Job) { j.SetProgress(90) }); err != nil {
+		return
+	}
+
+	// При повторной обработке предыдущий отчет сохраняем как ревизию
+	if previous, err := t.storage.GetReport(jobID); err == nil {
+		if err := t.storage.SaveReportRevision(previous); err != nil {
+			t.updateJob(jobID, func(j *service.Job) { j.SetError(err.Error()) })
+			return
+		}
+		report.Revision = previous.Revision + 1
+	}
+
+	if err := t.storage.SaveReport(report); err != nil {
+		t.updateJob(jobID, func(j *service.Job) { j.SetError(err.Error()) })
+		return
+	}
+
+	// Завершаем задачу
+	err = t.updateJob(jobID, func(j *service.Job) {
+		j.UpdateStatus(service.StatusCompleted)
+		j.SetProgress(100)
+	})
+	if errors.Is(err, service.ErrJobNotFound) {
+		// Задачу удалили, пока сохранялся отчет: не оставляем отчет без задачи
+		t.storage.DeleteJob(jobID)
+	}
+}
+
+// recordUsage добавляет расход токенов из meter к задаче и к журналу расхода за текущий день.
+// Событие не публикуется: расход попадет в следующий снимок задачи.
+func (t *Translator) recordUsage(jobID string, meter *usageMeter) {
+	usage := meter.total()
+	if usage.Requests == 0 {
+		return
+	}
+	// Журнал ведется отдельно от задач: расход остается в нем после удаления задачи
+	// и относится ко дню запроса, а не ко дню создания задачи
+	if err := t.storage.AddDailyUsage(service.UsageDate(time.Now()), usage); err != nil {
+		log.Printf("[Translator] Daily usage not recorded: %v", err)
+	}
+	_, err := t.storage.UpdateJob(jobID, func(j *service.Job) error {
+		j.AddUsage(usage)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[Translator] Usage of job %s not recorded: %v", jobID, err)
+	}
+}
+
+// updateJob атомарно применяет изменение к задаче, пока она обрабатывается.
+// Если задачу отменили или удалили, изменение не применяется и возвращается ошибка.
+func (t *Translator) updateJob(jobID string, update func(j *service.Job)) error {
+	job, err := t.storage.UpdateJob(jobID, func(j *service.Job) error {
+		if j.Status != service.StatusProcessing {
+			return errJobNotActive
+		}
+		update(j)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[Translator] Job %s not updated: %v", jobID, err)
+		return err
+	}
+	t.publish(job)
+	return nil
+}
+
+// publish оповещает подписчиков об изменении задачи.
+// Для завершенной задачи публикуется итоговое событие EventDone и отправляется уведомление.
+func (t *Translator) publish(job *service.Job) {
+	if !job.Status.IsFinal() {
+		t.events.Publish(job.ID, service.EventStatus, job)
+		return
+	}
+
+	t.events.Publish(job.ID, service.EventDone, job)
+	if t.opts.Notifier != nil {
+		t.opts.Notifier.JobFinished(job)
+	}
 }
 
-// GenerateSummary генерирует комплексное резюме по отчёту через AI
-func (t *Translator) GenerateSummary(reportJSON string) (string, error) {
-	return t.processor.aiClient.GenerateSummary(reportJSON)
+// GenerateSummary генерирует комплексное резюме по отчёту задачи jobID на языке locale через AI,
+// сохраняет его в отчёте и учитывает расход токенов в задаче.
+// Пока провайдер недоступен, возвращает ErrCircuitOpen.
+func (t *Translator) GenerateSummary(ctx context.Context, jobID, locale, reportJSON string) (string, error) {
+	return t.summarize(ctx, jobID, func(ctx context.Context) (string, error) {
+		return t.processor.aiClient.GenerateSummary(ctx, locale, reportJSON)
+	})
+}
+
+// StreamSummary генерирует резюме как GenerateSummary, передавая текст в onDelta по мере генерации
+func (t *Translator) StreamSummary(ctx context.Context, jobID, locale, reportJSON string, onDelta func(text string)) (string, error) {
+	return t.summarize(ctx, jobID, func(ctx context.Context) (string, error) {
+		return t.processor.aiClient.StreamSummary(ctx, locale, reportJSON, onDelta)
+	})
+}
+
+// summarize выполняет generate со счетчиком токенов и сохраняет готовое резюме в отчёте.
+// Заглушка демо-режима не сохраняется, чтобы после настройки провайдера резюме сгенерировалось заново.
+func (t *Translator) summarize(ctx context.Context, jobID string, generate func(ctx context.Context) (string, error)) (string, error) {
+	meter := &usageMeter{}
+	summary, err := generate(withUsageMeter(ctx, meter))
+	t.recordUsage(jobID, meter)
+	if err != nil || t.processor.aiClient.provider == nil {
+		return summary, err
+	}
+
+	if err := t.storage.SaveReportSummary(jobID, summary, time.Now()); err != nil {
+		log.Printf("[Translator] Summary of job %s not saved: %v", jobID, err)
+	}
+	return summary, nil
+}
+
+// AIStatus - состояние подключения к LLM
+type AIStatus struct {
+	// Mode - "live" или "demo" (провайдер не настроен)
+	Mode     string `json:"mode"`
+	Provider string `json:"provider,omitempty"`
+	// Circuit - состояние circuit breaker, если повторы включены
+	Circuit *CircuitStatus `json:"circuit,omitempty"`
+	// Cache - счетчики кэша описаний, если он включен
+	Cache *CacheStats `json:"cache,omitempty"`
+}
+
+// AIStatus возвращает состояние подключения к LLM
+func (t *Translator) AIStatus() AIStatus {
+	provider := t.processor.aiClient.provider
+	if provider == nil {
+		return AIStatus{Mode: "demo"}
+	}
+
+	status := AIStatus{Mode: "live", Provider: provider.Name()}
+	if resilient, ok := provider.(*ResilientProvider); ok {
+		circuit := resilient.CircuitStatus()
+		status.Circuit = &circuit
+	}
+	if cache := t.processor.aiClient.cache; cache != nil {
+		stats := cache.Stats()
+		status.Cache = &stats
+	}
+	return status
 }
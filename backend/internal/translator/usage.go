@@ -0,0 +1,99 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// ModelPrice - цена модели в долларах за миллион токенов
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// PriceTable - цены по названию модели. Ключ может быть префиксом названия:
+// "claude-sonnet-4-5" подходит и для "claude-sonnet-4-5-20250929".
+type PriceTable map[string]ModelPrice
+
+// ParsePriceTable разбирает цены вида "model=input:output,model2=input:output"
+// (доллары за миллион токенов промпта и ответа)
+func ParsePriceTable(value string) (PriceTable, error) {
+	prices := PriceTable{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		model, price, ok := strings.Cut(item, "=")
+		input, output, ok2 := strings.Cut(price, ":")
+		if !ok || !ok2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid price %q: expected model=input:output", item)
+		}
+		in, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil || in < 0 {
+			return nil, fmt.Errorf("invalid input price in %q", item)
+		}
+		out, err := strconv.ParseFloat(strings.TrimSpace(output), 64)
+		if err != nil || out < 0 {
+			return nil, fmt.Errorf("invalid output price in %q", item)
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{Input: in, Output: out}
+	}
+	return prices, nil
+}
+
+// Cost возвращает стоимость запроса в долларах. Используется цена с самым длинным
+// совпадающим префиксом; если цена модели не задана, возвращает 0.
+func (t PriceTable) Cost(model string, usage Usage) float64 {
+	var (
+		price ModelPrice
+		found string
+	)
+	for name, p := range t {
+		if strings.HasPrefix(model, name) && len(name) > len(found) {
+			price, found = p, name
+		}
+	}
+	if found == "" {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+}
+
+// usageMeter накапливает расход токенов всех запросов одной задачи.
+// Батчи обрабатываются параллельно, поэтому запись защищена мьютексом.
+type usageMeter struct {
+	mu    sync.Mutex
+	usage service.TokenUsage
+}
+
+func (m *usageMeter) add(usage service.TokenUsage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Add(usage)
+}
+
+func (m *usageMeter) total() service.TokenUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+type usageMeterKey struct{}
+
+// withUsageMeter привязывает к ctx счетчик, в который AIClient записывает расход запросов
+func withUsageMeter(ctx context.Context, meter *usageMeter) context.Context {
+	return context.WithValue(ctx, usageMeterKey{}, meter)
+}
+
+// usageMeterFrom возвращает счетчик из ctx или nil
+func usageMeterFrom(ctx context.Context) *usageMeter {
+	meter, _ := ctx.Value(usageMeterKey{}).(*usageMeter)
+	return meter
+}
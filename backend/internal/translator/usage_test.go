@@ -0,0 +1,107 @@
+package translator
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/i18n"
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// TestPriceTableCost проверяет разбор цен и выбор цены по самому длинному префиксу
+func TestPriceTableCost(t *testing.T) {
+	prices, err := ParsePriceTable(" claude-sonnet-4-5=3:15, claude=1:5 ,qwen/qwen3-32b=0.29:0.59")
+	if err != nil {
+		t.Fatalf("ParsePriceTable returned error: %v", err)
+	}
+
+	usage := Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000}
+	if cost := prices.Cost("claude-sonnet-4-5-20250929", usage); math.Abs(cost-4.5) > 1e-9 {
+		t.Errorf("expected 4.5 for dated model name, got %v", cost)
+	}
+	if cost := prices.Cost("claude-haiku", usage); math.Abs(cost-1.5) > 1e-9 {
+		t.Errorf("expected prefix price 1.5, got %v", cost)
+	}
+	if cost := prices.Cost("gpt-4o", usage); cost != 0 {
+		t.Errorf("expected zero cost for unknown model, got %v", cost)
+	}
+
+	for _, value := range []string{"model", "model=1", "model=a:1", "=1:2", "model=1:-2"} {
+		if _, err := ParsePriceTable(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+// TestTranslatorRecordsUsage проверяет учет токенов анализа и резюме в задаче и в журнале по дням
+func TestTranslatorRecordsUsage(t *testing.T) {
+	violations, err := loadDemoJSON()
+	if err != nil {
+		t.Fatalf("failed to load demo json: %v", err)
+	}
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"model":"test-model","choices":[{"message":{"role":"assistant","content":"{\"items\":[]}"}}],` +
+			`"usage":{"prompt_tokens":1000,"completion_tokens":500}}`))
+	}))
+	defer server.Close()
+
+	storage := service.NewMemoryStorage()
+	trans := NewTranslator(testProvider(server.URL), storage, Options{
+		Workers: 1,
+		Prices:  PriceTable{"test-model": {Input: 1, Output: 2}},
+	})
+
+	job := service.NewJob("https://example.com")
+	storage.SaveJob(job)
+	if err := trans.ProcessAnalysis(job, &domain.AnalysisRequest{URL: job.URL, Violations: violations}); err != nil {
+		t.Fatalf("ProcessAnalysis returned error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := storage.GetJob(job.ID)
+		if got.Status == service.StatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not complete, status %s", got.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := trans.GenerateSummary(context.Background(), job.ID, i18n.Russian, "{}"); err != nil {
+		t.Fatalf("GenerateSummary returned error: %v", err)
+	}
+
+	got, _ := storage.GetJob(job.ID)
+	n := int(atomic.LoadInt32(&calls))
+	if got.Usage == nil || got.Usage.Requests != n {
+		t.Fatalf("expected %d recorded requests, got %+v", n, got.Usage)
+	}
+	if got.Usage.PromptTokens != 1000*n || got.Usage.CompletionTokens != 500*n || got.Usage.TotalTokens != 1500*n {
+		t.Errorf("unexpected token counts: %+v", got.Usage)
+	}
+	// 1000 токенов по $1 и 500 по $2 за миллион
+	if want := 0.002 * float64(n); math.Abs(got.Usage.CostUSD-want) > 1e-9 {
+		t.Errorf("expected cost %v, got %v", want, got.Usage.CostUSD)
+	}
+
+	// Тот же расход записан в журнал за день запросов и остается в нем после удаления задачи
+	storage.DeleteJob(job.ID)
+	today := service.UsageDate(time.Now())
+	days, _ := storage.ListDailyUsage(today, today)
+	if len(days) != 1 || days[0].Requests != n || days[0].TotalTokens != 1500*n {
+		t.Errorf("expected %d requests in today's usage, got %+v", n, days)
+	}
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errForbiddenAddress возвращается при попытке отправить уведомление на внутренний адрес
+var errForbiddenAddress = errors.New("callback address is not allowed")
+
+// maxRedirects - сколько перенаправлений callback_url выполняется при доставке
+const maxRedirects = 5
+
+// guard решает, на какие адреса можно отправлять уведомления.
+// Без списка разрешенных хостов доступны любые публичные адреса; со списком - только
+// перечисленные имена хостов и адреса из перечисленных сетей, в том числе внутренние.
+type guard struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+// newGuard разбирает список разрешенных хостов: имена, IP-адреса и сети в нотации CIDR
+func newGuard(allowed []string) (*guard, error) {
+	g := &guard{hosts: make(map[string]bool)}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			g.nets = append(g.nets, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			g.nets = append(g.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if strings.ContainsAny(entry, "/:") {
+			return nil, fmt.Errorf("invalid allowed host %q", entry)
+		}
+		g.hosts[entry] = true
+	}
+	return g, nil
+}
+
+// restricted сообщает, задан ли список разрешенных хостов
+func (g *guard) restricted() bool {
+	return len(g.hosts) > 0 || len(g.nets) > 0
+}
+
+// trustedHost сообщает, указано ли имя хоста в списке разрешенных
+func (g *guard) trustedHost(host string) bool {
+	return g.hosts[strings.ToLower(host)]
+}
+
+// checkURL проверяет адрес до соединения: схему и, если задан список, имя хоста.
+// Адреса, в которые разрешается имя, проверяются при соединении в checkIP.
+func (g *guard) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", errForbiddenAddress, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: empty host", errForbiddenAddress)
+	}
+	if !g.restricted() || g.trustedHost(host) {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && g.inNets(ip) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not in the allowed hosts", errForbiddenAddress, host)
+}
+
+// checkIP проверяет адрес, с которым устанавливается соединение
+func (g *guard) checkIP(ip net.IP) error {
+	if ip == nil {
+		return errForbiddenAddress
+	}
+	if g.inNets(ip) {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, ip)
+	}
+	return nil
+}
+
+func (g *guard) inNets(ip net.IP) bool {
+	for _, network := range g.nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// client возвращает HTTP-клиент, который проверяет каждый адрес соединения после
+// разрешения DNS и каждый адрес перенаправления. Прокси из окружения не используется,
+// иначе проверялся бы адрес прокси, а не получателя.
+func (g *guard) client(timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			trusted := g.trustedHost(host)
+			dialer := &net.Dialer{
+				Timeout: timeout,
+				Control: func(_, address string, _ syscall.RawConn) error {
+					if trusted {
+						return nil
+					}
+					ipStr, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					return g.checkIP(net.ParseIP(ipStr))
+				},
+			}
+			return dialer.DialContext(ctx, network, address)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return g.checkURL(req.URL)
+		},
+	}
+}
@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/redact"
+	"github.com/danil/accessibility-analyzer/internal/service"
+	"github.com/google/uuid"
+)
+
+// Заголовки уведомления
+const (
+	// SignatureHeader содержит HMAC-SHA256 тела запроса в виде "sha256=<hex>"
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader содержит тип уведомления, например job.completed
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader содержит ID доставки, одинаковый для всех повторов
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// Options содержит настройки доставки вебхуков
+type Options struct {
+	// Secret - ключ подписи. Если пустой, заголовок подписи не отправляется
+	Secret string
+	// MaxAttempts - максимальное число попыток доставки
+	MaxAttempts int
+	// Timeout - таймаут одного запроса
+	Timeout time.Duration
+	// Backoff - пауза перед второй попыткой, далее она удваивается
+	Backoff time.Duration
+	// MaxBackoff - верхняя граница паузы между попытками
+	MaxBackoff time.Duration
+	// Redactor убирает персональные данные и секреты из URL страницы и текста ошибки (может быть nil)
+	Redactor *redact.Redactor
+	// AllowedHosts - имена хостов, IP-адреса и сети CIDR, на которые разрешены уведомления.
+	// Пустой список разрешает любые публичные адреса; внутренние адреса (loopback, частные
+	// сети, link-local) доступны, только если перечислены здесь.
+	AllowedHosts []string
+}
+
+// Payload - тело уведомления о завершении задачи
+type Payload struct {
+	Event      string       `json:"event"`
+	DeliveryID string       `json:"delivery_id"`
+	Timestamp  time.Time    `json:"timestamp"`
+	Job        *service.Job `json:"job"`
+	// ReportURL и Summary заполняются, если задача завершилась успешно
+	ReportURL string                `json:"report_url,omitempty"`
+	Summary   *domain.ReportSummary `json:"summary,omitempty"`
+}
+
+// Notifier отправляет подписанные уведомления о завершении задач на callback_url из запроса.
+// Каждая попытка доставки записывается в хранилище.
+type Notifier struct {
+	storage service.Storage
+	guard   *guard
+	client  *http.Client
+	opts    Options
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewNotifier создает новый Notifier. Возвращает ошибку, если список разрешенных хостов некорректен.
+func NewNotifier(storage service.Storage, opts Options) (*Notifier, error) {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	guard, err := newGuard(opts.AllowedHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notifier{
+		storage: storage,
+		guard:   guard,
+		client:  guard.client(opts.Timeout),
+		opts:    opts,
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// JobFinished асинхронно отправляет уведомление, если в исходном запросе задачи указан callback_url
+func (n *Notifier) JobFinished(job *service.Job) {
+	req, err := n.storage.GetRequest(job.ID)
+	if err != nil || req.CallbackURL == "" {
+		return
+	}
+
+	job = job.Clone()
+	job.URL = n.opts.Redactor.String(job.URL)
+	job.Error = n.opts.Redactor.String(job.Error)
+
+	payload := Payload{
+		Event:      "job." + string(job.Status),
+		DeliveryID: uuid.New().String(),
+		Timestamp:  time.Now().UTC(),
+		Job:        job,
+	}
+	if job.Status == service.StatusCompleted {
+		payload.ReportURL = "/api/v1/jobs/" + job.ID + "/report"
+		if report, err := n.storage.GetReport(job.ID); err == nil {
+			payload.Summary = &report.Summary
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("[Webhook] Failed to marshal payload for job %s: %v", job.ID, err)
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.deliver(req.CallbackURL, payload, body)
+	}()
+}
+
+// Close прекращает повторы и дожидается завершения текущих запросов
+func (n *Notifier) Close() {
+	n.once.Do(func() { close(n.stop) })
+	n.wg.Wait()
+}
+
+// deliver отправляет уведомление, повторяя попытки с экспоненциальной паузой
+func (n *Notifier) deliver(url string, payload Payload, body []byte) {
+	delay := n.opts.Backoff
+
+	for attempt := 1; attempt <= n.opts.MaxAttempts; attempt++ {
+		if n.deleted(payload.Job.ID) {
+			log.Printf("[Webhook] Job %s was deleted, dropping %s", payload.Job.ID, payload.Event)
+			return
+		}
+
+		started := time.Now()
+		statusCode, err := n.send(url, payload, body)
+
+		record := &service.WebhookDelivery{
+			ID:         payload.DeliveryID,
+			JobID:      payload.Job.ID,
+			Event:      payload.Event,
+			URL:        url,
+			Attempt:    attempt,
+			StatusCode: statusCode,
+			Success:    err == nil,
+			DurationMs: time.Since(started).Milliseconds(),
+			CreatedAt:  started,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		retry := err != nil && !errors.Is(err, errForbiddenAddress) && retryable(statusCode) && attempt < n.opts.MaxAttempts
+		if retry {
+			next := time.Now().Add(delay)
+			record.NextRetryAt = &next
+		}
+
+		// Задачу могли удалить во время запроса: запись о доставке осталась бы без задачи
+		if n.deleted(payload.Job.ID) {
+			log.Printf("[Webhook] Job %s was deleted, dropping %s", payload.Job.ID, payload.Event)
+			return
+		}
+		if saveErr := n.storage.SaveWebhookDelivery(record); saveErr != nil {
+			log.Printf("[Webhook] Failed to record delivery for job %s: %v", payload.Job.ID, saveErr)
+		}
+
+		if err == nil {
+			log.Printf("[Webhook] Delivered %s for job %s (attempt %d)", payload.Event, payload.Job.ID, attempt)
+			return
+		}
+		log.Printf("[Webhook] Delivery of %s for job %s failed (attempt %d): %v", payload.Event, payload.Job.ID, attempt, err)
+		if !retry {
+			return
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-n.stop:
+			return
+		}
+
+		delay *= 2
+		if delay > n.opts.MaxBackoff {
+			delay = n.opts.MaxBackoff
+		}
+	}
+}
+
+// deleted сообщает, что задачи уже нет в хранилище: уведомление о ней не отправляется и не записывается
+func (n *Notifier) deleted(jobID string) bool {
+	_, err := n.storage.GetJob(jobID)
+	return errors.Is(err, service.ErrJobNotFound)
+}
+
+// send выполняет одну попытку доставки. Возвращает HTTP-статус ответа (0, если ответа не было).
+func (n *Notifier) send(url string, payload Payload, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if err := n.guard.checkURL(req.URL); err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "accessibility-analyzer-webhook/1.0")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.DeliveryID)
+	if n.opts.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.opts.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable сообщает, имеет ли смысл повторять запрос после такого ответа
+func retryable(statusCode int) bool {
+	return statusCode == 0 ||
+		statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode >= 500
+}
+
+// Sign возвращает значение заголовка подписи для тела уведомления.
+// Получатель проверяет подпись, вычислив HMAC-SHA256 тела запроса своим секретом.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,289 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danil/accessibility-analyzer/internal/domain"
+	"github.com/danil/accessibility-analyzer/internal/redact"
+	"github.com/danil/accessibility-analyzer/internal/service"
+)
+
+// newFinishedJob сохраняет завершенную задачу с отчетом и callback_url
+func newFinishedJob(t *testing.T, storage service.Storage, callbackURL string) *service.Job {
+	t.Helper()
+
+	job := service.NewJob("https://example.com")
+	job.UpdateStatus(service.StatusCompleted)
+	job.SetProgress(100)
+	storage.SaveJob(job)
+	storage.SaveRequest(job.ID, &domain.AnalysisRequest{URL: job.URL, CallbackURL: callbackURL})
+	storage.SaveReport(&domain.Report{ID: job.ID, URL: job.URL, Summary: domain.ReportSummary{TotalIssues: 3, Critical: 1}})
+	return job
+}
+
+// newTestNotifier создает Notifier, которому разрешены уведомления на локальный тестовый сервер
+func newTestNotifier(t *testing.T, storage service.Storage, opts Options) *Notifier {
+	t.Helper()
+
+	opts.AllowedHosts = append(opts.AllowedHosts, "127.0.0.1")
+	notifier, err := NewNotifier(storage, opts)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	return notifier
+}
+
+// TestNotifierSignsAndRetries проверяет подпись уведомления и повтор после ошибки получателя
+func TestNotifierSignsAndRetries(t *testing.T) {
+	const secret = "test-secret"
+
+	var (
+		mu       sync.Mutex
+		requests int
+		payloads []Payload
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+
+		if r.Header.Get(SignatureHeader) != Sign(secret, body) {
+			t.Errorf("invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		var payload Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+
+		// Первая попытка завершается ошибкой сервера, вторая - успешно
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	storage := service.NewMemoryStorage()
+	job := newFinishedJob(t, storage, receiver.URL)
+
+	notifier := newTestNotifier(t, storage, Options{Secret: secret, MaxAttempts: 3, Backoff: 10 * time.Millisecond})
+	notifier.JobFinished(job)
+	notifier.wg.Wait()
+
+	deliveries, _ := storage.ListWebhookDeliveries(job.ID)
+	if len(deliveries) != 2 {
+		t.Fatalf("expected 2 delivery attempts, got %d", len(deliveries))
+	}
+	if deliveries[0].Success || deliveries[0].StatusCode != http.StatusBadGateway || deliveries[0].NextRetryAt == nil {
+		t.Errorf("unexpected first attempt: %+v", deliveries[0])
+	}
+	if !deliveries[1].Success || deliveries[1].Attempt != 2 || deliveries[1].ID != deliveries[0].ID {
+		t.Errorf("unexpected second attempt: %+v", deliveries[1])
+	}
+
+	payload := payloads[len(payloads)-1]
+	if payload.Event != "job.completed" || payload.Job.ID != job.ID {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	if payload.Summary == nil || payload.Summary.TotalIssues != 3 || payload.ReportURL == "" {
+		t.Errorf("expected report summary and link in payload, got %+v", payload)
+	}
+}
+
+// TestNotifierDoesNotRetryClientErrors проверяет, что ответ 4xx не повторяется
+func TestNotifierDoesNotRetryClientErrors(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer receiver.Close()
+
+	storage := service.NewMemoryStorage()
+	job := newFinishedJob(t, storage, receiver.URL)
+
+	notifier := newTestNotifier(t, storage, Options{MaxAttempts: 5, Backoff: time.Millisecond})
+	notifier.JobFinished(job)
+	notifier.wg.Wait()
+
+	deliveries, _ := storage.ListWebhookDeliveries(job.ID)
+	if len(deliveries) != 1 || deliveries[0].Success || deliveries[0].NextRetryAt != nil {
+		t.Errorf("expected a single failed attempt, got %+v", deliveries)
+	}
+}
+
+// TestNotifierDropsDeletedJob проверяет, что уведомление об удаленной задаче не записывается и не повторяется
+func TestNotifierDropsDeletedJob(t *testing.T) {
+	storage := service.NewMemoryStorage()
+	var requests int32
+	var jobID string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// Задачу удаляют, пока получатель обрабатывает уведомление
+		storage.DeleteJob(jobID)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	job := newFinishedJob(t, storage, receiver.URL)
+	jobID = job.ID
+
+	notifier := newTestNotifier(t, storage, Options{MaxAttempts: 3, Backoff: time.Millisecond})
+	notifier.JobFinished(job)
+	notifier.wg.Wait()
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected delivery to stop after the job was deleted, got %d requests", got)
+	}
+	if deliveries, _ := storage.ListWebhookDeliveries(job.ID); len(deliveries) != 0 {
+		t.Errorf("expected no delivery records for a deleted job, got %+v", deliveries)
+	}
+}
+
+// TestNotifierRedactsPayload проверяет удаление токенов и персональных данных из уведомления
+func TestNotifierRedactsPayload(t *testing.T) {
+	bodies := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	storage := service.NewMemoryStorage()
+	job := service.NewJob("https://example.com/account?session=f3a9c1d7e2&tab=orders")
+	job.SetError("AI provider error for ivan.petrov@example.com")
+	storage.SaveJob(job)
+	storage.SaveRequest(job.ID, &domain.AnalysisRequest{URL: job.URL, CallbackURL: receiver.URL})
+
+	redactor, _ := redact.New("")
+	notifier := newTestNotifier(t, storage, Options{MaxAttempts: 1, Redactor: redactor})
+	notifier.JobFinished(job)
+	notifier.wg.Wait()
+
+	body := <-bodies
+	for _, secret := range []string{"f3a9c1d7e2", "ivan.petrov@example.com"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("%q was not redacted: %s", secret, body)
+		}
+	}
+	if !strings.Contains(body, job.ID) || !strings.Contains(body, "tab=orders") {
+		t.Errorf("unexpected payload: %s", body)
+	}
+	if stored, _ := storage.GetJob(job.ID); stored.URL != job.URL {
+		t.Errorf("stored job must not be changed, got %q", stored.URL)
+	}
+}
+
+// TestNotifierBlocksInternalAddresses проверяет, что без списка разрешенных хостов
+// уведомления на внутренние адреса не отправляются и не повторяются
+func TestNotifierBlocksInternalAddresses(t *testing.T) {
+	var requests int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	notifier, err := NewNotifier(service.NewMemoryStorage(), Options{MaxAttempts: 3, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	// localhost разрешается в loopback только при соединении, поэтому проверку выполняет Dialer
+	localhost := strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{
+		receiver.URL,
+		localhost,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		storage := service.NewMemoryStorage()
+		notifier.storage = storage
+		job := newFinishedJob(t, storage, target)
+		notifier.JobFinished(job)
+		notifier.wg.Wait()
+
+		deliveries, _ := storage.ListWebhookDeliveries(job.ID)
+		if len(deliveries) != 1 || deliveries[0].Success || deliveries[0].NextRetryAt != nil {
+			t.Errorf("%s: expected a single rejected attempt, got %+v", target, deliveries)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("internal receiver must not be called, got %d requests", n)
+	}
+}
+
+// TestNotifierChecksRedirects проверяет, что перенаправление на внутренний адрес не выполняется
+func TestNotifierChecksRedirects(t *testing.T) {
+	var internalRequests int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&internalRequests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer internal.Close()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(internal.URL, "127.0.0.1", "localhost", 1), http.StatusTemporaryRedirect)
+	}))
+	defer redirector.Close()
+
+	// Разрешен только адрес перенаправляющего сервера, а не localhost
+	redirectorHost := strings.TrimPrefix(redirector.URL, "http://")
+	redirectorHost = redirectorHost[:strings.LastIndex(redirectorHost, ":")]
+	storage := service.NewMemoryStorage()
+	notifier, err := NewNotifier(storage, Options{MaxAttempts: 1, AllowedHosts: []string{redirectorHost}})
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	job := newFinishedJob(t, storage, redirector.URL)
+	notifier.JobFinished(job)
+	notifier.wg.Wait()
+
+	deliveries, _ := storage.ListWebhookDeliveries(job.ID)
+	if len(deliveries) != 1 || deliveries[0].Success {
+		t.Errorf("expected a failed delivery, got %+v", deliveries)
+	}
+	if n := atomic.LoadInt32(&internalRequests); n != 0 {
+		t.Errorf("redirect target must not be called, got %d requests", n)
+	}
+}
+
+// TestNotifierAllowedHosts проверяет список разрешенных хостов
+func TestNotifierAllowedHosts(t *testing.T) {
+	if _, err := NewNotifier(service.NewMemoryStorage(), Options{AllowedHosts: []string{"http://example.com/hook"}}); err == nil {
+		t.Error("expected an error for a URL in the allowed hosts")
+	}
+
+	notifier, err := NewNotifier(service.NewMemoryStorage(), Options{AllowedHosts: []string{"hooks.example.com", "10.1.0.0/16"}})
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	for raw, allowed := range map[string]bool{
+		"https://hooks.example.com/ci": true,
+		"https://HOOKS.example.com/ci": true,
+		"http://10.1.2.3/hook":         true,
+		"https://other.example.com/ci": false,
+		"http://10.2.0.1/hook":         false,
+		"ftp://hooks.example.com/ci":   false,
+	} {
+		u, _ := url.Parse(raw)
+		if err := notifier.guard.checkURL(u); (err == nil) != allowed {
+			t.Errorf("%s: allowed=%v, got error %v", raw, allowed, err)
+		}
+	}
+}